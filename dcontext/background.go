@@ -0,0 +1,30 @@
+package dcontext
+
+import (
+	"context"
+)
+
+var (
+	background = WithSoftness(context.Background())
+	todo       = WithSoftness(context.TODO())
+)
+
+// Background is like context.Background, but already has a hard/soft
+// distinction; it is the same as WithSoftness(context.Background()).
+// This is a convenient root for code that only needs to trigger
+// graceful (soft) shutdowns:
+//
+//	ctx, softCancel := context.WithCancel(dcontext.Background())
+//
+// Since the hard Context is context.Background(), it is never
+// canceled; if you also need to be able to trigger a hard shutdown,
+// then use WithSoftness on a Context that you can cancel instead.
+func Background() context.Context {
+	return background
+}
+
+// TODO is like context.TODO, but already has a hard/soft distinction;
+// it is the same as WithSoftness(context.TODO()).  See Background.
+func TODO() context.Context {
+	return todo
+}
@@ -0,0 +1,55 @@
+package dcontext
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	detachableMu   sync.RWMutex
+	detachableKeys []interface{}
+)
+
+// RegisterDetachable marks a Context value key as one that Detach
+// should carry over to the detached Context.  It is intended to be
+// called from the init function of packages that store
+// framework-level values in a Context (for example, dlog registers
+// the key for its logger, so that log fields survive Detach).  As
+// with context.WithValue, the key must be comparable, and should be of
+// an unexported type specific to the package.
+func RegisterDetachable(key interface{}) {
+	detachableMu.Lock()
+	defer detachableMu.Unlock()
+	for _, existing := range detachableKeys {
+		if existing == key {
+			return
+		}
+	}
+	detachableKeys = append(detachableKeys, key)
+}
+
+// Detach returns a fresh Context for doing work (such as cleanup)
+// that should continue even after ctx is canceled.  It has no
+// deadline or cancellation (and no hard/soft distinction), and it
+// carries:
+//
+//   - the values of ctx that were attached with WithValue (the same as
+//     WithoutCancelPreserving), and
+//   - the values of ctx for any key registered with
+//     RegisterDetachable, such as the dlog logger and the dtime clock.
+//
+// Unlike WithoutCancel, other values of ctx are not carried over, and
+// ctx is not kept reachable from the returned Context.
+func Detach(ctx context.Context) context.Context {
+	ret := WithoutCancelPreserving(ctx)
+
+	detachableMu.RLock()
+	keys := detachableKeys
+	detachableMu.RUnlock()
+	for _, key := range keys {
+		if val := ctx.Value(key); val != nil {
+			ret = context.WithValue(ret, key, val)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,63 @@
+package dcontext_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dlog"
+	dtime "github.com/datawire/dlib/dtime/v2"
+)
+
+func TestDetach(t *testing.T) {
+	type requestIDKey struct{}
+	type frameworkKey struct{}
+	type otherKey struct{}
+	dcontext.RegisterDetachable(frameworkKey{})
+	dcontext.RegisterDetachable(frameworkKey{}) // registering twice is fine
+
+	fc := dtime.NewFakeClock()
+
+	var logOutput strings.Builder
+	logger := logrus.New()
+	logger.SetOutput(&logOutput)
+	logger.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+
+	ctx, cancel := context.WithCancel(dlog.WithLogger(context.Background(), dlog.WrapLogrus(logger)))
+	ctx = dlog.WithField(ctx, "field", "value")
+	ctx = dtime.WithClock(ctx, fc)
+	ctx = dcontext.WithValue(ctx, requestIDKey{}, "req-1")
+	ctx = context.WithValue(ctx, frameworkKey{}, "framework")
+	ctx = context.WithValue(ctx, otherKey{}, "other")
+	ctx = dcontext.WithSoftness(ctx)
+	cancel()
+
+	detached := dcontext.Detach(ctx)
+
+	// Cancellation is stripped.
+	assert.NoError(t, detached.Err())
+	assert.Nil(t, detached.Done())
+	_, hasDeadline := detached.Deadline()
+	assert.False(t, hasDeadline)
+	assert.False(t, dcontext.IsSoftContext(detached))
+
+	// Values are selectively carried over.
+	assert.Equal(t, "req-1", detached.Value(requestIDKey{}))
+	assert.Equal(t, "framework", detached.Value(frameworkKey{}))
+	assert.Nil(t, detached.Value(otherKey{}))
+
+	// Framework values: dlog fields and the dtime clock.
+	dlog.Info(detached, "hello")
+	assert.Equal(t, "level=info msg=hello field=value\n", logOutput.String())
+	val, ok := dlog.FieldValue(detached, "field")
+	assert.True(t, ok)
+	assert.Equal(t, "value", val)
+	assert.Equal(t, fc.Now(), dtime.Now(detached))
+	fc.Step(time.Hour)
+	assert.Equal(t, fc.Now(), dtime.Now(detached))
+}
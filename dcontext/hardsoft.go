@@ -127,8 +127,14 @@ type childHardContext struct {
 func (c childHardContext) Deadline() (deadline time.Time, ok bool) { return c.hardCtx.Deadline() }
 func (c childHardContext) Done() <-chan struct{}                   { return c.hardCtx.Done() }
 func (c childHardContext) Err() error                              { return c.hardCtx.Err() }
-func (c childHardContext) Value(key interface{}) interface{}       { return c.softCtx.Value(key) }
 func (c childHardContext) String() string                          { return contextName(c.softCtx) + ".HardContext" }
+func (c childHardContext) Value(key interface{}) interface{} {
+	if key == (parentHardContextKey{}) {
+		// The hard Context has no softness of its own.
+		return nil
+	}
+	return c.softCtx.Value(key)
+}
 
 // HardContext takes a child Context that is canceled sooner (a "soft"
 // cancellation) and returns a Context with the same values, but with the
@@ -148,3 +154,24 @@ func HardContext(softCtx context.Context) context.Context {
 		softCtx: softCtx,
 	}
 }
+
+// WithoutSoftness returns a Context that has the values of ctx, but
+// that has no hard/soft distinction; it is canceled only when
+// HardContext(ctx) is canceled.  This is the complement to
+// WithSoftness, and is useful for launching a sub-task that should not
+// respond to a graceful shutdown, but only to a not-so-graceful one.
+//
+// This is the same as HardContext(ctx); it exists to document intent
+// at the call site.
+func WithoutSoftness(ctx context.Context) context.Context {
+	return HardContext(ctx)
+}
+
+// IsSoftContext returns whether the Context has a hard/soft
+// distinction; that is, whether WithSoftness is somewhere in its
+// ancestry (and hasn't been stripped by HardContext or WithoutCancel),
+// such that HardContext(ctx) would return a different Context, which
+// is canceled later than ctx.
+func IsSoftContext(ctx context.Context) bool {
+	return ctx.Value(parentHardContextKey{}) != nil
+}
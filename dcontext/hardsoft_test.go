@@ -0,0 +1,84 @@
+package dcontext_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+)
+
+func TestIsSoftContext(t *testing.T) {
+	ctx := context.Background()
+	ctxS := dcontext.WithSoftness(ctx)
+	ctxSH := dcontext.HardContext(ctxS)
+	ctxSHH := dcontext.HardContext(ctxSH)
+	ctxSHS := dcontext.WithSoftness(ctxSH)
+	ctxSW := dcontext.WithoutCancel(ctxS)
+
+	testcases := map[string]struct {
+		Ctx  context.Context
+		Soft bool
+	}{
+		"ctx":    {ctx, false},
+		"ctxS":   {ctxS, true},
+		"ctxSH":  {ctxSH, false},
+		"ctxSHH": {ctxSHH, false},
+		"ctxSHS": {ctxSHS, true},
+		"ctxSW":  {ctxSW, false},
+	}
+	for tcName, tcData := range testcases {
+		tcData := tcData
+		t.Run(tcName, func(t *testing.T) {
+			assert.Equal(t, tcData.Soft, dcontext.IsSoftContext(tcData.Ctx))
+			assert.Equal(t, tcData.Soft, dcontext.HardContext(tcData.Ctx) != tcData.Ctx)
+		})
+	}
+}
+
+func TestWithoutSoftness(t *testing.T) {
+	type ctxKey struct{}
+
+	hardCtx, hardCancel := context.WithCancel(context.Background())
+	defer hardCancel()
+	softCtx, softCancel := context.WithCancel(dcontext.WithSoftness(hardCtx))
+	defer softCancel()
+	softCtx = context.WithValue(softCtx, ctxKey{}, "foo")
+
+	ctx := dcontext.WithoutSoftness(softCtx)
+	assert.False(t, dcontext.IsSoftContext(ctx))
+	assert.Equal(t, "foo", ctx.Value(ctxKey{}))
+
+	softCancel()
+	assert.Error(t, softCtx.Err())
+	assert.False(t, isClosed(ctx.Done()))
+	assert.NoError(t, ctx.Err())
+
+	hardCancel()
+	assert.True(t, isClosed(ctx.Done()))
+	assert.Equal(t, context.Canceled, ctx.Err())
+}
+
+func TestBackground(t *testing.T) {
+	for name, fn := range map[string]func() context.Context{
+		"Background": dcontext.Background,
+		"TODO":       dcontext.TODO,
+	} {
+		fn := fn
+		t.Run(name, func(t *testing.T) {
+			ctx := fn()
+			assert.True(t, dcontext.IsSoftContext(ctx))
+			hardCtx := dcontext.HardContext(ctx)
+			assert.NotEqual(t, ctx, hardCtx, "HardContext should strip the soft layer")
+			assert.False(t, dcontext.IsSoftContext(hardCtx))
+			assert.Nil(t, ctx.Done())
+			assert.Nil(t, hardCtx.Done())
+
+			softCtx, softCancel := context.WithCancel(ctx)
+			softCancel()
+			assert.Error(t, softCtx.Err())
+			assert.NoError(t, dcontext.HardContext(softCtx).Err())
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package dcontext
+
+import (
+	"context"
+)
+
+type mergedContext struct {
+	context.Context // context.WithCancel(primary)
+	primary         context.Context
+	secondary       context.Context
+	hardCtx         context.Context // nil if primary is not soft
+}
+
+func (c *mergedContext) Err() error {
+	err := c.Context.Err()
+	if err != nil && c.primary.Err() == nil {
+		if secondaryErr := c.secondary.Err(); secondaryErr != nil {
+			return secondaryErr
+		}
+	}
+	return err
+}
+
+func (c *mergedContext) Value(key interface{}) interface{} {
+	if key == (parentHardContextKey{}) && c.hardCtx != nil {
+		return c.hardCtx
+	}
+	return c.Context.Value(key)
+}
+
+func (c *mergedContext) String() string {
+	return contextName(c.primary) + ".Merge(" + contextName(c.secondary) + ")"
+}
+
+// mergeCancel is Merge without any hard/soft awareness.
+func mergeCancel(primary, secondary context.Context) (*mergedContext, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(primary)
+	if secondaryDone := secondary.Done(); secondaryDone != nil {
+		go func() {
+			select {
+			case <-secondaryDone:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+	return &mergedContext{
+		Context:   ctx,
+		primary:   primary,
+		secondary: secondary,
+	}, cancel
+}
+
+// Merge returns a Context that is canceled when either primary or
+// secondary is canceled (or when the returned CancelFunc is called).
+// Values and the deadline are drawn from primary only.
+//
+// The hard/soft-ness of primary is preserved: If primary is a soft
+// Context, then the returned Context is also soft, and its
+// HardContext is canceled when either HardContext(primary) or
+// HardContext(secondary) is canceled.  If primary is not soft, then
+// neither is the returned Context, even if secondary is soft.
+//
+// Canceling the returned Context releases resources associated with
+// it, so code should call the CancelFunc as soon as the operations
+// running in the Context complete.
+func Merge(primary, secondary context.Context) (context.Context, context.CancelFunc) {
+	softCtx, softCancel := mergeCancel(primary, secondary)
+	if HardContext(primary) == primary {
+		return softCtx, softCancel
+	}
+	hardCtx, hardCancel := mergeCancel(HardContext(primary), HardContext(secondary))
+	softCtx.hardCtx = hardCtx
+	return softCtx, func() {
+		softCancel()
+		hardCancel()
+	}
+}
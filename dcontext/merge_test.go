@@ -0,0 +1,85 @@
+package dcontext_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+)
+
+type mergeInput struct {
+	ctx        context.Context
+	softCancel context.CancelFunc
+	hardCancel context.CancelFunc
+}
+
+func newMergeInput(soft bool) mergeInput {
+	hardCtx, hardCancel := context.WithCancel(context.Background())
+	if !soft {
+		return mergeInput{ctx: hardCtx, softCancel: hardCancel, hardCancel: hardCancel}
+	}
+	softCtx, softCancel := context.WithCancel(dcontext.WithSoftness(hardCtx))
+	return mergeInput{ctx: softCtx, softCancel: softCancel, hardCancel: hardCancel}
+}
+
+func TestMerge(t *testing.T) {
+	type ctxKey struct{}
+
+	testcases := map[string]struct {
+		PrimarySoft   bool
+		SecondarySoft bool
+	}{
+		"hard+hard": {false, false},
+		"hard+soft": {false, true},
+		"soft+hard": {true, false},
+		"soft+soft": {true, true},
+	}
+	for tcName, tcData := range testcases {
+		tcData := tcData
+		t.Run(tcName, func(t *testing.T) {
+			for _, cancelPrimary := range []bool{true, false} {
+				primary := newMergeInput(tcData.PrimarySoft)
+				primary.ctx = context.WithValue(primary.ctx, ctxKey{}, "primary")
+				secondary := newMergeInput(tcData.SecondarySoft)
+				secondary.ctx = context.WithValue(secondary.ctx, ctxKey{}, "secondary")
+
+				ctx, cancel := dcontext.Merge(primary.ctx, secondary.ctx)
+				hardCtx := dcontext.HardContext(ctx)
+				assert.Equal(t, tcData.PrimarySoft, hardCtx != ctx)
+				assert.Equal(t, "primary", ctx.Value(ctxKey{}))
+				assert.Equal(t, "primary", hardCtx.Value(ctxKey{}))
+				assert.NoError(t, ctx.Err())
+				assert.NoError(t, hardCtx.Err())
+
+				in := secondary
+				if cancelPrimary {
+					in = primary
+				}
+
+				in.softCancel()
+				<-ctx.Done()
+				assert.Equal(t, context.Canceled, ctx.Err())
+				if tcData.PrimarySoft && (cancelPrimary || tcData.SecondarySoft) {
+					assert.NoError(t, hardCtx.Err())
+				}
+
+				in.hardCancel()
+				<-hardCtx.Done()
+				assert.Equal(t, context.Canceled, hardCtx.Err())
+
+				cancel()
+				primary.hardCancel()
+				secondary.hardCancel()
+			}
+		})
+	}
+}
+
+func TestMergeCancel(t *testing.T) {
+	ctx, cancel := dcontext.Merge(context.Background(), context.Background())
+	assert.NoError(t, ctx.Err())
+	cancel()
+	assert.Equal(t, context.Canceled, ctx.Err())
+}
@@ -0,0 +1,43 @@
+package dcontext
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// WithSoftTimeout is the hard/soft-aware equivalent of
+// context.WithTimeout.  It returns a soft Context that is canceled
+// after the soft timeout, and whose HardContext is canceled after the
+// hard timeout.  Calling the returned CancelFunc cancels both.
+//
+// It panics if hard is shorter than soft.
+func WithSoftTimeout(parent context.Context, soft, hard time.Duration) (context.Context, context.CancelFunc) {
+	if hard < soft {
+		panic(fmt.Errorf("dcontext.WithSoftTimeout: hard timeout (%v) is shorter than soft timeout (%v)", hard, soft))
+	}
+	now := time.Now()
+	return withSoftDeadline(parent, now.Add(soft), now.Add(hard))
+}
+
+// WithSoftDeadline is the hard/soft-aware equivalent of
+// context.WithDeadline.  It returns a soft Context that is canceled at
+// softDeadline, and whose HardContext is canceled at hardDeadline.
+// Calling the returned CancelFunc cancels both.
+//
+// It panics if hardDeadline is before softDeadline.
+func WithSoftDeadline(parent context.Context, softDeadline, hardDeadline time.Time) (context.Context, context.CancelFunc) {
+	if hardDeadline.Before(softDeadline) {
+		panic(fmt.Errorf("dcontext.WithSoftDeadline: hard deadline (%v) is before soft deadline (%v)", hardDeadline, softDeadline))
+	}
+	return withSoftDeadline(parent, softDeadline, hardDeadline)
+}
+
+func withSoftDeadline(parent context.Context, softDeadline, hardDeadline time.Time) (context.Context, context.CancelFunc) {
+	hardCtx, hardCancel := context.WithDeadline(parent, hardDeadline)
+	softCtx, softCancel := context.WithDeadline(WithSoftness(hardCtx), softDeadline)
+	return softCtx, func() {
+		softCancel()
+		hardCancel()
+	}
+}
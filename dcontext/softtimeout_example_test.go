@@ -0,0 +1,28 @@
+package dcontext_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/datawire/dlib/dcontext"
+)
+
+// WithSoftTimeout replaces the usual setup of a hard Context with a
+// deadline, made soft with WithSoftness, and then given a shorter
+// deadline.
+func ExampleWithSoftTimeout() {
+	ctx, cancel := dcontext.WithSoftTimeout(context.Background(),
+		10*time.Millisecond, // graceful shutdown starts after this
+		20*time.Millisecond) // and is cut short after this
+	defer cancel()
+
+	<-ctx.Done()
+	fmt.Println("soft:", ctx.Err())
+	<-dcontext.HardContext(ctx).Done()
+	fmt.Println("hard:", dcontext.HardContext(ctx).Err())
+
+	// Output:
+	// soft: context deadline exceeded
+	// hard: context deadline exceeded
+}
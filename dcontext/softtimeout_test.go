@@ -0,0 +1,55 @@
+package dcontext_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+)
+
+func TestWithSoftTimeout(t *testing.T) {
+	ctx, cancel := dcontext.WithSoftTimeout(context.Background(), 100*time.Millisecond, 200*time.Millisecond)
+	defer cancel()
+	hardCtx := dcontext.HardContext(ctx)
+	assert.NotEqual(t, ctx, hardCtx)
+
+	softDeadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	hardDeadline, ok := hardCtx.Deadline()
+	assert.True(t, ok)
+	assert.Equal(t, 100*time.Millisecond, hardDeadline.Sub(softDeadline))
+
+	<-ctx.Done()
+	assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+	assert.NoError(t, hardCtx.Err())
+
+	<-hardCtx.Done()
+	assert.Equal(t, context.DeadlineExceeded, hardCtx.Err())
+}
+
+func TestWithSoftDeadlineCancel(t *testing.T) {
+	now := time.Now()
+	ctx, cancel := dcontext.WithSoftDeadline(context.Background(), now.Add(time.Hour), now.Add(2*time.Hour))
+	hardCtx := dcontext.HardContext(ctx)
+	assert.NoError(t, ctx.Err())
+	assert.NoError(t, hardCtx.Err())
+
+	cancel()
+	assert.Equal(t, context.Canceled, ctx.Err())
+	assert.Equal(t, context.Canceled, hardCtx.Err())
+}
+
+func TestWithSoftTimeoutInvalid(t *testing.T) {
+	assert.Panics(t, func() {
+		_, cancel := dcontext.WithSoftTimeout(context.Background(), 2*time.Second, time.Second)
+		cancel()
+	})
+	now := time.Now()
+	assert.Panics(t, func() {
+		_, cancel := dcontext.WithSoftDeadline(context.Background(), now.Add(time.Second), now)
+		cancel()
+	})
+}
@@ -0,0 +1,151 @@
+package dcontext
+
+import (
+	"context"
+	"strings"
+)
+
+// TraceContext returns a human-readable, multi-line description of the
+// chain of Contexts that ctx was built from, for debugging.  There is
+// one line per layer, starting with ctx itself and ending with the
+// root (usually "Background").  Each line names the function that
+// created that layer (WithCancel, WithValue, WithSoftness,
+// HardContext, WithoutCancel, ...), and for WithValue layers says what
+// the key is (but not the value, which is none of our business).  For
+// example:
+//
+//	HardContext [done: context canceled]
+//	WithValue(dlog.loggerContextKey)
+//	WithSoftness
+//	WithCancel
+//	Background
+//
+// The layers added by this package, and the layer directly beneath
+// them, are noted if they are done; other layers are described by the
+// standard library's String methods, which don't say whether they are
+// done.
+//
+// The exact output format is not stable, and may change between dlib
+// or Go versions; it should not be parsed.
+//
+// TraceContext(nil) returns "<nil>".
+func TraceContext(ctx context.Context) string {
+	if ctx == nil {
+		return "<nil>"
+	}
+	var lines []string
+	for ctx != nil {
+		line, parent, ok := traceLayer(ctx)
+		if !ok {
+			// Not one of ours; fall back to parsing its String.
+			layers := traceString(contextName(ctx))
+			if err := ctx.Err(); err != nil {
+				layers[0] += " [done: " + err.Error() + "]"
+			}
+			lines = append(lines, layers...)
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			line += " [done: " + err.Error() + "]"
+		}
+		lines = append(lines, line)
+		ctx = parent
+	}
+	return strings.Join(lines, "\n")
+}
+
+// traceLayer describes the outermost layer of ctx, and returns the
+// Context that it wraps (or nil if it is a root); it returns false if
+// ctx was not created by this package.
+func traceLayer(ctx context.Context) (string, context.Context, bool) {
+	switch c := ctx.(type) {
+	case childHardContext:
+		return "HardContext", c.softCtx, true
+	case withoutCancel:
+		return "WithoutCancel", c.Context, true
+	case withoutCancelPreserving:
+		return "WithoutCancelPreserving", nil, true
+	case *mergedContext:
+		return "Merge(" + contextName(c.secondary) + ")", c.primary, true
+	}
+	return "", nil, false
+}
+
+// traceString splits the String of a Context (as built by the
+// standard library, in which each layer appends ".Layer(args)" to its
+// parent's String) in to layers, outermost first.
+func traceString(str string) []string {
+	var layers []string
+	depth, start := 0, 0
+	for i := 0; i < len(str); i++ {
+		switch str[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '.':
+			if depth == 0 && i > start && isTraceLayer(str[i+1:]) {
+				layers = append(layers, traceName(str[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	layers = append(layers, traceName(str[start:]))
+
+	// Reverse, so that the outermost layer is first.
+	for i, j := 0, len(layers)-1; i < j; i, j = i+1, j-1 {
+		layers[i], layers[j] = layers[j], layers[i]
+	}
+	return layers
+}
+
+// traceLayerNames are the names that the standard library's (and
+// this package's) Context String methods give to layers.
+var traceLayerNames = []string{
+	"WithCancel",
+	"WithDeadline(",
+	"WithValue(",
+	"WithoutCancel",
+	"HardContext",
+	"Merge(",
+}
+
+func isTraceLayer(str string) bool {
+	for _, name := range traceLayerNames {
+		if !strings.HasPrefix(str, name) {
+			continue
+		}
+		// Make sure that "WithCancel" doesn't match "WithCancelFoo".
+		rest := str[len(name):]
+		if strings.HasSuffix(name, "(") || rest == "" || rest[0] == '.' {
+			return true
+		}
+	}
+	return false
+}
+
+// traceName turns a single layer of a Context's String in to the name
+// that TraceContext uses for it.
+func traceName(layer string) string {
+	switch {
+	case layer == "context.Background":
+		return "Background"
+	case layer == "context.TODO":
+		return "TODO"
+	case layer == "dcontext.WithoutCancelPreserving":
+		return "WithoutCancelPreserving"
+	case strings.HasPrefix(layer, "WithValue(") && strings.HasSuffix(layer, ")"):
+		// "WithValue(key, val)"; show only the key.
+		args := strings.TrimSuffix(strings.TrimPrefix(layer, "WithValue("), ")")
+		key := args
+		if i := strings.Index(args, ", "); i >= 0 {
+			key = args[:i]
+		}
+		key = strings.TrimPrefix(key, "type ")
+		if key == "dcontext.parentHardContextKey" {
+			return "WithSoftness"
+		}
+		return "WithValue(" + key + ")"
+	}
+	return layer
+}
@@ -0,0 +1,104 @@
+package dcontext_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+)
+
+func TestTraceContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx = dcontext.WithSoftness(ctx)
+	ctx, softCancel := context.WithTimeout(ctx, time.Hour)
+	// Values from packages other than dlib aren't shown.
+	ctx = context.WithValue(ctx, http.LocalAddrContextKey, "secret")
+	ctx = dcontext.WithValue(ctx, http.LocalAddrContextKey, "secret")
+	softCancel()
+	hardCtx := dcontext.HardContext(ctx)
+
+	trace := dcontext.TraceContext(hardCtx)
+	t.Log("\n" + trace)
+	lines := []string{
+		"HardContext",
+		"WithValue(dcontext.survivingValuesKey) [done: context canceled]",
+		"WithValue(net/http context value local-addr)",
+		"WithValue(net/http context value local-addr)",
+		"", // WithDeadline(...), checked below
+		"WithSoftness",
+		"WithCancel",
+		"Background",
+	}
+	actual := splitLines(trace)
+	if !assert.Len(t, actual, len(lines)) {
+		return
+	}
+	for i, line := range lines {
+		if line == "" {
+			assert.Regexp(t, `^WithDeadline\(.*\)$`, actual[i])
+			continue
+		}
+		assert.Equal(t, line, actual[i])
+	}
+	assert.NotContains(t, trace, "secret")
+
+	cancel()
+	assert.Contains(t, dcontext.TraceContext(hardCtx), "HardContext [done: context canceled]")
+}
+
+func TestTraceContextOther(t *testing.T) {
+	testcases := map[string]struct {
+		Ctx      context.Context
+		Expected string
+	}{
+		"nil":           {nil, "<nil>"},
+		"TODO":          {context.TODO(), "TODO"},
+		"WithoutCancel": {dcontext.WithoutCancel(context.Background()), "WithoutCancel\nBackground"},
+		"WithoutCancelPreserving": {
+			dcontext.WithoutCancelPreserving(context.Background()),
+			"WithoutCancelPreserving",
+		},
+		"dlib-under-stdlib": {
+			context.WithValue(dcontext.WithoutCancel(context.TODO()), http.ServerContextKey, "x"),
+			"WithValue(net/http context value http-server)\nWithoutCancel\nTODO",
+		},
+		"Merge": {
+			mustMerge(context.Background(), context.TODO()),
+			"Merge(context.TODO)\nBackground",
+		},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			assert.NotPanics(t, func() {
+				assert.Equal(t, tc.Expected, dcontext.TraceContext(tc.Ctx))
+			})
+		})
+	}
+}
+
+func splitLines(s string) []string {
+	var ret []string
+	for len(s) > 0 {
+		i := 0
+		for i < len(s) && s[i] != '\n' {
+			i++
+		}
+		ret = append(ret, s[:i])
+		if i < len(s) {
+			i++
+		}
+		s = s[i:]
+	}
+	return ret
+}
+
+func mustMerge(primary, secondary context.Context) context.Context {
+	ctx, _ := dcontext.Merge(primary, secondary)
+	return ctx
+}
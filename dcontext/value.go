@@ -0,0 +1,66 @@
+package dcontext
+
+import (
+	"context"
+	"time"
+)
+
+type survivingValuesKey struct{}
+
+// survivingValues is a linked list of the values that have been
+// attached with WithValue, most recent first.
+type survivingValues struct {
+	parent *survivingValues
+	key    interface{}
+	val    interface{}
+}
+
+func getSurvivingValues(ctx context.Context) *survivingValues {
+	sv, _ := ctx.Value(survivingValuesKey{}).(*survivingValues)
+	return sv
+}
+
+// WithValue is like context.WithValue, but additionally marks the
+// value as one that survives detachment with WithoutCancelPreserving.
+// This is useful for things like request IDs or trace IDs that should
+// follow a detached cleanup goroutine.
+func WithValue(ctx context.Context, key, val interface{}) context.Context {
+	ctx = context.WithValue(ctx, key, val)
+	return context.WithValue(ctx, survivingValuesKey{}, &survivingValues{
+		parent: getSurvivingValues(ctx),
+		key:    key,
+		val:    val,
+	})
+}
+
+type withoutCancelPreserving struct {
+	values *survivingValues
+}
+
+func (withoutCancelPreserving) Deadline() (deadline time.Time, ok bool) { return }
+func (withoutCancelPreserving) Done() <-chan struct{}                   { return nil }
+func (withoutCancelPreserving) Err() error                              { return nil }
+func (withoutCancelPreserving) String() string                          { return "dcontext.WithoutCancelPreserving" }
+func (c withoutCancelPreserving) Value(key interface{}) interface{} {
+	if key == (survivingValuesKey{}) {
+		return c.values
+	}
+	for sv := c.values; sv != nil; sv = sv.parent {
+		if sv.key == key {
+			return sv.val
+		}
+	}
+	return nil
+}
+
+// WithoutCancelPreserving returns a fresh Context that has no
+// deadline or cancellation, and that carries only the values of
+// parent that were attached with WithValue.
+//
+// This differs from WithoutCancel, which keeps the entire parent
+// Context (and all of its values) reachable; WithoutCancelPreserving
+// lets the caller control exactly which values follow a detached
+// task, and doesn't keep anything else from parent alive.
+func WithoutCancelPreserving(parent context.Context) context.Context {
+	return withoutCancelPreserving{values: getSurvivingValues(parent)}
+}
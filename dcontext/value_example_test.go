@@ -0,0 +1,31 @@
+package dcontext_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/datawire/dlib/dcontext"
+)
+
+type requestIDKey struct{}
+type sessionKey struct{}
+
+// A value attached with WithValue follows a task that has been detached
+// with WithoutCancelPreserving; a value attached with context.WithValue
+// does not, and neither does the parent's cancellation.
+func ExampleWithoutCancelPreserving() {
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = dcontext.WithValue(ctx, requestIDKey{}, "req-1")
+	ctx = context.WithValue(ctx, sessionKey{}, "big session object")
+	cancel()
+
+	cleanupCtx := dcontext.WithoutCancelPreserving(ctx)
+	fmt.Println("err:", cleanupCtx.Err())
+	fmt.Println("request ID:", cleanupCtx.Value(requestIDKey{}))
+	fmt.Println("session:", cleanupCtx.Value(sessionKey{}))
+
+	// Output:
+	// err: <nil>
+	// request ID: req-1
+	// session: <nil>
+}
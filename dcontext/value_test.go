@@ -0,0 +1,40 @@
+package dcontext_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+)
+
+func TestWithoutCancelPreserving(t *testing.T) {
+	type requestIDKey struct{}
+	type traceIDKey struct{}
+	type otherKey struct{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = dcontext.WithValue(ctx, requestIDKey{}, "req-1")
+	ctx = context.WithValue(ctx, otherKey{}, "other")
+	ctx = dcontext.WithValue(ctx, traceIDKey{}, "trace-1")
+	ctx = dcontext.WithValue(ctx, requestIDKey{}, "req-2")
+	assert.Equal(t, "req-2", ctx.Value(requestIDKey{}))
+	assert.Equal(t, "trace-1", ctx.Value(traceIDKey{}))
+	assert.Equal(t, "other", ctx.Value(otherKey{}))
+	cancel()
+
+	detached := dcontext.WithoutCancelPreserving(ctx)
+	assert.NoError(t, detached.Err())
+	assert.False(t, isClosed(detached.Done()))
+	_, ok := detached.Deadline()
+	assert.False(t, ok)
+	assert.Equal(t, "req-2", detached.Value(requestIDKey{}))
+	assert.Equal(t, "trace-1", detached.Value(traceIDKey{}))
+	assert.Nil(t, detached.Value(otherKey{}))
+
+	// Values survive repeated detachment.
+	detached = dcontext.WithoutCancelPreserving(dcontext.WithValue(detached, otherKey{}, "other-2"))
+	assert.Equal(t, "req-2", detached.Value(requestIDKey{}))
+	assert.Equal(t, "other-2", detached.Value(otherKey{}))
+}
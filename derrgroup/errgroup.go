@@ -40,6 +40,7 @@ package derrgroup
 
 import (
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -65,6 +66,24 @@ func (s GoroutineState) String() string {
 	}
 }
 
+// GoroutineInfo describes the state of a goroutine launched with
+// Go, along with timing information about it.
+type GoroutineInfo struct {
+	State GoroutineState
+	// StartedAt is when the goroutine was launched.
+	StartedAt time.Time
+	// FinishedAt is when the goroutine exited; it is the zero
+	// time.Time if the goroutine is still running.
+	FinishedAt time.Time
+	// Duration is how long the goroutine ran for; if it is still
+	// running, then it is how long it had been running for at the
+	// time that the GoroutineInfo was obtained.
+	Duration time.Duration
+	// Err is the error that the goroutine returned; it is nil
+	// unless State is GoroutineErrored.
+	Err error
+}
+
 // A Group is a collection of goroutines working on subtasks that are part of
 // the same overall task.
 //
@@ -73,10 +92,16 @@ type Group struct {
 	cancel           func()
 	cancelOnNonError bool
 
-	wg sync.WaitGroup
-
-	listMu sync.RWMutex
-	list   map[string]GoroutineState
+	// listMu protects list, numRunning, running, and idle.  We
+	// don't use a sync.WaitGroup to track running goroutines,
+	// because sync.WaitGroup forbids calling Add (from a zero
+	// count) concurrently with Wait, and we want to allow
+	// goroutines to be added to the Group at any time.
+	listMu     sync.RWMutex
+	list       map[string]*GoroutineInfo
+	numRunning int           // the number of entries in list that are GoroutineRunning
+	running    int           // like numRunning, but also counts rejected duplicate names
+	idle       chan struct{} // closed when running drops to 0
 
 	errOnce sync.Once
 	err     error
@@ -95,22 +120,54 @@ func NewGroup(cancel func(), cancelOnNonError bool) *Group {
 
 // Wait blocks until all function calls from the Go method have returned, then
 // returns the first non-nil error (if any) from them.
+//
+// It is safe to call Go concurrently with Wait (such as from inside one of the
+// functions passed to Go); Wait will not return until the newly added
+// goroutines have also returned.
 func (g *Group) Wait() error {
-	g.wg.Wait()
-	return g.err
+	for {
+		g.listMu.RLock()
+		idle := g.idle
+		g.listMu.RUnlock()
+		if idle == nil {
+			return g.err
+		}
+		<-idle
+	}
+}
+
+// addLocked records that a goroutine has been launched; it must be called with
+// listMu held.
+func (g *Group) addLocked() {
+	if g.running == 0 {
+		g.idle = make(chan struct{})
+	}
+	g.running++
+}
+
+// doneLocked records that a goroutine has returned; it must be called with
+// listMu held.
+func (g *Group) doneLocked() {
+	g.running--
+	if g.running == 0 {
+		close(g.idle)
+		g.idle = nil
+	}
 }
 
 // Go calls the given function in a new goroutine.
 //
 // The first call to return a non-nil error cancels the group; its error will be
 // returned by Wait.
+//
+// Go may be called at any time, including while Wait is running.
 func (g *Group) Go(name string, f func() error) {
 	g.listMu.Lock()
 	if g.list == nil {
-		g.list = make(map[string]GoroutineState)
+		g.list = make(map[string]*GoroutineInfo)
 	}
 	if _, exists := g.list[name]; exists {
-		g.wg.Add(1)
+		g.addLocked()
 		g.listMu.Unlock()
 		go func() {
 			g.errOnce.Do(func() {
@@ -119,17 +176,25 @@ func (g *Group) Go(name string, f func() error) {
 					g.cancel()
 				}
 			})
-			g.wg.Done()
+			g.listMu.Lock()
+			g.doneLocked()
+			g.listMu.Unlock()
 		}()
 		return
 	}
-	g.list[name] = GoroutineRunning
-	g.wg.Add(1)
+	info := &GoroutineInfo{
+		State:     GoroutineRunning,
+		StartedAt: time.Now(),
+	}
+	g.list[name] = info
+	g.numRunning++
+	g.addLocked()
 	g.listMu.Unlock()
 
 	go func() {
 		exitState := GoroutineExited
-		if err := f(); err != nil {
+		err := f()
+		if err != nil {
 			exitState = GoroutineErrored
 			g.errOnce.Do(func() {
 				g.err = err
@@ -141,11 +206,12 @@ func (g *Group) Go(name string, f func() error) {
 			g.cancel()
 		}
 		g.listMu.Lock()
-		if g.list == nil {
-			g.list = make(map[string]GoroutineState)
-		}
-		g.list[name] = exitState
-		g.wg.Done()
+		info.State = exitState
+		info.Err = err
+		info.FinishedAt = time.Now()
+		info.Duration = info.FinishedAt.Sub(info.StartedAt)
+		g.numRunning--
+		g.doneLocked()
 		g.listMu.Unlock()
 	}()
 }
@@ -157,7 +223,43 @@ func (g *Group) List() map[string]GoroutineState {
 
 	ret := make(map[string]GoroutineState, len(g.list))
 	for k, v := range g.list {
-		ret[k] = v
+		ret[k] = v.State
+	}
+
+	return ret
+}
+
+// Len returns the number of goroutines launched with Go, including
+// ones that have exited; it is the same as len(g.List()), but without
+// making a copy of the list.
+func (g *Group) Len() int {
+	g.listMu.RLock()
+	defer g.listMu.RUnlock()
+	return len(g.list)
+}
+
+// RunningLen returns the number of goroutines launched with Go that
+// are still running.
+func (g *Group) RunningLen() int {
+	g.listMu.RLock()
+	defer g.listMu.RUnlock()
+	return g.numRunning
+}
+
+// ListInfo is like List, but includes timing information about each
+// goroutine.
+func (g *Group) ListInfo() map[string]GoroutineInfo {
+	g.listMu.RLock()
+	defer g.listMu.RUnlock()
+
+	now := time.Now()
+	ret := make(map[string]GoroutineInfo, len(g.list))
+	for k, v := range g.list {
+		info := *v
+		if info.State == GoroutineRunning {
+			info.Duration = now.Sub(info.StartedAt)
+		}
+		ret[k] = info
 	}
 
 	return ret
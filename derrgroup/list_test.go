@@ -0,0 +1,104 @@
+package derrgroup_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/derrgroup"
+)
+
+func TestListInfo(t *testing.T) {
+	assert := assert.New(t)
+	group := new(derrgroup.Group)
+
+	release := make(chan struct{})
+	group.Go("running", func() error {
+		<-release
+		return nil
+	})
+	group.Go("exited", func() error { return nil })
+	for group.List()["exited"] == derrgroup.GoroutineRunning {
+		time.Sleep(time.Millisecond)
+	}
+
+	list := group.ListInfo()
+	assert.Len(list, 2)
+
+	running := list["running"]
+	assert.Equal(derrgroup.GoroutineRunning, running.State)
+	assert.False(running.StartedAt.IsZero())
+	assert.True(running.FinishedAt.IsZero())
+
+	exited := list["exited"]
+	assert.Equal(derrgroup.GoroutineExited, exited.State)
+	assert.False(exited.FinishedAt.Before(exited.StartedAt))
+	assert.Equal(exited.FinishedAt.Sub(exited.StartedAt), exited.Duration)
+
+	close(release)
+	assert.NoError(group.Wait())
+	running = group.ListInfo()["running"]
+	assert.Equal(derrgroup.GoroutineExited, running.State)
+	assert.False(running.FinishedAt.IsZero())
+}
+
+func TestLen(t *testing.T) {
+	assert := assert.New(t)
+	group := new(derrgroup.Group)
+	assert.Equal(0, group.Len())
+	assert.Equal(0, group.RunningLen())
+
+	release := make(chan struct{})
+	group.Go("running", func() error {
+		<-release
+		return nil
+	})
+	group.Go("exited", func() error { return nil })
+	assert.Equal(2, group.Len())
+	for group.List()["exited"] == derrgroup.GoroutineRunning {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(2, group.Len())
+	assert.Equal(1, group.RunningLen())
+
+	// A rejected duplicate name is not counted.
+	group.Go("running", func() error { return nil })
+	assert.Equal(2, group.Len())
+	assert.Equal(1, group.RunningLen())
+
+	close(release)
+	assert.Error(group.Wait())
+	assert.Equal(2, group.Len())
+	assert.Equal(0, group.RunningLen())
+}
+
+func TestGoDuringWait(t *testing.T) {
+	group := new(derrgroup.Group)
+
+	childRelease := make(chan struct{})
+	childFinished := false
+	group.Go("parent", func() error {
+		group.Go("child", func() error {
+			<-childRelease
+			childFinished = true
+			return nil
+		})
+		return nil
+	})
+
+	waitDone := make(chan error)
+	go func() {
+		waitDone <- group.Wait()
+	}()
+
+	select {
+	case <-waitDone:
+		t.Fatal("Wait returned before the child goroutine finished")
+	case <-time.After(time.Second / 10):
+	}
+
+	close(childRelease)
+	assert.NoError(t, <-waitDone)
+	assert.True(t, childFinished)
+}
@@ -29,11 +29,10 @@ import (
 //  - `sigs.k8s.io/controller-tools/pkg/loader.ErrList` : (1) Doesn't implement .Is().  (2) Output
 //    is all on one line and hard to read.
 //
-// Note that (like k8s.io/apimachinery/pkg/util/errors.Aggregate) we don't implement .As(), because
-// we buy in to the same justification:
-//
-//   // Errors.As() is not supported, because the caller presumably cares about a
-//   // specific error of potentially multiple that match the given type.
+// MultiError implements the Go 1.20 "Unwrap() []error" convention, so errors.Is and errors.As
+// search through each of the errors in the list.  Note that this means that errors.As finds the
+// first error in the list that matches the given type; if you care about all of the errors of a
+// given type, you should iterate over the list yourself.
 type MultiError []error
 
 func (errs MultiError) Error() string {
@@ -71,3 +70,71 @@ func (errs MultiError) Is(target error) bool {
 	}
 	return false
 }
+
+// Unwrap returns the errors in the list, for use by errors.Is and errors.As.
+func (errs MultiError) Unwrap() []error {
+	return errs
+}
+
+// As finds the first error in the list that matches target, and if so, sets target to that error
+// value and returns true.
+func (errs MultiError) As(target interface{}) bool {
+	for _, err := range errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Append appends the non-nil errors in additional to base, and returns the result.  Any of the
+// errors (including base) that are MultiErrors are flattened in to the result, rather than being
+// nested.
+//
+// If the result would have no errors, then nil is returned.  If it would have exactly one error,
+// then that error is returned directly, rather than as a 1-element MultiError.  Otherwise, a
+// MultiError is returned.  This makes it appropriate to use for accumulating errors:
+//
+//     var err error
+//     for _, item := range items {
+//         err = derror.Append(err, process(item))
+//     }
+//     return err
+func Append(base error, additional ...error) error {
+	var ret MultiError
+	ret = appendFlat(ret, base)
+	for _, err := range additional {
+		ret = appendFlat(ret, err)
+	}
+	switch len(ret) {
+	case 0:
+		return nil
+	case 1:
+		return ret[0]
+	default:
+		return ret
+	}
+}
+
+func appendFlat(errs MultiError, err error) MultiError {
+	switch err := err.(type) {
+	case nil:
+		return errs
+	case MultiError:
+		for _, child := range err {
+			errs = appendFlat(errs, child)
+		}
+		return errs
+	default:
+		return append(errs, err)
+	}
+}
+
+// Combine combines the non-nil errors in errs in the same way as Append: nil if none of them are
+// non-nil, the error itself if exactly one is, and a (flattened) MultiError otherwise.  This is
+// convenient for collecting the errors from several independent operations:
+//
+//	return derror.Combine(closeA(), closeB(), closeC())
+func Combine(errs ...error) error {
+	return Append(nil, errs...)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -76,3 +77,91 @@ func TestMultiError(t *testing.T) {
 		})
 	}
 }
+
+type codeError struct {
+	code int
+}
+
+func (e *codeError) Error() string { return fmt.Sprintf("code %d", e.code) }
+
+func TestMultiErrorUnwrap(t *testing.T) {
+	errFoo := errors.New("foo")
+	input := derror.MultiError{
+		errors.New("bar"),
+		fmt.Errorf("wrapped: %w", errFoo),
+		&codeError{code: 1},
+		fmt.Errorf("wrapped: %w", &codeError{code: 2}),
+	}
+	var err error = fmt.Errorf("outer: %w", input)
+
+	assert.Equal(t, []error(input), input.Unwrap())
+
+	assert.True(t, errors.Is(err, errFoo))
+	assert.False(t, errors.Is(err, io.EOF))
+
+	var ce *codeError
+	if assert.True(t, errors.As(err, &ce)) {
+		assert.Equal(t, 1, ce.code)
+	}
+	assert.True(t, input.As(&ce))
+
+	var pe *os.PathError
+	assert.False(t, errors.As(err, &pe))
+}
+
+func TestAppend(t *testing.T) {
+	errA := errors.New("a")
+	errB := errors.New("b")
+	errC := errors.New("c")
+
+	testcases := map[string]struct {
+		Base       error
+		Additional []error
+		Expected   error
+	}{
+		"nil":            {Base: nil, Additional: nil, Expected: nil},
+		"all-nil":        {Base: nil, Additional: []error{nil, nil}, Expected: nil},
+		"empty-multi":    {Base: derror.MultiError{}, Additional: []error{nil}, Expected: nil},
+		"base-only":      {Base: errA, Additional: nil, Expected: errA},
+		"additional-one": {Base: nil, Additional: []error{nil, errA}, Expected: errA},
+		"two": {
+			Base:       errA,
+			Additional: []error{nil, errB},
+			Expected:   derror.MultiError{errA, errB},
+		},
+		"accumulate": {
+			Base:       derror.MultiError{errA, errB},
+			Additional: []error{errC},
+			Expected:   derror.MultiError{errA, errB, errC},
+		},
+		"flatten": {
+			Base:       errA,
+			Additional: []error{derror.MultiError{errB, errC}},
+			Expected:   derror.MultiError{errA, errB, errC},
+		},
+	}
+	for tcName, tcData := range testcases {
+		tcData := tcData
+		t.Run(tcName, func(t *testing.T) {
+			assert.Equal(t, tcData.Expected, derror.Append(tcData.Base, tcData.Additional...))
+		})
+	}
+
+	// Appending must not modify the base MultiError's backing array.
+	base := make(derror.MultiError, 1, 10)
+	base[0] = errA
+	_ = derror.Append(base, errB)
+	_ = derror.Append(base, errC)
+	assert.Equal(t, derror.MultiError{errA}, base)
+	assert.Equal(t, derror.MultiError{errA, errB}, derror.Append(base, errB))
+}
+
+func TestCombine(t *testing.T) {
+	errA := errors.New("a")
+	errB := errors.New("b")
+
+	assert.Nil(t, derror.Combine())
+	assert.Nil(t, derror.Combine(nil, nil, nil))
+	assert.Equal(t, errA, derror.Combine(nil, errA, nil))
+	assert.Equal(t, derror.MultiError{errA, errB}, derror.Combine(errA, nil, errB))
+}
@@ -0,0 +1,24 @@
+package derror
+
+// Must returns v if err is nil, and panics with err otherwise.  It is
+// intended for use during initialization, where an error indicates a
+// programming mistake:
+//
+//     var tmpl = derror.Must(template.New("x").Parse(text))
+//
+// The panic value is err itself (not a new error), so a recovering
+// PanicToError will see the original error, and errors.Is and
+// errors.As will work on the result.
+func Must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// MustNoErr is like Must, but for functions that return only an error.
+func MustNoErr(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,46 @@
+package derror_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/derror"
+)
+
+func recoverErr(fn func()) (err error) {
+	defer func() {
+		err = derror.PanicToError(recover())
+	}()
+	fn()
+	return nil
+}
+
+func TestMust(t *testing.T) {
+	assert.Equal(t, 42, derror.Must(strconv.Atoi("42")))
+
+	err := recoverErr(func() {
+		derror.Must(strconv.Atoi("forty-two"))
+	})
+	assert.EqualError(t, err, `PANIC: strconv.Atoi: parsing "forty-two": invalid syntax`)
+	assert.True(t, errors.Is(err, strconv.ErrSyntax))
+	var numErr *strconv.NumError
+	assert.True(t, errors.As(err, &numErr))
+}
+
+func TestMustNoErr(t *testing.T) {
+	assert.NoError(t, recoverErr(func() {
+		derror.MustNoErr(nil)
+	}))
+
+	err := recoverErr(func() {
+		derror.MustNoErr(io.EOF)
+	})
+	assert.EqualError(t, err, "PANIC: EOF")
+	assert.True(t, errors.Is(err, io.EOF))
+	assert.Contains(t, fmt.Sprintf("%+v", err), "must_test.go")
+}
@@ -68,7 +68,10 @@ var _ featurefulError = panicError{}
 // PanicToError(), then it is returned verbatim.
 //
 // If the input is an error, it is wrapped with the message "PANIC:"
-// and has a stack trace attached to it.
+// and has a stack trace attached to it.  The returned error unwraps
+// to the input error, so errors.Is and errors.As (and
+// github.com/pkg/errors.Cause) still see the original error and its
+// type; there is no need to avoid wrapping in order to preserve it.
 //
 // If the input is anything else, it is formatted with "%+v" and
 // returned as an error with a stack trace attached.
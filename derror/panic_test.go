@@ -172,3 +172,40 @@ func TestPanicToError(t *testing.T) {
 		panic("root")
 	})
 }
+
+type customPanicError struct {
+	msg string
+}
+
+func (e *customPanicError) Error() string { return e.msg }
+
+func TestPanicToErrorPreservesType(t *testing.T) {
+	for name, root := range map[string]error{
+		"plain":      &customPanicError{msg: "custom"},
+		"with-stack": pkgerrors.WithStack(&customPanicError{msg: "custom"}),
+	} {
+		root := root
+		t.Run(name, func(t *testing.T) {
+			var err error
+			func() {
+				defer func() {
+					err = derror.PanicToError(recover())
+				}()
+				panic(root)
+			}()
+
+			if !stderrors.Is(err, root) {
+				t.Error("error: errors.Is doesn't find the panic value")
+			}
+			var target *customPanicError
+			if !stderrors.As(err, &target) {
+				t.Error("error: errors.As doesn't find the panic value's type")
+			} else if target.msg != "custom" {
+				t.Errorf("error: errors.As found the wrong value: %q", target.msg)
+			}
+			if v := fmt.Sprintf("%+v", err); !strings.Contains(v, "panic_test.go") {
+				t.Errorf("error: %%+v doesn't include a stack trace: %q", v)
+			}
+		})
+	}
+}
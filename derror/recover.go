@@ -0,0 +1,29 @@
+package derror
+
+import (
+	"context"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+// RecoverAndLog takes an arbitrary object returned from recover(), and
+// if it is non-nil, converts it to an error with PanicToError and logs
+// it (including the stack trace) at the error level.  The error is
+// returned, so that the caller may also use it.
+//
+// Because the arguments to a deferred function call are evaluated
+// when the defer statement is executed (not when the function is
+// called), recover() must be called from inside a deferred closure:
+//
+//     defer func() {
+//         derror.RecoverAndLog(ctx, recover())
+//     }()
+//
+// Writing "defer derror.RecoverAndLog(ctx, recover())" does NOT work.
+func RecoverAndLog(ctx context.Context, recovered interface{}) error {
+	err := PanicToError(recovered)
+	if err != nil {
+		dlog.Errorf(ctx, "recovered panic: %+v", err)
+	}
+	return err
+}
@@ -0,0 +1,45 @@
+package derror_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/derror"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestRecoverAndLog(t *testing.T) {
+	var log strings.Builder
+	logger := logrus.New()
+	logger.SetOutput(&log)
+	logger.SetFormatter(&logrus.TextFormatter{
+		DisableTimestamp: true,
+	})
+	ctx := dlog.WithLogger(context.Background(), dlog.WrapLogrus(logger))
+
+	var err error
+	func() {
+		defer func() {
+			err = derror.RecoverAndLog(ctx, recover())
+		}()
+		panic("boom")
+	}()
+
+	assert.EqualError(t, err, "PANIC: boom")
+	assert.Contains(t, log.String(), `level=error msg="recovered panic: PANIC: boom\n`)
+	assert.Contains(t, log.String(), "recover_test.go")
+}
+
+func TestRecoverAndLogNil(t *testing.T) {
+	var log strings.Builder
+	logger := logrus.New()
+	logger.SetOutput(&log)
+	ctx := dlog.WithLogger(context.Background(), dlog.WrapLogrus(logger))
+
+	assert.NoError(t, derror.RecoverAndLog(ctx, nil))
+	assert.Equal(t, "", log.String())
+}
@@ -0,0 +1,65 @@
+package derror
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+type stackError struct {
+	err   error
+	stack errors.StackTrace
+}
+
+func (se stackError) Error() string                 { return se.err.Error() }
+func (se stackError) Unwrap() error                 { return se.err } // Go 1.13 std "errors"
+func (se stackError) Cause() error                  { return se.err } // "github.com/pkg/errors"
+func (se stackError) StackTrace() errors.StackTrace { return se.stack }
+func (se stackError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			fmt.Fprintf(s, "%+v", se.err)
+			se.stack.Format(s, verb)
+			return
+		}
+		_, _ = io.WriteString(s, se.Error())
+	case 's':
+		_, _ = io.WriteString(s, se.Error())
+	case 'q':
+		_, _ = fmt.Fprintf(s, "%q", se.Error())
+	}
+}
+
+var _ unwrapper = stackError{}
+var _ causer = stackError{}
+var _ featurefulError = stackError{}
+
+// HasStack returns whether err, or any error that it wraps, has a stack
+// trace attached to it (as errors from WithStack, PanicToError, and
+// most of the functions in github.com/pkg/errors do).
+func HasStack(err error) bool {
+	var st stackTracer
+	return stderrors.As(err, &st)
+}
+
+// WithStack returns err with a stack trace of the caller of WithStack
+// attached to it, which is included when the error is formatted with
+// "%+v".  The returned error unwraps to err, so errors.Is and errors.As
+// see through it.
+//
+// If err is nil, WithStack returns nil.  If err already has a stack
+// trace (see HasStack), it is returned as-is; so it is safe to call
+// WithStack on an error that might already have one.
+func WithStack(err error) error {
+	if err == nil || HasStack(err) {
+		return err
+	}
+	return stackError{
+		err: err,
+		// Trim off the frame for WithStack itself.
+		stack: errors.WithStack(err).(stackTracer).StackTrace()[1:],
+	}
+}
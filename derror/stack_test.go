@@ -0,0 +1,61 @@
+package derror_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/derror"
+)
+
+func TestWithStack(t *testing.T) {
+	_, file, line, _ := runtime.Caller(0)
+	err := derror.WithStack(io.EOF) // must be the line after runtime.Caller
+	line++
+
+	assert.True(t, derror.HasStack(err))
+	assert.False(t, derror.HasStack(io.EOF))
+	assert.Equal(t, "EOF", err.Error())
+	assert.Equal(t, "EOF", fmt.Sprintf("%v", err))
+	assert.Equal(t, "EOF", fmt.Sprintf("%s", err))
+	assert.Equal(t, `"EOF"`, fmt.Sprintf("%q", err))
+
+	verbose := fmt.Sprintf("%+v", err)
+	assert.True(t, strings.HasPrefix(verbose, "EOF\n"), verbose)
+	frames := strings.Split(verbose, "\n")
+	if assert.Greater(t, len(frames), 2) {
+		// The first frame is the caller of WithStack, not WithStack itself.
+		assert.Equal(t, thispackage+".TestWithStack", frames[1])
+		assert.Equal(t, fmt.Sprintf("\t%s:%d", file, line), frames[2])
+	}
+	assert.NotContains(t, verbose, "derror.WithStack")
+
+	assert.True(t, errors.Is(err, io.EOF))
+	assert.Equal(t, io.EOF, pkgerrors.Cause(err))
+	var pathErr *os.PathError
+	assert.True(t, errors.As(derror.WithStack(&os.PathError{Op: "open", Path: "x", Err: io.EOF}), &pathErr))
+}
+
+func TestWithStackIdempotent(t *testing.T) {
+	assert.NoError(t, derror.WithStack(nil))
+	assert.False(t, derror.HasStack(nil))
+
+	once := derror.WithStack(io.EOF)
+	twice := derror.WithStack(once)
+	assert.Equal(t, once, twice)
+	assert.Equal(t, fmt.Sprintf("%+v", once), fmt.Sprintf("%+v", twice))
+
+	// An error that already has a stack from github.com/pkg/errors is left alone.
+	pkgErr := pkgerrors.New("x")
+	assert.Equal(t, pkgErr, derror.WithStack(pkgErr))
+	wrapped := fmt.Errorf("wrapped: %w", pkgErr)
+	assert.True(t, derror.HasStack(wrapped))
+	assert.Equal(t, wrapped, derror.WithStack(wrapped))
+}
@@ -16,7 +16,7 @@ import (
 func BenchmarkExecHostname(b *testing.B) {
 	ctx := dlog.NewTestContext(b, false) // MODIFIED: ADDED
 	b.ReportAllocs()
-	path, err := LookPath("hostname")
+	path, err := LookPath(ctx, "hostname") // MODIFIED: FROM: path, err := LookPath("hostname")
 	if err != nil {
 		b.Fatalf("could not find hostname: %v", err)
 	}
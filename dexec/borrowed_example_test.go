@@ -22,7 +22,7 @@ import (
 )
 
 func ExampleLookPath() {
-	path, err := exec.LookPath("fortune")
+	path, err := exec.LookPath(context.Background(), "fortune") // MODIFIED: FROM: path, err := exec.LookPath("fortune")
 	if err != nil {
 		log.Fatal("installing fortune is in your future")
 	}
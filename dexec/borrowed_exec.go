@@ -1,5 +1,6 @@
 // MODIFIED: META: This file is a verbatim subset of Go 1.15.14 os/exec/exec.go,
-// MODIFIED: META: except that the imports list has been changed.
+// MODIFIED: META: except that the imports list has been changed, and for
+// MODIFIED: META: lines marked "MODIFIED".
 
 // Copyright 2009 The Go Authors. All rights reserved.
 // Use of this source code is governed by a BSD-style
@@ -36,10 +37,7 @@ func interfaceEqual(a, b interface{}) bool {
 // thread state (for example, Linux or Plan 9 name spaces), the new
 // process will inherit the caller's thread state.
 func (c *Cmd) Run() error {
-	if err := c.Start(); err != nil {
-		return err
-	}
-	return c.Wait()
+	return c.run(nil) // MODIFIED: FROM: if err := c.Start(); err != nil { return err }; return c.Wait()
 }
 
 // Output runs the command and returns its standard output.
@@ -57,9 +55,15 @@ func (c *Cmd) Output() ([]byte, error) {
 		c.Stderr = &prefixSuffixSaver{N: 32 << 10}
 	}
 
-	err := c.Run()
+	err := c.run(func() { // MODIFIED: FROM: err := c.Run()
+		stdout.Reset()  // MODIFIED: ADDED
+		if captureErr { // MODIFIED: ADDED
+			c.Stderr = &prefixSuffixSaver{N: 32 << 10} // MODIFIED: ADDED
+		} // MODIFIED: ADDED
+	}) // MODIFIED: ADDED
 	if err != nil && captureErr {
-		if ee, ok := err.(*ExitError); ok {
+		var ee *ExitError        // MODIFIED: ADDED
+		if errors.As(err, &ee) { // MODIFIED: FROM: if ee, ok := err.(*ExitError); ok {
 			ee.Stderr = c.Stderr.(*loggingWriter).writer.(*prefixSuffixSaver).Bytes() // MODIFIED: FROM: ee.Stderr = c.Stderr.(*prefixSuffixSaver).Bytes()
 		}
 	}
@@ -78,7 +82,7 @@ func (c *Cmd) CombinedOutput() ([]byte, error) {
 	var b bytes.Buffer
 	c.Stdout = &b
 	c.Stderr = &b
-	err := c.Run()
+	err := c.run(b.Reset) // MODIFIED: FROM: err := c.Run()
 	return b.Bytes(), err
 }
 
@@ -893,7 +893,7 @@ func TestHelperProcess(*testing.T) {
 		fmt.Printf("%s", string(output))
 		os.Exit(0)
 	case "lookpath":
-		p, err := exec.LookPath(args[0])
+		p, err := exec.LookPath(context.Background(), args[0]) // MODIFIED: FROM: p, err := exec.LookPath(args[0])
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "LookPath failed: %v\n", err)
 			os.Exit(1)
@@ -1114,7 +1114,7 @@ func TestDedupEnvEcho(t *testing.T) {
 }
 
 func TestString(t *testing.T) {
-	echoPath, err := exec.LookPath("echo")
+	echoPath, err := exec.LookPath(context.Background(), "echo") // MODIFIED: FROM: echoPath, err := exec.LookPath("echo")
 	if err != nil {
 		t.Skip(err)
 	}
@@ -1137,7 +1137,7 @@ func TestString(t *testing.T) {
 }
 
 func TestStringPathNotResolved(t *testing.T) {
-	_, err := exec.LookPath("makemeasandwich")
+	_, err := exec.LookPath(context.Background(), "makemeasandwich") // MODIFIED: FROM: _, err := exec.LookPath("makemeasandwich")
 	if err == nil {
 		t.Skip("wow, thanks")
 	}
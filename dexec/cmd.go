@@ -43,11 +43,19 @@
 package dexec
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
 
 	// Specifically use github.com/pkg/errors instead of stdlib "errors" because the situations
 	// we'll use it are situations where stacktraces will be useful.
@@ -55,6 +63,7 @@ import (
 
 	"github.com/datawire/dlib/dcontext"
 	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/dlib/dtime/v2"
 	"github.com/datawire/dlib/internal/sigint"
 )
 
@@ -68,9 +77,6 @@ type ExitError = exec.ExitError
 // ErrNotFound is the os/exec.ErrNotFound value.
 var ErrNotFound = exec.ErrNotFound
 
-// LookPath is the os/exe.LookPath function.
-var LookPath = exec.LookPath
-
 // Cmd represents an external command being prepared or run.
 //
 // A Cmd cannot be reused after calling its Run, Output or CombinedOutput
@@ -87,14 +93,183 @@ type Cmd struct {
 
 	DisableLogging bool
 
+	// IOLogLevel is the level at which data read from .Stdin or
+	// written to .Stdout and .Stderr is logged.  CommandContext
+	// initializes it to dlog.LogLevelInfo.
+	IOLogLevel dlog.LogLevel
+	// StderrLogLevel, if non-nil, overrides IOLogLevel for data
+	// written to .Stderr.  It has no effect if .Stdout and .Stderr
+	// are the same writer, since then the two streams cannot be
+	// told apart.
+	//
+	// Because dlog.LogLevelError is the zero LogLevel, this is a
+	// pointer so that "unset" can be distinguished from
+	// LogLevelError.
+	StderrLogLevel *dlog.LogLevel
+
+	// IOLogMaxLineSize is the maximum number of bytes of I/O data
+	// that are logged in a single log entry.  A longer line is
+	// split across multiple entries; each entry but the last is
+	// suffixed with " [truncated, continued]", and each entry but
+	// the first is prefixed with " [continuation]".  A zero or
+	// negative value means no limit.  CommandContext initializes
+	// it to 4096.
+	IOLogMaxLineSize int
+
+	// IOLogFormat controls how data read from .Stdin or written to
+	// .Stdout and .Stderr is represented in the log; the default
+	// (IOLogFormatRaw) is to log it as-is, which is not great for
+	// binary data.
+	IOLogFormat IOLogFormat
+
+	// StdinData, if non-nil, is used as the command's standard
+	// input; it is a shorthand for setting .Stdin to a
+	// bytes.Reader.  It is an error to set both StdinData and
+	// .Stdin.
+	StdinData []byte
+
+	// StdinCtx, if non-nil, bounds how long data is copied from
+	// .Stdin to the command: once it is done, any blocked read
+	// from .Stdin is abandoned and the command's standard input is
+	// closed.  If StdinCtx is nil, then the hard Context that the
+	// Cmd was created with is used, so that a .Stdin that blocks
+	// forever can't prevent Wait from returning once the command
+	// has been killed.  StdinCtx has no effect if .Stdin is an
+	// *os.File, since then the command reads it directly, or if
+	// .Stdin is a *bytes.Reader, *bytes.Buffer, or *strings.Reader,
+	// since reading from those never blocks.  If StdinCtx is nil and
+	// .Stdin implements io.WriterTo, then .Stdin is assumed to be
+	// able to look after itself, and is used as-is.
+	//
+	// Note that the read from .Stdin that was blocked is not
+	// interrupted (there is no general way to interrupt an
+	// io.Reader); it is left to finish in the background, and
+	// whatever it reads is discarded.
+	StdinCtx context.Context
+
+	// OutputTransform, if non-nil, is applied to each line
+	// (without its trailing newline) written to .Stdout or
+	// .Stderr, before it is logged and passed along to the
+	// underlying writer.  An incomplete final line is passed along
+	// untransformed, with a newline appended.  OutputTransform has
+	// no effect on a stream that is an *os.File.
+	OutputTransform func(line string) string
+
+	// StdoutTransform and StderrTransform, if non-nil, are
+	// applied to the whole of the command's standard output and
+	// standard error streams (respectively) before they reach
+	// .Stdout and .Stderr; for example, to decompress or decrypt
+	// the output.  Unlike OutputTransform, they are not
+	// line-oriented, and the data is logged untransformed, as the
+	// command wrote it.  If .Stdout and .Stderr are the same
+	// writer, then only StdoutTransform is used, and it is applied
+	// to the combined stream.
+	StdoutTransform func(io.Reader) io.Reader
+	StderrTransform func(io.Reader) io.Reader
+
+	// EnvInherit controls whether the command inherits the calling
+	// process's environment when .Env is nil (as with
+	// os/exec.Cmd).  If EnvInherit is false, then the command's
+	// environment is exactly .Env, and is empty if .Env is nil;
+	// this is useful for hermetic tests.  CommandContext
+	// initializes it to true.
+	//
+	// Note that CommandContextWithEnv sets .Env to a copy of the
+	// calling process's environment, so EnvInherit has no effect
+	// on commands created with it.
+	EnvInherit bool
+
+	// WorkDir is the working directory of the command.  If it is
+	// empty, then the directory set on the Context by WithWorkDir
+	// is used; if it is a relative path, then it is relative to
+	// that directory.  If neither is set, the command runs in the
+	// calling process's current directory.  WorkDir is ignored if
+	// .Dir is set.
+	WorkDir string
+
+	// KillSignal, if non-nil, is sent to the process instead of
+	// SIGKILL when the hard Context is canceled, for programs that
+	// clean up better when given (for example) SIGTERM.  If the
+	// process does not exit within KillTimeout of being sent
+	// KillSignal, then SIGKILL is sent as a last resort.  (On
+	// GOOS=windows, most signals cannot be sent, and so KillSignal
+	// will likely fail to be sent, resulting in SIGKILL being sent
+	// once KillTimeout has elapsed.)
+	KillSignal os.Signal
+	// KillTimeout is how long to wait after sending KillSignal
+	// before sending SIGKILL, and how long to wait after sending
+	// SIGINT because of .Timeout before killing the process.  If
+	// zero, then 5 seconds is used.  It is measured with the
+	// Context's Clock (see dtime/v2.WithClock).
+	KillTimeout time.Duration
+
+	// Timeout, if positive, is a deadline for the command that is
+	// separate from the Context that the Cmd was created with
+	// (which might, for example, be the Context for an entire
+	// test).  Once the command has been running for Timeout, it is
+	// shut down regardless of the state of the Context: if the
+	// Context has a soft/hard distinction (see
+	// dcontext.WithSoftness) then it is sent SIGINT, and if it
+	// hasn't exited within KillTimeout of that then it is killed
+	// (as if the hard Context had been canceled); otherwise it is
+	// killed right away.  If the command is shut down because of
+	// Timeout, then Wait returns an error that is (per errors.Is)
+	// context.DeadlineExceeded, and that also wraps the
+	// *ExitError.  Timeout is measured with the Context's Clock
+	// (see dtime/v2.WithClock).
+	//
+	// When Retry is set, each attempt gets its own Timeout.
+	Timeout time.Duration
+
+	// SuccessExitCodes lists non-zero exit codes that should be
+	// treated the same as exit code 0; for example, grep(1) exits
+	// with 1 when it doesn't find any matches, which might not be
+	// an error.  If the command exits with one of these codes, then
+	// Wait (and so Run, Output, and CombinedOutput) returns nil,
+	// it is logged as having finished successfully, and it is not
+	// retried.
+	SuccessExitCodes []int
+
+	// Retry controls whether Run, Output, and CombinedOutput
+	// re-run the command if it exits with a non-zero status.
+	Retry Retry
+
 	ctx context.Context
 
+	// The .Stdin, .Stdout, and .Stderr values from before Start
+	// wrapped them, so that they may be restored for a retry.
+	origStdin  io.Reader
+	origStdout io.Writer
+	origStderr io.Writer
+
+	// The contextReader that .Stdin was wrapped in, if any, whose
+	// pump goroutine must be stopped once the command exits.
+	stdinReader *contextReader
+
+	// The writers that StdoutTransform and StderrTransform are
+	// running in, which must be closed once the command exits.
+	transformWriters []*transformWriter
+
 	pidlock sync.RWMutex
 
 	waitDone chan struct{}
 	waitOnce sync.Once
 
 	supervisorDone chan struct{}
+
+	// pipelined is set by NewPipeline; the Pipeline, rather than
+	// the Cmd's own supervisor, responds to the Context being
+	// canceled.
+	pipelined bool
+
+	// timeoutTimer closes timedOut once the current attempt has
+	// been running for .Timeout; it is nil if .Timeout is not set.
+	timeoutTimer *dtime.FuncTimer
+	timedOut     chan struct{}
+
+	// lookPathErr is the error from LookPath in CommandContext; it
+	// is returned by Start.
+	lookPathErr error
 }
 
 // CommandContext returns the Cmd struct to execute the named program with
@@ -106,20 +281,38 @@ type Cmd struct {
 //     becomes done before the command completes on its own.
 //  2. For logging (see github.com/datawire/dlib/dlog).
 //
+// If name contains no path separators, then LookPath is used to
+// resolve it, so a PATH set on the Context with WithPath is respected.
+//
 // See the os/exec.Command and os/exec.CommandContext documentation
 // for more information.
 func CommandContext(ctx context.Context, name string, arg ...string) *Cmd {
 	osCtx, osCancel := context.WithCancel(dcontext.WithoutCancel(ctx))
+	var lookPathErr error
+	cmd := exec.CommandContext(osCtx, name, arg...)
+	if _, hasPath := ctx.Value(pathContextKey{}).(string); hasPath && filepath.Base(name) == name {
+		// Redo os/exec's lookup using the PATH from the Context.
+		var path string
+		if path, lookPathErr = LookPath(ctx, name); lookPathErr == nil {
+			cmd = exec.CommandContext(osCtx, path, arg...)
+			cmd.Args[0] = name
+		}
+	}
 	ret := &Cmd{
-		Cmd:      exec.CommandContext(osCtx, name, arg...),
-		ctx:      ctx,
-		osCancel: osCancel,
+		Cmd:         cmd,
+		lookPathErr: lookPathErr,
+		ctx:         ctx,
+		osCancel:    osCancel,
+		IOLogLevel:  dlog.LogLevelInfo,
+		EnvInherit:  true,
+
+		IOLogMaxLineSize: 4096,
 	}
 	ret.pidlock.Lock()
 	return ret
 }
 
-func (c *Cmd) logiofn(stream string) func(error, []byte) {
+func (c *Cmd) logiofn(stream string, level dlog.LogLevel) func(error, []byte) {
 	return func(err error, msg []byte) {
 		if c.DisableLogging {
 			return
@@ -133,16 +326,84 @@ func (c *Cmd) logiofn(stream string) func(error, []byte) {
 		}
 		ctx := dlog.WithField(c.ctx, "dexec.pid", pid)
 		ctx = dlog.WithField(ctx, "dexec.stream", stream)
-		if msg != nil {
-			ctx = dlog.WithField(ctx, "dexec.data", string(msg))
-		}
 		if err != nil {
 			ctx = dlog.WithField(ctx, "dexec.err", err)
 		}
-		// We don't have an additional message to log; all of the info that we want to log
-		// is provided via dlog.WithField.
-		dlog.Print(ctx)
+		if msg == nil {
+			// We don't have an additional message to log; all of the info that we want
+			// to log is provided via dlog.WithField.
+			dlog.Log(ctx, level)
+			return
+		}
+		for _, chunk := range splitIOLogData(c.IOLogFormat.format(msg), c.IOLogMaxLineSize) {
+			dlog.Log(dlog.WithField(ctx, "dexec.data", chunk), level)
+		}
+	}
+}
+
+// IOLogFormat is how I/O data is represented in the log; see
+// Cmd.IOLogFormat.
+type IOLogFormat int
+
+const (
+	// IOLogFormatRaw logs the data as-is.
+	IOLogFormatRaw IOLogFormat = iota
+	// IOLogFormatHexDump logs the data as a hex dump, in the format
+	// of encoding/hex.Dump.
+	IOLogFormatHexDump
+	// IOLogFormatBase64 logs the data encoded with standard
+	// (padded) base64.
+	IOLogFormatBase64
+)
+
+func (f IOLogFormat) format(data []byte) string {
+	switch f {
+	case IOLogFormatHexDump:
+		return hex.Dump(data)
+	case IOLogFormatBase64:
+		return base64.StdEncoding.EncodeToString(data)
+	default:
+		return string(data)
+	}
+}
+
+const (
+	ioLogTruncatedSuffix    = " [truncated, continued]"
+	ioLogContinuationPrefix = " [continuation]"
+)
+
+// splitIOLogData splits data in to chunks of at most max bytes
+// (not counting a trailing newline, or the markers that are added
+// to each chunk to indicate that it has been split).  Chunks are
+// only split on UTF-8 character boundaries.
+func splitIOLogData(data string, max int) []string {
+	body := strings.TrimSuffix(data, "\n")
+	newline := data[len(body):]
+	if max <= 0 || len(body) <= max {
+		return []string{data}
+	}
+	var chunks []string
+	for len(body) > max {
+		n := max
+		for n > 0 && !utf8.RuneStart(body[n]) {
+			n--
+		}
+		if n == 0 {
+			n = max
+		}
+		chunks = append(chunks, body[:n])
+		body = body[n:]
 	}
+	chunks = append(chunks, body+newline)
+	for i := range chunks {
+		if i > 0 {
+			chunks[i] = ioLogContinuationPrefix + chunks[i]
+		}
+		if i < len(chunks)-1 {
+			chunks[i] += ioLogTruncatedSuffix
+		}
+	}
+	return chunks
 }
 
 // Start starts the specified command but does not wait for it to complete.
@@ -157,16 +418,60 @@ func (c *Cmd) logiofn(stream string) func(error, []byte) {
 // put the child process in its own process group.
 func (c *Cmd) Start() error {
 	if c.ctx != dcontext.HardContext(c.ctx) && !c.canInterrupt() {
-		return errors.New("dexec.Cmd.Start: on GOOS=windows it is an error to use soft cancellation without CREATE_NEW_PROCESS_GROUP")
+		return c.abortStart(errors.New("dexec.Cmd.Start: on GOOS=windows it is an error to use soft cancellation without CREATE_NEW_PROCESS_GROUP"))
 	}
 
-	c.Stdin = fixupReader(c.Stdin, c.logiofn("stdin"))
+	if c.lookPathErr != nil {
+		return c.abortStart(c.lookPathErr)
+	}
+
+	if c.StdinData != nil && c.Stdin != nil {
+		return c.abortStart(errors.New("dexec.Cmd.Start: StdinData and Stdin are both set"))
+	}
+
+	if c.Dir == "" {
+		c.Dir = c.resolveWorkDir()
+	}
+	if c.Env == nil && !c.EnvInherit {
+		// os/exec treats a nil Env as "inherit", but an empty
+		// non-nil Env as "empty".
+		c.Env = []string{}
+	}
+
+	c.origStdin, c.origStdout, c.origStderr = c.Stdin, c.Stdout, c.Stderr
+	if c.StdinData != nil {
+		c.Stdin = bytes.NewReader(c.StdinData)
+	} else if c.Stdin != nil {
+		_, isFile := c.Stdin.(*os.File)
+		_, isWriterTo := c.Stdin.(io.WriterTo)
+		// Don't hide an io.WriterTo's fast path from io.Copy
+		// unless StdinCtx asks for it.
+		if !isFile && canBlock(c.Stdin) && (c.StdinCtx != nil || !isWriterTo) {
+			stdinCtx := c.StdinCtx
+			if stdinCtx == nil {
+				stdinCtx = dcontext.HardContext(c.ctx)
+			}
+			if stdinCtx.Done() != nil {
+				// Only pay for the extra goroutine if the
+				// Context can actually be canceled.
+				c.stdinReader = newContextReader(stdinCtx, c.Stdin)
+				c.Stdin = c.stdinReader
+			}
+		}
+	}
+
+	stderrLevel := c.IOLogLevel
+	if c.StderrLogLevel != nil {
+		stderrLevel = *c.StderrLogLevel
+	}
+	c.Stdin = fixupReader(c.Stdin, c.logiofn("stdin", c.IOLogLevel))
+	c.transformWriters = nil
 	if interfaceEqual(c.Stdout, c.Stderr) {
-		c.Stdout = fixupWriter(c.Stdout, c.logiofn("stdout+stderr"))
+		c.Stdout = fixupWriter(c.wrapTransform(c.Stdout, c.StdoutTransform), c.logiofn("stdout+stderr", c.IOLogLevel), c.OutputTransform)
 		c.Stderr = c.Stdout
 	} else {
-		c.Stdout = fixupWriter(c.Stdout, c.logiofn("stdout"))
-		c.Stderr = fixupWriter(c.Stderr, c.logiofn("stderr"))
+		c.Stdout = fixupWriter(c.wrapTransform(c.Stdout, c.StdoutTransform), c.logiofn("stdout", c.IOLogLevel), c.OutputTransform)
+		c.Stderr = fixupWriter(c.wrapTransform(c.Stderr, c.StderrTransform), c.logiofn("stderr", stderrLevel), c.OutputTransform)
 	}
 
 	select {
@@ -177,6 +482,7 @@ func (c *Cmd) Start() error {
 	err := c.Cmd.Start()
 	if err != nil {
 		c.osCancel()
+		c.closeTransforms()
 	} else {
 		if !c.DisableLogging {
 			ctx := dlog.WithField(c.ctx, "dexec.pid", c.Process.Pid)
@@ -191,20 +497,26 @@ func (c *Cmd) Start() error {
 				dlog.Printf(dlog.WithField(ctx, "dexec.stream", "stderr"), "not logging output written to file %q", stderr.Name())
 			}
 		}
+		timedOut := make(chan struct{})
+		c.timedOut = timedOut
+		if c.Timeout > 0 {
+			c.timeoutTimer = dtime.AfterFunc(c.ctx, c.Timeout, func() { close(timedOut) })
+		}
 		c.waitDone = make(chan struct{})
 		c.supervisorDone = make(chan struct{})
+		softDone, hardDone := c.ctx.Done(), dcontext.HardContext(c.ctx).Done()
+		if c.pipelined {
+			softDone, hardDone = nil, nil
+		}
 		go func() {
 			defer close(c.supervisorDone)
 			if c.ctx != dcontext.HardContext(c.ctx) {
 				// possibly-soft shutdown
 				select {
-				case <-c.ctx.Done(): // shutdown
+				case <-softDone: // shutdown
 					select {
-					case <-dcontext.HardContext(c.ctx).Done(): // hard shutdown
-						if !c.DisableLogging {
-							dlog.Print(c.ctx, "sending SIGKILL")
-						}
-						c.osCancel() // let os/exec send it for us
+					case <-hardDone: // hard shutdown
+						c.hardKill()
 						return
 					default: // soft shutdown
 						if !c.DisableLogging {
@@ -212,17 +524,35 @@ func (c *Cmd) Start() error {
 						}
 						_ = sigint.SendInterrupt(c.Cmd.Process)
 					}
+				case <-timedOut: // soft shutdown, escalating to a hard shutdown
+					if !c.DisableLogging {
+						dlog.Printf(c.ctx, "timed out after %v; sending SIGINT", c.Timeout)
+					}
+					_ = sigint.SendInterrupt(c.Cmd.Process)
+					timer := dtime.NewTimer(c.ctx, c.killTimeout())
+					defer timer.Stop()
+					select {
+					case <-hardDone: // hard shutdown
+					case <-timer.C:
+					case <-c.waitDone:
+						// it exited
+						return
+					}
+					c.hardKill()
+					return
 				case <-c.waitDone:
 					// it exited on its own
 					return
 				}
 			}
 			select {
-			case <-dcontext.HardContext(c.ctx).Done(): // hard shutdown
+			case <-hardDone: // hard shutdown
+				c.hardKill()
+			case <-timedOut:
 				if !c.DisableLogging {
-					dlog.Print(c.ctx, "sending SIGKILL")
+					dlog.Printf(c.ctx, "timed out after %v", c.Timeout)
 				}
-				c.osCancel() // let os/exec send it for us
+				c.hardKill()
 			case <-c.waitDone:
 				// it exited on its own
 			}
@@ -232,17 +562,134 @@ func (c *Cmd) Start() error {
 	return err
 }
 
+// abortStart releases the resources that CommandContext set up for a
+// command that is not going to be started, and returns err.
+func (c *Cmd) abortStart(err error) error {
+	c.osCancel()
+	c.pidlock.Unlock()
+	return err
+}
+
+// wrapTransform wraps o such that its data is passed through
+// transform (which may be nil).
+func (c *Cmd) wrapTransform(o io.Writer, transform func(io.Reader) io.Reader) io.Writer {
+	if transform == nil {
+		return o
+	}
+	w := newTransformWriter(o, transform)
+	c.transformWriters = append(c.transformWriters, w)
+	return w
+}
+
+// closeTransforms closes the writers that StdoutTransform and
+// StderrTransform are running in, waiting for them to finish writing
+// the transformed output, and returns the first error that either
+// encountered.
+func (c *Cmd) closeTransforms() error {
+	var err error
+	for _, w := range c.transformWriters {
+		if closeErr := w.close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	c.transformWriters = nil
+	return err
+}
+
+// hardKill kills the process in response to the hard Context being
+// canceled; sending .KillSignal first if it is set.
+func (c *Cmd) hardKill() {
+	if c.sendKillSignal() && c.awaitExit() {
+		return
+	}
+	c.sendSIGKILL()
+}
+
+// sendKillSignal sends .KillSignal to the process, and returns
+// whether it was set.
+func (c *Cmd) sendKillSignal() bool {
+	if c.KillSignal == nil {
+		return false
+	}
+	if !c.DisableLogging {
+		dlog.Printf(c.ctx, "sending signal: %v", c.KillSignal)
+	}
+	if err := c.Process.Signal(c.KillSignal); err != nil && !c.DisableLogging {
+		dlog.Printf(c.ctx, "failed to send signal: %v", err)
+	}
+	return true
+}
+
+// awaitExit waits up to .KillTimeout for the process to exit, and
+// returns whether it did.
+func (c *Cmd) awaitExit() bool {
+	timer := dtime.NewTimer(c.ctx, c.killTimeout())
+	defer timer.Stop()
+	select {
+	case <-c.waitDone:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+func (c *Cmd) sendSIGKILL() {
+	if !c.DisableLogging {
+		dlog.Print(c.ctx, "sending SIGKILL")
+	}
+	c.osCancel() // let os/exec send it for us
+}
+
+// killTimeout returns .KillTimeout, or its default if it is unset.
+func (c *Cmd) killTimeout() time.Duration {
+	if c.KillTimeout == 0 {
+		return 5 * time.Second
+	}
+	return c.KillTimeout
+}
+
 // Wait waits for the command to exit and waits for any copying to
 // stdin or copying from stdout or stderr to complete.
 //
 // See the os/exec.Cmd.Wait documenaton for more information.
 func (c *Cmd) Wait() error {
 	err := c.Cmd.Wait()
+	if c.stdinReader != nil {
+		c.stdinReader.close()
+		c.stdinReader = nil
+	}
+	if c.isSuccessExit(err) {
+		err = nil
+	}
+	if c.OutputTransform != nil {
+		// Flush any incomplete final lines.
+		for _, w := range []io.Writer{c.Stdout, c.Stderr} {
+			if lw, ok := w.(*loggingWriter); ok {
+				if flushErr := lw.flush(); flushErr != nil && err == nil {
+					err = flushErr
+				}
+			}
+		}
+	}
+	if transformErr := c.closeTransforms(); transformErr != nil && err == nil {
+		err = transformErr
+	}
 
 	if c.waitDone != nil {
 		c.waitOnce.Do(func() { close(c.waitDone) })
 	}
 	<-c.supervisorDone
+	if c.timeoutTimer != nil {
+		c.timeoutTimer.Stop()
+		c.timeoutTimer = nil
+	}
+	select {
+	case <-c.timedOut:
+		if err != nil && c.ctx.Err() == nil {
+			err = &timeoutError{timeout: c.Timeout, err: err}
+		}
+	default:
+	}
 
 	pid := -1
 	if c.Process != nil {
@@ -261,6 +708,36 @@ func (c *Cmd) Wait() error {
 	return err
 }
 
+// timeoutError is returned by Wait when the command was shut down
+// because of Cmd.Timeout.
+type timeoutError struct {
+	timeout time.Duration
+	err     error
+}
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("timed out after %v: %v", e.timeout, e.err)
+}
+
+func (e *timeoutError) Unwrap() error { return e.err }
+
+func (e *timeoutError) Is(target error) bool { return target == context.DeadlineExceeded }
+
+// isSuccessExit returns whether err is an ExitError for one of the
+// codes in .SuccessExitCodes.
+func (c *Cmd) isSuccessExit(err error) bool {
+	var exitErr *ExitError
+	if len(c.SuccessExitCodes) == 0 || !errors.As(err, &exitErr) {
+		return false
+	}
+	for _, code := range c.SuccessExitCodes {
+		if exitErr.ExitCode() == code {
+			return true
+		}
+	}
+	return false
+}
+
 // StdinPipe returns a pipe that will be connected to the command's
 // standard input when the command starts.
 //
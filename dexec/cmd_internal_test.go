@@ -0,0 +1,187 @@
+package dexec
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestSplitIOLogData(t *testing.T) {
+	testcases := map[string]struct {
+		Input    string
+		Max      int
+		Expected []string
+	}{
+		"unlimited": {"abcdef\n", 0, []string{"abcdef\n"}},
+		"short":     {"abc\n", 3, []string{"abc\n"}},
+		"no-newline": {"abcdef", 3, []string{
+			"abc [truncated, continued]",
+			" [continuation]def",
+		}},
+		"utf8": {"aé€\n", 3, []string{
+			"aé [truncated, continued]",
+			" [continuation]€\n",
+		}},
+	}
+	for tcName, tcData := range testcases {
+		tcData := tcData
+		t.Run(tcName, func(t *testing.T) {
+			assert.Equal(t, tcData.Expected, splitIOLogData(tcData.Input, tcData.Max))
+		})
+	}
+}
+
+func TestStartErrorReleasesLock(t *testing.T) {
+	ctx := dlog.NewTestContext(t, true)
+
+	cmd := CommandContext(ctx, os.Args[0])
+	cmd.StdinData = []byte("hello")
+	cmd.Stdin = strings.NewReader("world")
+	assert.Error(t, cmd.Start())
+	assert.True(t, cmd.pidlock.TryLock(), "pidlock is still held")
+
+	p := NewPipeline(ctx, CommandContext(ctx, os.Args[0]), CommandContext(ctx, os.Args[0]))
+	p.cmds[0].Stdout = io.Discard
+	assert.Error(t, p.Start())
+	for i, cmd := range p.cmds {
+		assert.True(t, cmd.pidlock.TryLock(), "cmds[%d].pidlock is still held", i)
+	}
+}
+
+func TestStdinNotWrappedWithoutCancel(t *testing.T) {
+	cmd := CommandContext(dlog.NewTestContext(t, true), os.Args[0], "-test.run=^$")
+	cmd.Stdin = strings.NewReader("hello")
+	cmd.StdinCtx = context.Background()
+	if !assert.NoError(t, cmd.Start()) {
+		return
+	}
+	defer func() { _ = cmd.Wait() }()
+	if lr, ok := cmd.Stdin.(*loggingReader); assert.True(t, ok) {
+		_, wrapped := lr.reader.(*contextReader)
+		assert.False(t, wrapped, "stdin wrapped in a contextReader even though StdinCtx cannot be canceled")
+	}
+}
+
+func TestStdinNotWrappedIfCannotBlock(t *testing.T) {
+	testcases := map[string]struct {
+		Stdin    io.Reader
+		StdinCtx context.Context
+		Wrapped  bool
+	}{
+		"strings.Reader":          {strings.NewReader("hello"), nil, false},
+		"strings.Reader-StdinCtx": {strings.NewReader("hello"), context.Background(), false},
+		"WriterTo":                {bufio.NewReader(strings.NewReader("hello")), nil, false},
+		"WriterTo-StdinCtx":       {bufio.NewReader(strings.NewReader("hello")), dcontext.WithSoftness(context.Background()), true},
+		"Reader":                  {io.LimitReader(strings.NewReader("hello"), 5), nil, true},
+	}
+	for tcName, tcData := range testcases {
+		tcData := tcData
+		t.Run(tcName, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(dlog.NewTestContext(t, true))
+			defer cancel()
+			stdinCtx := tcData.StdinCtx
+			if stdinCtx != nil {
+				var stdinCancel context.CancelFunc
+				stdinCtx, stdinCancel = context.WithCancel(stdinCtx)
+				defer stdinCancel()
+			}
+			cmd := CommandContext(ctx, os.Args[0], "-test.run=^$")
+			cmd.Stdin = tcData.Stdin
+			cmd.StdinCtx = stdinCtx
+			if !assert.NoError(t, cmd.Start()) {
+				return
+			}
+			defer func() { _ = cmd.Wait() }()
+			if lr, ok := cmd.Stdin.(*loggingReader); assert.True(t, ok) {
+				_, wrapped := lr.reader.(*contextReader)
+				assert.Equal(t, tcData.Wrapped, wrapped)
+			}
+		})
+	}
+}
+
+func TestContextReaderSinglePump(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var readers []int64
+	r := newContextReader(ctx, readerFunc(func(p []byte) (int, error) {
+		readers = append(readers, goid())
+		if len(readers) == 3 {
+			return 0, io.EOF
+		}
+		return copy(p, "x"), nil
+	}))
+	data, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "xx", string(data))
+	if assert.Len(t, readers, 3) {
+		assert.Equal(t, readers[0], readers[1])
+		assert.Equal(t, readers[0], readers[2])
+	}
+}
+
+// goid returns the ID of the calling goroutine.
+func goid() int64 {
+	var buf [64]byte
+	str := strings.TrimPrefix(string(buf[:runtime.Stack(buf[:], false)]), "goroutine ")
+	id, _ := strconv.ParseInt(str[:strings.IndexByte(str, ' ')], 10, 64)
+	return id
+}
+
+type readerFunc func([]byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) { return f(p) }
+
+func TestContextReaderAbandoned(t *testing.T) {
+	const n = 100
+	before := runtime.NumGoroutine()
+	var blocked int32
+
+	var writers []*io.PipeWriter
+	for i := 0; i < n; i++ {
+		pr, pw := io.Pipe()
+		writers = append(writers, pw)
+		ctx, cancel := context.WithCancel(context.Background())
+		reading := make(chan struct{})
+		r := newContextReader(ctx, readerFunc(func(p []byte) (int, error) {
+			atomic.AddInt32(&blocked, 1)
+			defer atomic.AddInt32(&blocked, -1)
+			close(reading)
+			return pr.Read(p)
+		}))
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, err := r.Read(make([]byte, 16))
+			assert.ErrorIs(t, err, context.Canceled)
+		}()
+		<-reading
+		cancel()
+		<-done
+	}
+	// The abandoned Reads are still blocked until the underlying
+	// readers return.
+	assert.Equal(t, int32(n), atomic.LoadInt32(&blocked))
+
+	for _, pw := range writers {
+		_ = pw.Close()
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+}
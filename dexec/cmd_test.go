@@ -0,0 +1,278 @@
+package dexec_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dexec"
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/dlib/dtime/v2"
+)
+
+func TestStdinData(t *testing.T) {
+	cmd := dexec.CommandContext(dlog.NewTestContext(t, true), os.Args[0], "-test.run=TestHelperProcess", "--", "cat")
+	cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1"}
+	cmd.StdinData = []byte("hello\nworld\n")
+	output, err := cmd.Output()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "hello\nworld\n", string(output))
+}
+
+func TestStdinDataConflict(t *testing.T) {
+	cmd := dexec.CommandContext(dlog.NewTestContext(t, true), os.Args[0], "-test.run=TestHelperProcess", "--", "cat")
+	cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1"}
+	cmd.StdinData = []byte("hello")
+	cmd.Stdin = strings.NewReader("world")
+	assert.EqualError(t, cmd.Start(), "dexec.Cmd.Start: StdinData and Stdin are both set")
+}
+
+// TestIgnoreSIGINTHelperProcess ignores SIGINT, prints "ready", and then
+// sleeps for a long time.
+func TestIgnoreSIGINTHelperProcess(*testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	defer os.Exit(0)
+
+	signal.Ignore(os.Interrupt)
+	fmt.Println("ready")
+	time.Sleep(time.Minute)
+}
+
+// TestRetryHelperProcess fails (after printing the attempt number) until it
+// has been run $RETRY_SUCCEED_ON times, counting attempts in the file
+// $RETRY_COUNTER.
+func TestRetryHelperProcess(*testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	defer os.Exit(0)
+
+	counterFile := os.Getenv("RETRY_COUNTER")
+	succeedOn, _ := strconv.Atoi(os.Getenv("RETRY_SUCCEED_ON"))
+	content, _ := os.ReadFile(counterFile)
+	attempt, _ := strconv.Atoi(string(content))
+	attempt++
+	_ = os.WriteFile(counterFile, []byte(strconv.Itoa(attempt)), 0644)
+
+	fmt.Printf("attempt %d\n", attempt)
+	if attempt < succeedOn {
+		os.Exit(3)
+	}
+}
+
+func newRetryCmd(ctx context.Context, t *testing.T, succeedOn int) *dexec.Cmd {
+	cmd := dexec.CommandContext(ctx, os.Args[0], "-test.run=TestRetryHelperProcess")
+	cmd.Env = []string{
+		"GO_WANT_HELPER_PROCESS=1",
+		"RETRY_COUNTER=" + filepath.Join(t.TempDir(), "counter"),
+		"RETRY_SUCCEED_ON=" + strconv.Itoa(succeedOn),
+	}
+	return cmd
+}
+
+func TestRetry(t *testing.T) {
+	t.Run("succeeds", func(t *testing.T) {
+		var log strings.Builder
+		cmd := newRetryCmd(newCapturingContext(t, &log), t, 3)
+		cmd.Retry = dexec.Retry{
+			MaxAttempts: 5,
+			Backoff:     func(int) time.Duration { return 0 },
+		}
+		output, err := cmd.Output()
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "attempt 3\n", string(output))
+		assert.Contains(t, log.String(), `level=warning msg="attempt 1/5 failed with exit code 3; retrying in 0s"`)
+		assert.Contains(t, log.String(), `level=warning msg="attempt 2/5 failed with exit code 3; retrying in 0s"`)
+		assert.NotContains(t, log.String(), `attempt 3/5`)
+	})
+	t.Run("exhausted", func(t *testing.T) {
+		var log strings.Builder
+		cmd := newRetryCmd(newCapturingContext(t, &log), t, 5)
+		cmd.Retry = dexec.Retry{
+			MaxAttempts: 2,
+			Backoff:     func(int) time.Duration { return 0 },
+		}
+		output, err := cmd.Output()
+		assert.EqualError(t, err, "exit status 3")
+		assert.Equal(t, "attempt 2\n", string(output))
+		assert.Equal(t, 1, strings.Count(log.String(), "level=warning"))
+	})
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(dlog.NewTestContext(t, true))
+		defer cancel()
+		cmd := newRetryCmd(ctx, t, 5)
+		cmd.Retry = dexec.Retry{
+			MaxAttempts: 5,
+			Backoff:     func(int) time.Duration { return time.Hour },
+		}
+		time.AfterFunc(time.Second, cancel)
+		start := time.Now()
+		output, err := cmd.Output()
+		assert.EqualError(t, err, "exit status 3")
+		assert.Equal(t, "attempt 1\n", string(output))
+		assert.Less(t, time.Since(start), time.Minute)
+	})
+	t.Run("fakeClock", func(t *testing.T) {
+		// The backoff is measured with the Context's Clock, so a
+		// FakeClock controls it.
+		clock := dtime.NewFakeClock()
+		ctx := dtime.WithClock(dlog.NewTestContext(t, true), clock)
+		cmd := newRetryCmd(ctx, t, 2)
+		cmd.Retry = dexec.Retry{
+			MaxAttempts: 2,
+			Backoff:     func(int) time.Duration { return time.Hour },
+		}
+		type result struct {
+			output []byte
+			err    error
+		}
+		done := make(chan result)
+		go func() {
+			output, err := cmd.Output()
+			done <- result{output, err}
+		}()
+
+		waitCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		if !assert.NoError(t, clock.WaitFor(waitCtx, 1)) {
+			return
+		}
+		clock.Step(time.Hour - time.Second)
+		select {
+		case <-done:
+			t.Fatal("retried before the backoff elapsed")
+		case <-time.After(100 * time.Millisecond):
+		}
+		clock.Step(time.Second)
+		res := <-done
+		assert.NoError(t, res.err)
+		assert.Equal(t, "attempt 2\n", string(res.output))
+	})
+}
+
+func TestDefaultBackoff(t *testing.T) {
+	assert.Equal(t, 1*time.Second, dexec.DefaultBackoff(1))
+	assert.Equal(t, 2*time.Second, dexec.DefaultBackoff(2))
+	assert.Equal(t, 16*time.Second, dexec.DefaultBackoff(5))
+	assert.Equal(t, 30*time.Second, dexec.DefaultBackoff(6))
+	assert.Equal(t, 30*time.Second, dexec.DefaultBackoff(100))
+}
+
+func TestSuccessExitCodes(t *testing.T) {
+	if _, err := dexec.LookPath(context.Background(), "grep"); err != nil {
+		t.Skip("skipping test; grep not found")
+	}
+	t.Run("listed", func(t *testing.T) {
+		var log strings.Builder
+		cmd := dexec.CommandContext(newCapturingContext(t, &log), "grep", "needle")
+		cmd.StdinData = []byte("haystack\n")
+		cmd.SuccessExitCodes = []int{1}
+		output, err := cmd.Output()
+		assert.NoError(t, err)
+		assert.Empty(t, output)
+		assert.Contains(t, log.String(), `msg="finished successfully: exit status 1"`)
+	})
+	t.Run("unlisted", func(t *testing.T) {
+		var log strings.Builder
+		cmd := dexec.CommandContext(newCapturingContext(t, &log), "grep", "needle")
+		cmd.StdinData = []byte("haystack\n")
+		cmd.SuccessExitCodes = []int{2}
+		err := cmd.Run()
+		assert.EqualError(t, err, "exit status 1")
+		assert.Contains(t, log.String(), `msg="finished with error: exit status 1"`)
+	})
+	t.Run("notRetried", func(t *testing.T) {
+		cmd := newRetryCmd(dlog.NewTestContext(t, true), t, 5)
+		cmd.SuccessExitCodes = []int{3}
+		cmd.Retry = dexec.Retry{
+			MaxAttempts: 5,
+			Backoff:     func(int) time.Duration { return 0 },
+		}
+		output, err := cmd.Output()
+		assert.NoError(t, err)
+		assert.Equal(t, "attempt 1\n", string(output))
+	})
+}
+
+func TestTimeout(t *testing.T) {
+	for _, soft := range []bool{false, true} {
+		soft := soft
+		name := "hard"
+		if soft {
+			name = "soft"
+		}
+		t.Run(name, func(t *testing.T) {
+			ctx := dlog.NewTestContext(t, false)
+			if soft {
+				ctx = dcontext.WithSoftness(ctx)
+			}
+			cmd := dexec.CommandContext(ctx, os.Args[0], "-test.run=TestHelperProcess", "--", "sleep")
+			cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1"}
+			cmd.Timeout = 100 * time.Millisecond
+
+			start := time.Now()
+			err := cmd.Run()
+			assert.Less(t, time.Since(start), 2*time.Second)
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+			var exitErr *dexec.ExitError
+			assert.ErrorAs(t, err, &exitErr)
+			assert.NoError(t, ctx.Err())
+		})
+	}
+	t.Run("escalates", func(t *testing.T) {
+		// A soft Context, and a command that ignores SIGINT; it should be killed
+		// KillTimeout after the Timeout expires.
+		fc := dtime.NewFakeClock()
+		ctx := dtime.WithClock(dcontext.WithSoftness(dlog.NewTestContext(t, false)), fc)
+		cmd := dexec.CommandContext(ctx, os.Args[0], "-test.run=TestIgnoreSIGINTHelperProcess")
+		cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1"}
+		cmd.Timeout = time.Hour
+		cmd.KillTimeout = time.Minute
+		stdout, err := cmd.StdoutPipe()
+		if !assert.NoError(t, err) {
+			return
+		}
+		if !assert.NoError(t, cmd.Start()) {
+			return
+		}
+		line, err := bufio.NewReader(stdout).ReadString('\n')
+		assert.NoError(t, err)
+		assert.Equal(t, "ready\n", line)
+
+		waitCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		assert.NoError(t, fc.WaitFor(waitCtx, 1)) // the Timeout timer
+		fc.Step(time.Hour)
+		assert.NoError(t, fc.WaitFor(waitCtx, 1)) // the KillTimeout timer
+		fc.Step(time.Minute)
+
+		err = cmd.Wait()
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.False(t, cmd.ProcessState.Success())
+		assert.NoError(t, ctx.Err())
+	})
+	t.Run("finishes", func(t *testing.T) {
+		cmd := dexec.CommandContext(dlog.NewTestContext(t, true), os.Args[0], "-test.run=TestHelperProcess", "--", "echo", "hi")
+		cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1"}
+		cmd.Timeout = time.Minute
+		output, err := cmd.Output()
+		assert.NoError(t, err)
+		assert.Equal(t, "hi\n", string(output))
+	})
+}
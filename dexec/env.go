@@ -0,0 +1,56 @@
+package dexec
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strings"
+)
+
+// CommandContextWithEnv is like CommandContext, but sets the command's
+// environment to the calling process's environment (os.Environ) with
+// env merged on top of it.  A key in env with an empty value removes
+// that variable from the environment.
+func CommandContextWithEnv(ctx context.Context, env map[string]string, name string, arg ...string) *Cmd {
+	cmd := CommandContext(ctx, name, arg...)
+	cmd.Env = mergeEnv(os.Environ(), env)
+	return cmd
+}
+
+// mergeEnv returns a copy of base (a list of "KEY=value" strings) with
+// overrides applied.  The order of base is preserved; new keys are
+// appended in sorted order.
+func mergeEnv(base []string, overrides map[string]string) []string {
+	ret := make([]string, 0, len(base)+len(overrides))
+	seen := make(map[string]bool, len(overrides))
+	for _, kv := range base {
+		key := kv
+		if eq := strings.IndexByte(kv, '='); eq >= 0 {
+			key = kv[:eq]
+		}
+		val, overridden := overrides[key]
+		switch {
+		case !overridden:
+			ret = append(ret, kv)
+		case seen[key] || val == "":
+			// drop it
+		default:
+			ret = append(ret, key+"="+val)
+		}
+		if overridden {
+			seen[key] = true
+		}
+	}
+
+	keys := make([]string, 0, len(overrides))
+	for key, val := range overrides {
+		if !seen[key] && val != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		ret = append(ret, key+"="+overrides[key])
+	}
+	return ret
+}
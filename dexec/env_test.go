@@ -0,0 +1,75 @@
+package dexec_test
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dexec"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestCommandContextWithEnv(t *testing.T) {
+	t.Setenv("DEXEC_TEST_OVERRIDE", "old")
+	t.Setenv("DEXEC_TEST_DELETE", "old")
+
+	cmd := dexec.CommandContextWithEnv(dlog.NewTestContext(t, true),
+		map[string]string{
+			"GO_WANT_HELPER_PROCESS": "1",
+			"DEXEC_TEST_OVERRIDE":    "new",
+			"DEXEC_TEST_DELETE":      "",
+			"DEXEC_TEST_ADD":         "added",
+		},
+		os.Args[0], "-test.run=TestHelperProcess", "--", "echoenv",
+		"DEXEC_TEST_OVERRIDE", "DEXEC_TEST_DELETE", "DEXEC_TEST_ADD", "PATH")
+	output, err := cmd.Output()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "new\n\nadded\n"+os.Getenv("PATH")+"\n", string(output))
+
+	assert.NotContains(t, cmd.Env, "DEXEC_TEST_DELETE=old")
+}
+
+func TestEnvInherit(t *testing.T) {
+	t.Setenv("HOME", "/home/dexec-test")
+	if os.Getenv("PATH") == "" {
+		t.Setenv("PATH", "/bin")
+	}
+
+	cmd := dexec.CommandContext(dlog.NewTestContext(t, true),
+		os.Args[0], "-test.run=TestHelperProcess", "--", "echoenv", "PATH", "HOME", "DEXEC_TEST_ADD")
+	assert.True(t, cmd.EnvInherit)
+	cmd.EnvInherit = false
+	cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1", "DEXEC_TEST_ADD=added"}
+	output, err := cmd.Output()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "\n\nadded\n", string(output))
+}
+
+func TestEnvInheritNilEnv(t *testing.T) {
+	envPath, err := exec.LookPath("env")
+	if err != nil {
+		t.Skip("no env(1) program:", err)
+	}
+
+	cmd := dexec.CommandContext(dlog.NewTestContext(t, true), envPath)
+	cmd.EnvInherit = false
+	output, err := cmd.Output()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "", string(output))
+
+	// The default is to inherit.
+	t.Setenv("DEXEC_TEST_INHERITED", "yes")
+	output, err = dexec.CommandContext(dlog.NewTestContext(t, true), envPath).Output()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, string(output), "DEXEC_TEST_INHERITED=yes\n")
+}
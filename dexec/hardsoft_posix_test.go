@@ -0,0 +1,114 @@
+// +build !windows
+
+package dexec_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dexec"
+)
+
+func TestKillSignal(t *testing.T) {
+	log := &strings.Builder{}
+	ctx := newCapturingContext(t, log)
+	ctx, hardCancel := context.WithCancel(ctx)
+	defer hardCancel()
+
+	output := &lineBuffer{
+		lines: make(chan string, 50),
+	}
+	cmd := dexec.CommandContext(ctx, os.Args[0], "-test.run=TestKillSignalHelperProcess")
+	cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1"}
+	cmd.Stdout = output
+	cmd.KillSignal = syscall.SIGTERM
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// give it a chance to set up the signal handler
+	line := <-output.lines
+	if line != "started\n" {
+		t.Fatalf("didn't get expected output: %q", line)
+	}
+
+	// send SIGTERM
+	hardCancel()
+	assert.NoError(t, cmd.Wait())
+
+	assert.Equal(t, fmt.Sprintf(``+
+		`level=info msg="started command [%[1]s \"-test.run=TestKillSignalHelperProcess\"]" dexec.pid=%[2]d`+"\n"+
+		`level=info dexec.err=EOF dexec.pid=%[2]d dexec.stream=stdin`+"\n"+
+		`level=info dexec.data="started\n" dexec.pid=%[2]d dexec.stream=stdout`+"\n"+
+		`level=info msg="sending signal: terminated"`+"\n"+
+		`level=info dexec.data="caught signal: terminated\n" dexec.pid=%[2]d dexec.stream=stdout`+"\n"+
+		`level=info msg="finished successfully: exit status 0" dexec.pid=%[2]d`+"\n"+
+		``, quote15(os.Args[0]), cmd.ProcessState.Pid()),
+		log.String())
+}
+
+func TestKillSignalTimeout(t *testing.T) {
+	log := &strings.Builder{}
+	ctx := newCapturingContext(t, log)
+	ctx, hardCancel := context.WithCancel(ctx)
+	defer hardCancel()
+
+	output := &lineBuffer{
+		lines: make(chan string, 50),
+	}
+	// TestSoftHelperProcess catches SIGTERM but does not exit.
+	cmd := dexec.CommandContext(ctx, os.Args[0], "-test.run=TestSoftHelperProcess")
+	cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1"}
+	cmd.Stdout = output
+	cmd.KillSignal = syscall.SIGTERM
+	cmd.KillTimeout = 100 * time.Millisecond
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// give it a chance to set up the signal handler
+	line := <-output.lines
+	if line != "started\n" {
+		t.Fatalf("didn't get expected output: %q", line)
+	}
+
+	// send SIGTERM, then SIGKILL
+	hardCancel()
+	err := cmd.Wait()
+	if assert.Error(t, err) {
+		assert.Equal(t, errKilled, err.Error())
+	}
+
+	assert.Equal(t, fmt.Sprintf(``+
+		`level=info msg="started command [%[1]s \"-test.run=TestSoftHelperProcess\"]" dexec.pid=%[2]d`+"\n"+
+		`level=info dexec.err=EOF dexec.pid=%[2]d dexec.stream=stdin`+"\n"+
+		`level=info dexec.data="started\n" dexec.pid=%[2]d dexec.stream=stdout`+"\n"+
+		`level=info msg="sending signal: terminated"`+"\n"+
+		`level=info dexec.data="caught signal: terminated\n" dexec.pid=%[2]d dexec.stream=stdout`+"\n"+
+		`level=info msg="sending SIGKILL"`+"\n"+
+		`level=info msg="finished with error: `+errKilled+`" dexec.pid=%[2]d`+"\n"+
+		``, quote15(os.Args[0]), cmd.ProcessState.Pid()),
+		log.String())
+}
+
+func TestKillSignalHelperProcess(*testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	defer os.Exit(0)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGTERM)
+
+	fmt.Println("started")
+
+	fmt.Println("caught signal:", <-sigs)
+}
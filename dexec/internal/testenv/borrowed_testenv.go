@@ -1,5 +1,5 @@
 // MODIFIED: META: This file is a verbatim subset of Go 1.15.14 internal/testenv/testenv.go,
-// MODIFIED: META: except that the imports list has been changed.
+// MODIFIED: META: except that the imports list has been changed, and for lines marked "MODIFIED".
 
 // Copyright 2015 The Go Authors. All rights reserved.
 // Use of this source code is governed by a BSD-style
@@ -14,6 +14,7 @@
 package testenv
 
 import (
+	"context" // MODIFIED: ADDED
 	"errors"
 	exec "github.com/datawire/dlib/dexec"         // MODIFIED: FROM: "os/exec"
 	"github.com/datawire/dlib/dexec/internal/cfg" // MODIFIED: FROM: "internal/cfg"
@@ -89,7 +90,7 @@ func GoTool() (string, error) {
 	if _, err := os.Stat(path); err == nil {
 		return path, nil
 	}
-	goBin, err := exec.LookPath("go" + exeSuffix)
+	goBin, err := exec.LookPath(context.Background(), "go"+exeSuffix) // MODIFIED: FROM: goBin, err := exec.LookPath("go" + exeSuffix)
 	if err != nil {
 		return "", errors.New("cannot find go tool: " + err.Error())
 	}
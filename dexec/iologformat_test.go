@@ -0,0 +1,99 @@
+package dexec_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dexec"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestIOLogFormatHelperProcess(*testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	defer os.Exit(0)
+
+	_, _ = os.Stdout.Write(allBytes())
+}
+
+func allBytes() []byte {
+	ret := make([]byte, 256)
+	for i := range ret {
+		ret[i] = byte(i)
+	}
+	return ret
+}
+
+// runIOLogFormat runs TestIOLogFormatHelperProcess with the given
+// IOLogFormat, and returns the dexec.data of each stdout log entry.
+func runIOLogFormat(t *testing.T, format dexec.IOLogFormat) []string {
+	var logOutput bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&logOutput)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	ctx := dlog.WithLogger(context.Background(), dlog.WrapLogrus(logger))
+
+	cmd := dexec.CommandContext(ctx, os.Args[0], "-test.run=TestIOLogFormatHelperProcess")
+	cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+	cmd.IOLogFormat = format
+	output, err := cmd.Output()
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	assert.Equal(t, allBytes(), output, "the output itself is not encoded")
+
+	var chunks []string
+	scanner := bufio.NewScanner(&logOutput)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		if !assert.NoError(t, json.Unmarshal(scanner.Bytes(), &entry)) {
+			return nil
+		}
+		if data, ok := entry["dexec.data"].(string); ok && entry["dexec.stream"] == "stdout" {
+			chunks = append(chunks, data)
+		}
+	}
+	return chunks
+}
+
+func TestIOLogFormat(t *testing.T) {
+	// The logging writer splits the data after each newline, so
+	// 0x00-0x0a are logged separately from 0x0b-0xff.
+	data := allBytes()
+	parts := [][]byte{data[:0x0b], data[0x0b:]}
+
+	t.Run("Raw", func(t *testing.T) {
+		chunks := runIOLogFormat(t, dexec.IOLogFormatRaw)
+		if assert.Len(t, chunks, 2) {
+			assert.Equal(t, string(parts[0]), chunks[0])
+		}
+	})
+	t.Run("HexDump", func(t *testing.T) {
+		chunks := runIOLogFormat(t, dexec.IOLogFormatHexDump)
+		assert.Equal(t, []string{hex.Dump(parts[0]), hex.Dump(parts[1])}, chunks)
+	})
+	t.Run("Base64", func(t *testing.T) {
+		chunks := runIOLogFormat(t, dexec.IOLogFormatBase64)
+		var decoded []byte
+		for _, chunk := range chunks {
+			b, err := base64.StdEncoding.DecodeString(chunk)
+			assert.NoError(t, err)
+			decoded = append(decoded, b...)
+		}
+		assert.Equal(t, data, decoded)
+		assert.Equal(t, []string{
+			base64.StdEncoding.EncodeToString(parts[0]),
+			base64.StdEncoding.EncodeToString(parts[1]),
+		}, chunks)
+	})
+}
@@ -30,6 +30,7 @@ func (w *logWriter) Write(p []byte) (int, error) {
 func newCapturingContext(tb testing.TB, w io.Writer) context.Context {
 	logger := logrus.New()
 	logger.SetOutput(&logWriter{tb, w})
+	logger.SetLevel(logrus.TraceLevel)
 	logger.SetFormatter(&logrus.TextFormatter{
 		DisableTimestamp: true,
 	})
@@ -180,6 +181,7 @@ func TestLogging(t *testing.T) {
 	testcases := map[string]struct {
 		InputStdout         io.Writer
 		InputDisableLogging bool
+		InputIOLogLevel     *dlog.LogLevel
 		ExpectedOutput      string
 	}{
 		"default": {
@@ -195,6 +197,15 @@ func TestLogging(t *testing.T) {
 			InputDisableLogging: true,
 			ExpectedOutput:      "",
 		},
+		"IOLogLevel": {
+			InputStdout:     &strings.Builder{},
+			InputIOLogLevel: func() *dlog.LogLevel { l := dlog.LogLevelDebug; return &l }(),
+			ExpectedOutput: `` +
+				`level=info msg="started command [` + quote15(os.Args[0]) + ` \"-test.run=TestLoggingHelperProcess\"]" dexec.pid={{ .PID }}` + "\n" +
+				`level=debug dexec.err=EOF dexec.pid={{ .PID }} dexec.stream=stdin` + "\n" +
+				`level=debug dexec.data="this is stdout\n" dexec.pid={{ .PID }} dexec.stream=stdout` + "\n" +
+				`level=info msg="finished successfully: exit status 0" dexec.pid={{ .PID }}` + "\n",
+		},
 	}
 	for tcName, tcData := range testcases {
 		tcData := tcData
@@ -206,6 +217,9 @@ func TestLogging(t *testing.T) {
 			cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
 			cmd.Stdout = tcData.InputStdout
 			cmd.DisableLogging = tcData.InputDisableLogging
+			if tcData.InputIOLogLevel != nil {
+				cmd.IOLogLevel = *tcData.InputIOLogLevel
+			}
 
 			assert.NoError(t, cmd.Run())
 
@@ -230,3 +244,116 @@ func TestLoggingHelperProcess(*testing.T) {
 
 	fmt.Println("this is stdout")
 }
+
+func TestOutputTransformHelperProcess(*testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	defer os.Exit(0)
+
+	fmt.Print("foo\nbar\npartial")
+}
+
+func TestOutputTransform(t *testing.T) {
+	var actualLog strings.Builder
+	ctx := newCapturingContext(t, &actualLog)
+
+	cmd := dexec.CommandContext(ctx, os.Args[0], "-test.run=TestOutputTransformHelperProcess")
+	cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+	cmd.OutputTransform = func(line string) string { return "[" + line + "]" }
+
+	output, err := cmd.Output()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "[foo]\n[bar]\npartial\n", string(output))
+
+	pid := cmd.ProcessState.Pid()
+	expectedLog := `` +
+		fmt.Sprintf(`level=info msg="started command [%s \"-test.run=TestOutputTransformHelperProcess\"]" dexec.pid=%d`, quote15(os.Args[0]), pid) + "\n" +
+		fmt.Sprintf(`level=info dexec.err=EOF dexec.pid=%d dexec.stream=stdin`, pid) + "\n" +
+		fmt.Sprintf(`level=info dexec.data="[foo]\n" dexec.pid=%d dexec.stream=stdout`, pid) + "\n" +
+		fmt.Sprintf(`level=info dexec.data="[bar]\n" dexec.pid=%d dexec.stream=stdout`, pid) + "\n" +
+		fmt.Sprintf(`level=info dexec.data="partial\n" dexec.pid=%d dexec.stream=stdout`, pid) + "\n" +
+		fmt.Sprintf(`level=info msg="finished successfully: exit status 0" dexec.pid=%d`, pid) + "\n"
+	assert.Equal(t, expectedLog, actualLog.String())
+}
+
+func TestIOLogMaxLineSizeHelperProcess(*testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	defer os.Exit(0)
+
+	fmt.Println("short")
+	fmt.Println(strings.Repeat("a", 10) + strings.Repeat("b", 10) + strings.Repeat("c", 5))
+}
+
+func TestIOLogMaxLineSize(t *testing.T) {
+	var actualLog strings.Builder
+	ctx := newCapturingContext(t, &actualLog)
+
+	cmd := dexec.CommandContext(ctx, os.Args[0], "-test.run=TestIOLogMaxLineSizeHelperProcess")
+	cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+	cmd.IOLogMaxLineSize = 10
+
+	output, err := cmd.Output()
+	if !assert.NoError(t, err) {
+		return
+	}
+	// The output itself is not split.
+	assert.Equal(t, "short\naaaaaaaaaabbbbbbbbbbccccc\n", string(output))
+
+	pid := cmd.ProcessState.Pid()
+	expectedLog := `` +
+		fmt.Sprintf(`level=info msg="started command [%s \"-test.run=TestIOLogMaxLineSizeHelperProcess\"]" dexec.pid=%d`, quote15(os.Args[0]), pid) + "\n" +
+		fmt.Sprintf(`level=info dexec.err=EOF dexec.pid=%d dexec.stream=stdin`, pid) + "\n" +
+		fmt.Sprintf(`level=info dexec.data="short\n" dexec.pid=%d dexec.stream=stdout`, pid) + "\n" +
+		fmt.Sprintf(`level=info dexec.data="aaaaaaaaaa [truncated, continued]" dexec.pid=%d dexec.stream=stdout`, pid) + "\n" +
+		fmt.Sprintf(`level=info dexec.data=" [continuation]bbbbbbbbbb [truncated, continued]" dexec.pid=%d dexec.stream=stdout`, pid) + "\n" +
+		fmt.Sprintf(`level=info dexec.data=" [continuation]ccccc\n" dexec.pid=%d dexec.stream=stdout`, pid) + "\n" +
+		fmt.Sprintf(`level=info msg="finished successfully: exit status 0" dexec.pid=%d`, pid) + "\n"
+	assert.Equal(t, expectedLog, actualLog.String())
+}
+
+func TestLoggingStdinHelperProcess(*testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	defer os.Exit(0)
+
+	_, _ = io.Copy(io.Discard, os.Stdin)
+}
+
+func TestLoggingStdin(t *testing.T) {
+	testcases := map[string]struct {
+		InputDisableLogging bool
+		ExpectLogged        bool
+	}{
+		"default":        {ExpectLogged: true},
+		"DisableLogging": {InputDisableLogging: true, ExpectLogged: false},
+	}
+	for tcName, tcData := range testcases {
+		tcData := tcData
+		t.Run(tcName, func(t *testing.T) {
+			var actualLog strings.Builder
+			ctx := newCapturingContext(t, &actualLog)
+
+			cmd := dexec.CommandContext(ctx, os.Args[0], "-test.run=TestLoggingStdinHelperProcess")
+			cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+			cmd.Stdin = strings.NewReader("secret")
+			cmd.DisableLogging = tcData.InputDisableLogging
+
+			if !assert.NoError(t, cmd.Run()) {
+				return
+			}
+			pid := cmd.ProcessState.Pid()
+			expectedLine := fmt.Sprintf(`level=info dexec.data=secret dexec.pid=%d dexec.stream=stdin`, pid) + "\n"
+			if tcData.ExpectLogged {
+				assert.Contains(t, actualLog.String(), expectedLine)
+			} else {
+				assert.Equal(t, "", actualLog.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package dexec
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+type pathContextKey struct{}
+
+// WithPath returns a copy of ctx that specifies a PATH (a list of
+// directories separated by os.PathListSeparator) that LookPath, and
+// so CommandContext, searches for commands in, instead of the
+// process's $PATH environment variable.  This is useful for hermetic
+// tests.
+//
+// WithPath only affects how the command itself is found; it does
+// not set the PATH environment variable of the command.
+func WithPath(ctx context.Context, path string) context.Context {
+	return context.WithValue(ctx, pathContextKey{}, path)
+}
+
+// LookPath is like os/exec.LookPath, but if the Context has a PATH
+// set with WithPath, then that is searched instead of the process's
+// $PATH environment variable.
+func LookPath(ctx context.Context, name string) (string, error) {
+	path, ok := ctx.Value(pathContextKey{}).(string)
+	if !ok || strings.ContainsRune(name, os.PathSeparator) || strings.ContainsRune(name, '/') {
+		return exec.LookPath(name)
+	}
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			// Unix shell semantics: path element "" means "."
+			dir = "."
+		}
+		// Let exec.LookPath decide whether the file is executable
+		// (and, on GOOS=windows, try the $PATHEXT extensions).  Make
+		// sure that the candidate contains a separator, or else
+		// exec.LookPath would go searching the process's $PATH.
+		candidate := filepath.Join(dir, name)
+		if !strings.ContainsRune(candidate, os.PathSeparator) {
+			candidate = "." + string(os.PathSeparator) + candidate
+		}
+		if found, err := exec.LookPath(candidate); err == nil {
+			if !filepath.IsAbs(found) {
+				return found, &Error{Name: name, Err: exec.ErrDot}
+			}
+			return found, nil
+		}
+	}
+	return "", &Error{Name: name, Err: ErrNotFound}
+}
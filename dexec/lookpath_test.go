@@ -0,0 +1,125 @@
+package dexec_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dexec"
+	"github.com/datawire/dlib/dlog"
+)
+
+// installMock copies the test binary in to dir as the given command name, so that it can be used
+// as a mock binary with TestHelperProcess.
+func installMock(t *testing.T, dir, name string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	self, err := os.ReadFile(os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, self, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLookPath(t *testing.T) {
+	dirA := t.TempDir()
+	dirB := t.TempDir()
+	mockB := installMock(t, dirB, "dexec-mock-cmd")
+
+	ctx := context.Background()
+
+	// No override: same as os/exec.LookPath
+	_, err := dexec.LookPath(ctx, "dexec-mock-cmd")
+	assert.True(t, errors.Is(err, exec.ErrNotFound))
+
+	// Override
+	ctx = dexec.WithPath(ctx, dirA+string(os.PathListSeparator)+dirB)
+	path, err := dexec.LookPath(ctx, "dexec-mock-cmd")
+	assert.NoError(t, err)
+	assert.Equal(t, mockB, path)
+
+	// Earlier directories take precedence
+	mockA := installMock(t, dirA, "dexec-mock-cmd")
+	path, err = dexec.LookPath(ctx, "dexec-mock-cmd")
+	assert.NoError(t, err)
+	assert.Equal(t, mockA, path)
+
+	// Not found
+	_, err = dexec.LookPath(ctx, "dexec-no-such-cmd")
+	var execErr *dexec.Error
+	if assert.True(t, errors.As(err, &execErr)) {
+		assert.Equal(t, "dexec-no-such-cmd", execErr.Name)
+		assert.True(t, errors.Is(err, dexec.ErrNotFound))
+	}
+
+	// Names with a separator are not searched for
+	path, err = dexec.LookPath(ctx, mockB)
+	assert.NoError(t, err)
+	assert.Equal(t, mockB, path)
+}
+
+func TestLookPathDot(t *testing.T) {
+	cwd := t.TempDir()
+	elsewhere := t.TempDir()
+	installMock(t, elsewhere, "dexec-mock-cmd")
+	// The command is in the process's $PATH, but not in the
+	// Context's PATH; it must not be found.
+	t.Setenv("PATH", elsewhere)
+
+	oldwd, err := os.Getwd()
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, os.Chdir(cwd)) {
+		return
+	}
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	for _, path := range []string{".", "", string(os.PathListSeparator)} {
+		ctx := dexec.WithPath(context.Background(), path)
+		_, err := dexec.LookPath(ctx, "dexec-mock-cmd")
+		assert.True(t, errors.Is(err, dexec.ErrNotFound), "PATH=%q: %v", path, err)
+	}
+
+	// Once it is in the current directory, it is found, but
+	// (like os/exec) it is an error to run it from a relative
+	// PATH entry.
+	installMock(t, cwd, "dexec-mock-cmd")
+	for _, path := range []string{".", string(os.PathListSeparator)} {
+		ctx := dexec.WithPath(context.Background(), path)
+		found, err := dexec.LookPath(ctx, "dexec-mock-cmd")
+		assert.True(t, errors.Is(err, exec.ErrDot), "PATH=%q: %v", path, err)
+		assert.False(t, filepath.IsAbs(found))
+	}
+}
+
+func TestCommandContextWithPath(t *testing.T) {
+	dir := t.TempDir()
+	mock := installMock(t, dir, "dexec-mock-cmd")
+
+	ctx := dexec.WithPath(dlog.NewTestContext(t, false), dir)
+
+	cmd := dexec.CommandContext(ctx, "dexec-mock-cmd", "-test.run=TestHelperProcess", "--", "echo", "mocked")
+	cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+	assert.Equal(t, mock, cmd.Path)
+	assert.Equal(t, "dexec-mock-cmd", cmd.Args[0])
+	out, err := cmd.Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "mocked\n", string(out))
+
+	cmd = dexec.CommandContext(ctx, "dexec-no-such-cmd")
+	err = cmd.Run()
+	assert.True(t, errors.Is(err, dexec.ErrNotFound))
+}
@@ -0,0 +1,199 @@
+package dexec
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/dlib/internal/sigint"
+)
+
+// A Pipeline is a sequence of commands where each command's standard
+// output is connected to the next command's standard input, like a
+// shell "cmd1 | cmd2 | cmd3" pipeline.
+//
+// The .Stdin of the first command and the .Stdout of the last command
+// may be set as usual; the streams connecting the commands are
+// *os.File pipes, and so are not logged.  Each command's log entries
+// have a "dexec.pipeline_index" field identifying its position in the
+// pipeline.
+type Pipeline struct {
+	ctx  context.Context
+	cmds []*Cmd
+
+	supervisorDone chan struct{}
+	waitDone       chan struct{}
+}
+
+// NewPipeline returns a Pipeline that runs the given commands.
+//
+// The commands are shut down according to the pipeline's Context
+// rather than their own Contexts (which are still used for logging).
+// When the pipeline's Context is canceled the commands are signaled
+// in reverse order (last command first): SIGINT for a soft
+// cancellation; for a hard cancellation, each command's .KillSignal
+// and then (after its .KillTimeout) SIGKILL, or just SIGKILL if
+// .KillSignal is not set (see github.com/datawire/dlib/dcontext).
+// Each command's .Timeout still applies to that command alone.
+func NewPipeline(ctx context.Context, cmds ...*Cmd) *Pipeline {
+	for i, cmd := range cmds {
+		cmd.ctx = dlog.WithField(cmd.ctx, "dexec.pipeline_index", i)
+		cmd.pipelined = true
+	}
+	return &Pipeline{
+		ctx:  ctx,
+		cmds: cmds,
+	}
+}
+
+// Start starts all of the commands in the pipeline, but does not wait
+// for them to complete.  If any command fails to start, then the
+// commands that have already been started are killed.
+func (p *Pipeline) Start() error {
+	if len(p.cmds) == 0 {
+		return errors.New("dexec.Pipeline.Start: no commands")
+	}
+	for i, cmd := range p.cmds[:len(p.cmds)-1] {
+		if cmd.Stdout != nil {
+			return p.abortStart(0, errors.Errorf("dexec.Pipeline.Start: cmds[%d].Stdout already set", i))
+		}
+		if next := p.cmds[i+1]; next.Stdin != nil || next.StdinData != nil {
+			return p.abortStart(0, errors.Errorf("dexec.Pipeline.Start: cmds[%d].Stdin already set", i+1))
+		}
+	}
+
+	var pipes []*os.File
+	defer func() {
+		// The children have their own copies; close ours.
+		for _, f := range pipes {
+			_ = f.Close()
+		}
+	}()
+	for i, cmd := range p.cmds[:len(p.cmds)-1] {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return p.abortStart(0, err)
+		}
+		pipes = append(pipes, r, w)
+		cmd.Stdout = w
+		p.cmds[i+1].Stdin = r
+	}
+
+	for i, cmd := range p.cmds {
+		if err := cmd.Start(); err != nil {
+			for j := i - 1; j >= 0; j-- {
+				p.cmds[j].osCancel()
+				_ = p.cmds[j].Wait()
+			}
+			return p.abortStart(i+1, err)
+		}
+	}
+
+	p.waitDone = make(chan struct{})
+	p.supervisorDone = make(chan struct{})
+	go p.supervise()
+	return nil
+}
+
+// abortStart releases the resources for the commands starting at
+// p.cmds[from], which are not going to be started, and returns err.
+func (p *Pipeline) abortStart(from int, err error) error {
+	for _, cmd := range p.cmds[from:] {
+		_ = cmd.abortStart(nil)
+	}
+	return err
+}
+
+func (p *Pipeline) supervise() {
+	defer close(p.supervisorDone)
+	if p.ctx != dcontext.HardContext(p.ctx) {
+		select {
+		case <-p.ctx.Done():
+			select {
+			case <-dcontext.HardContext(p.ctx).Done():
+				p.kill()
+				return
+			default:
+				for i := len(p.cmds) - 1; i >= 0; i-- {
+					if !p.cmds[i].DisableLogging {
+						dlog.Print(p.cmds[i].ctx, "sending SIGINT")
+					}
+					_ = sigint.SendInterrupt(p.cmds[i].Process)
+				}
+			}
+		case <-p.waitDone:
+			return
+		}
+	}
+	select {
+	case <-dcontext.HardContext(p.ctx).Done():
+		p.kill()
+	case <-p.waitDone:
+	}
+}
+
+// kill is the Pipeline equivalent of Cmd.hardKill.
+func (p *Pipeline) kill() {
+	var wg sync.WaitGroup
+	for i := len(p.cmds) - 1; i >= 0; i-- {
+		cmd := p.cmds[i]
+		if !cmd.sendKillSignal() {
+			cmd.sendSIGKILL()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !cmd.awaitExit() {
+				cmd.sendSIGKILL()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Wait waits for all of the commands in the pipeline to exit.  Like a
+// shell with "set -o pipefail", if any of the commands fail then the
+// error from the last (rightmost) failing command is returned.
+func (p *Pipeline) Wait() error {
+	var ret error
+	for _, cmd := range p.cmds {
+		if err := cmd.Wait(); err != nil {
+			ret = err
+		}
+	}
+	if p.waitDone != nil {
+		close(p.waitDone)
+		<-p.supervisorDone
+	}
+	return ret
+}
+
+// Run starts the pipeline and waits for it to complete.
+func (p *Pipeline) Run() error {
+	if err := p.Start(); err != nil {
+		return err
+	}
+	return p.Wait()
+}
+
+// Output runs the pipeline and returns the standard output of the
+// last command.
+func (p *Pipeline) Output() ([]byte, error) {
+	if len(p.cmds) == 0 {
+		return nil, errors.New("dexec.Pipeline.Output: no commands")
+	}
+	last := p.cmds[len(p.cmds)-1]
+	if last.Stdout != nil {
+		return nil, errors.New("exec: Stdout already set")
+	}
+	var stdout bytes.Buffer
+	last.Stdout = &stdout
+	err := p.Run()
+	return stdout.Bytes(), err
+}
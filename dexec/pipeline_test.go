@@ -0,0 +1,119 @@
+package dexec_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dexec"
+)
+
+// TestPipelineHelperProcess applies a line-by-line transformation (named by
+// the final argument) to stdin, writing the result to stdout.
+func TestPipelineHelperProcess(*testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	defer os.Exit(0)
+
+	mode := os.Args[len(os.Args)-1]
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch mode {
+		case "upper":
+			fmt.Println(strings.ToUpper(line))
+		case "double":
+			fmt.Println(line)
+			fmt.Println(line)
+		case "prefix":
+			fmt.Printf("> %s\n", line)
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown mode %q\n", mode)
+			os.Exit(2)
+		}
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	var log strings.Builder
+	ctx := newCapturingContext(t, &log)
+
+	var cmds []*dexec.Cmd
+	for _, mode := range []string{"upper", "double", "prefix"} {
+		cmd := dexec.CommandContext(ctx, os.Args[0], "-test.run=TestPipelineHelperProcess", "--", mode)
+		cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1"}
+		cmds = append(cmds, cmd)
+	}
+	cmds[0].StdinData = []byte("foo\nbar\n")
+
+	output, err := dexec.NewPipeline(ctx, cmds...).Output()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "> FOO\n> FOO\n> BAR\n> BAR\n", string(output))
+
+	for i, cmd := range cmds {
+		assert.Contains(t, log.String(),
+			fmt.Sprintf(`level=info msg="finished successfully: exit status 0" dexec.pid=%d dexec.pipeline_index=%d`,
+				cmd.ProcessState.Pid(), i))
+	}
+}
+
+func TestPipelineStdoutSet(t *testing.T) {
+	ctx := newCapturingContext(t, &strings.Builder{})
+	cmds := []*dexec.Cmd{
+		dexec.CommandContext(ctx, os.Args[0], "-test.run=TestPipelineHelperProcess", "--", "upper"),
+		dexec.CommandContext(ctx, os.Args[0], "-test.run=TestPipelineHelperProcess", "--", "upper"),
+	}
+	cmds[0].Stdout = &strings.Builder{}
+	assert.EqualError(t, dexec.NewPipeline(ctx, cmds...).Run(), "dexec.Pipeline.Start: cmds[0].Stdout already set")
+}
+
+func TestPipelineSoftCancel(t *testing.T) {
+	var log strings.Builder
+	ctx, cancel := context.WithCancel(dcontext.WithSoftness(newCapturingContext(t, &log)))
+	defer cancel()
+
+	stdin, stdinW, err := os.Pipe()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer stdin.Close()
+	defer stdinW.Close()
+
+	var cmds []*dexec.Cmd
+	for i := 0; i < 3; i++ {
+		cmd := dexec.CommandContext(ctx, os.Args[0], "-test.run=TestPipelineHelperProcess", "--", "upper")
+		cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1"}
+		cmds = append(cmds, cmd)
+	}
+	cmds[0].Stdin = stdin
+	cmds[2].Stdout = &strings.Builder{}
+
+	p := dexec.NewPipeline(ctx, cmds...)
+	if !assert.NoError(t, p.Start()) {
+		return
+	}
+	cancel()
+	assert.Error(t, p.Wait())
+
+	// Each command is signaled exactly once, last command first.
+	var signaled []string
+	for _, line := range strings.Split(log.String(), "\n") {
+		if strings.Contains(line, `msg="sending SIGINT"`) {
+			signaled = append(signaled, line[strings.Index(line, "dexec.pipeline_index="):])
+		}
+	}
+	assert.Equal(t, []string{
+		"dexec.pipeline_index=2",
+		"dexec.pipeline_index=1",
+		"dexec.pipeline_index=0",
+	}, signaled)
+}
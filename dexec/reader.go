@@ -2,8 +2,11 @@ package dexec
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"os"
+	"strings"
+	"sync"
 )
 
 func fixupReader(o io.Reader, log func(error, []byte)) io.Reader {
@@ -52,3 +55,107 @@ func (l *loggingReader) Read(p []byte) (n int, err error) {
 
 	return n, err
 }
+
+// contextReader wraps an io.Reader such that Read returns early (with
+// the Context's error) once the Context is done, even if the
+// underlying Read is blocked.  The blocked Read is left running in
+// the background, as there is no general way to interrupt it; but
+// whatever is calling contextReader.Read (os/exec's stdin-copying
+// goroutine) is free to finish.
+//
+// The underlying Reads are all done by a single pump goroutine, which
+// is started by the first Read and exits once the underlying Reader
+// returns an error, once the Context is done, or once close is
+// called.
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+	reqs      chan int
+	results   chan contextReadResult
+	err       error
+}
+
+type contextReadResult struct {
+	data []byte
+	err  error
+}
+
+func newContextReader(ctx context.Context, reader io.Reader) *contextReader {
+	return &contextReader{
+		ctx:     ctx,
+		reader:  reader,
+		stop:    make(chan struct{}),
+		reqs:    make(chan int),
+		results: make(chan contextReadResult, 1),
+	}
+}
+
+// canBlock returns whether a Read from r might block; reading from an
+// in-memory buffer never does, so there's no need to wrap it in a
+// contextReader.
+func canBlock(r io.Reader) bool {
+	switch r.(type) {
+	case *bytes.Reader, *bytes.Buffer, *strings.Reader:
+		return false
+	}
+	return true
+}
+
+// pump does the underlying Reads on behalf of Read.  It reads in to
+// its own buffer rather than the caller's, so that a Read that is
+// still running after Read has given up on it doesn't write to a
+// buffer that the caller has since reused.  The buffer is only reused
+// once Read has copied the previous result out of it.
+func (r *contextReader) pump() {
+	var buf []byte
+	for {
+		var size int
+		select {
+		case size = <-r.reqs:
+		case <-r.ctx.Done():
+			return
+		case <-r.stop:
+			return
+		}
+		if cap(buf) < size {
+			buf = make([]byte, size)
+		}
+		n, err := r.reader.Read(buf[:size])
+		r.results <- contextReadResult{buf[:n], err}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.startOnce.Do(func() { go r.pump() })
+	select {
+	case r.reqs <- len(p):
+	case <-r.ctx.Done():
+		return 0, r.ctx.Err()
+	}
+	select {
+	case res := <-r.results:
+		r.err = res.err
+		return copy(p, res.data), res.err
+	case <-r.ctx.Done():
+		return 0, r.ctx.Err()
+	}
+}
+
+// close tells the pump goroutine to exit if it is waiting for another
+// Read; it does not interrupt an underlying Read that is in progress.
+func (r *contextReader) close() {
+	r.stopOnce.Do(func() { close(r.stop) })
+}
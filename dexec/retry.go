@@ -0,0 +1,117 @@
+package dexec
+
+import (
+	"context"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/dlib/dtime/v2"
+)
+
+// Retry configures re-running a command that exits with a non-zero
+// status.
+//
+// Only Run, Output, and CombinedOutput retry; Start and Wait never
+// do.  A command that fails to start at all is not retried.  Because
+// an io.Reader can only be consumed once, a command that uses .Stdin
+// (rather than .StdinData) will see only whatever input remains on
+// retries.  Output written to .Stdout and .Stderr by failed attempts
+// is not retracted, except by Output and CombinedOutput, which
+// discard the output of failed attempts.
+type Retry struct {
+	// MaxAttempts is the maximum number of times to run the
+	// command.  A value <= 1 disables retrying.
+	MaxAttempts int
+	// Backoff returns how long to wait after the given attempt
+	// (starting at 1) fails before making the next attempt.  If
+	// nil, DefaultBackoff is used.  The wait is measured with the
+	// Context's Clock (see dtime/v2.WithClock).
+	Backoff func(attempt int) time.Duration
+}
+
+// DefaultBackoff is the Backoff used by Retry if none is set: 1s,
+// 2s, 4s, 8s, and so on, capped at 30s.
+func DefaultBackoff(attempt int) time.Duration {
+	const max = 30 * time.Second
+	if attempt > 5 {
+		return max
+	}
+	if d := time.Second << (attempt - 1); d < max {
+		return d
+	}
+	return max
+}
+
+func (r Retry) backoff(attempt int) time.Duration {
+	if r.Backoff == nil {
+		return DefaultBackoff(attempt)
+	}
+	return r.Backoff(attempt)
+}
+
+// run implements Run, Output, and CombinedOutput.  If the command
+// is retried, then reset is called (if non-nil) after the Cmd has
+// been reset but before it is started again.
+func (c *Cmd) run(reset func()) error {
+	for attempt := 1; ; attempt++ {
+		if err := c.Start(); err != nil {
+			return err
+		}
+		err := c.Wait()
+
+		var exitErr *ExitError
+		if err == nil || attempt >= c.Retry.MaxAttempts || !errors.As(err, &exitErr) || c.ctx.Err() != nil {
+			return err
+		}
+
+		delay := c.Retry.backoff(attempt)
+		if !c.DisableLogging {
+			dlog.Warnf(dlog.WithField(c.ctx, "dexec.pid", exitErr.Pid()),
+				"attempt %d/%d failed with exit code %d; retrying in %v",
+				attempt, c.Retry.MaxAttempts, exitErr.ExitCode(), delay)
+		}
+		timer := dtime.NewTimer(c.ctx, delay)
+		select {
+		case <-c.ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		c.resetForRetry()
+		if reset != nil {
+			reset()
+		}
+	}
+}
+
+// resetForRetry replaces the underlying os/exec.Cmd with a fresh one
+// that has the same configuration, so that the command may be
+// started again.
+func (c *Cmd) resetForRetry() {
+	osCtx, osCancel := context.WithCancel(dcontext.WithoutCancel(c.ctx))
+	old := c.Cmd
+	cmd := exec.CommandContext(osCtx, old.Path)
+	cmd.Path = old.Path
+	cmd.Args = old.Args
+	cmd.Env = old.Env
+	cmd.Dir = old.Dir
+	cmd.Stdin = c.origStdin
+	cmd.Stdout = c.origStdout
+	cmd.Stderr = c.origStderr
+	cmd.ExtraFiles = old.ExtraFiles
+	cmd.SysProcAttr = old.SysProcAttr
+
+	c.osCancel()
+	c.pidlock.Lock()
+	c.Cmd = cmd
+	c.osCancel = osCancel
+	c.waitDone = nil
+	c.waitOnce = sync.Once{}
+	c.supervisorDone = nil
+}
@@ -0,0 +1,90 @@
+package dexec_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dexec"
+	"github.com/datawire/dlib/dlog"
+)
+
+// waitTimeout calls cmd.Wait, but gives up after a while, so that a
+// regression doesn't hang the test suite.
+func waitTimeout(t *testing.T, cmd *dexec.Cmd) error {
+	t.Helper()
+	errCh := make(chan error, 1)
+	go func() { errCh <- cmd.Wait() }()
+	select {
+	case err := <-errCh:
+		return err
+	case <-time.After(10 * time.Second):
+		t.Fatal("Wait did not return; the stdin goroutine appears to be stuck")
+		return nil
+	}
+}
+
+// notifyReader closes reading when Read is first called.
+type notifyReader struct {
+	io.Reader
+	reading chan struct{}
+	once    sync.Once
+}
+
+func (r *notifyReader) Read(p []byte) (int, error) {
+	r.once.Do(func() { close(r.reading) })
+	return r.Reader.Read(p)
+}
+
+func TestStdinCtx(t *testing.T) {
+	t.Run("StdinCtx", func(t *testing.T) {
+		pr, pw := io.Pipe()
+		defer pw.Close()
+
+		stdinCtx, stdinCancel := context.WithCancel(context.Background())
+		defer stdinCancel()
+
+		var stdout strings.Builder
+		cmd := dexec.CommandContext(dlog.NewTestContext(t, false), os.Args[0], "-test.run=TestHelperProcess", "--", "cat")
+		cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1"}
+		blocking := &notifyReader{Reader: pr, reading: make(chan struct{})}
+		cmd.Stdin = io.MultiReader(strings.NewReader("hello\n"), blocking)
+		cmd.StdinCtx = stdinCtx
+		cmd.Stdout = &stdout
+		if !assert.NoError(t, cmd.Start()) {
+			return
+		}
+
+		<-blocking.reading
+		stdinCancel()
+		err := waitTimeout(t, cmd)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, "hello\n", stdout.String())
+		assert.True(t, cmd.ProcessState.Success())
+	})
+	t.Run("command-context", func(t *testing.T) {
+		pr, pw := io.Pipe()
+		defer pw.Close()
+
+		ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+		defer cancel()
+
+		cmd := dexec.CommandContext(ctx, os.Args[0], "-test.run=TestHelperProcess", "--", "cat")
+		cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1"}
+		cmd.Stdin = pr
+		if !assert.NoError(t, cmd.Start()) {
+			return
+		}
+
+		cancel()
+		err := waitTimeout(t, cmd)
+		assert.Error(t, err)
+		assert.False(t, cmd.ProcessState.Success())
+	})
+}
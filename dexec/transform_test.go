@@ -0,0 +1,115 @@
+package dexec_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dexec"
+	"github.com/datawire/dlib/dlog"
+)
+
+const transformTestData = "hello, compressed world\n"
+
+func TestStreamTransformHelperProcess(*testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	defer os.Exit(0)
+
+	zw := gzip.NewWriter(os.Stdout)
+	_, _ = io.WriteString(zw, transformTestData)
+	_ = zw.Close()
+	_, _ = io.WriteString(os.Stderr, "quiet\n")
+}
+
+func gunzipTransform(r io.Reader) io.Reader {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return errReader{err}
+	}
+	return zr
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
+func upperTransform(r io.Reader) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			if _, err := pw.Write(append(bytes.ToUpper(scanner.Bytes()), '\n')); err != nil {
+				return
+			}
+		}
+		_ = pw.CloseWithError(scanner.Err())
+	}()
+	return pr
+}
+
+func TestStreamTransform(t *testing.T) {
+	var logOutput bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&logOutput)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	ctx := dlog.WithLogger(context.Background(), dlog.WrapLogrus(logger))
+
+	var stdout, stderr bytes.Buffer
+	cmd := dexec.CommandContext(ctx, os.Args[0], "-test.run=TestStreamTransformHelperProcess")
+	cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.StdoutTransform = gunzipTransform
+	cmd.StderrTransform = upperTransform
+	cmd.IOLogFormat = dexec.IOLogFormatBase64
+	if !assert.NoError(t, cmd.Run()) {
+		return
+	}
+	assert.Equal(t, transformTestData, stdout.String())
+	assert.Equal(t, "QUIET\n", stderr.String())
+
+	// The raw (compressed) bytes are what get logged.
+	var logged []byte
+	scanner := bufio.NewScanner(&logOutput)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		if !assert.NoError(t, json.Unmarshal(scanner.Bytes(), &entry)) {
+			return
+		}
+		if data, ok := entry["dexec.data"].(string); ok && entry["dexec.stream"] == "stdout" {
+			b, err := base64.StdEncoding.DecodeString(data)
+			assert.NoError(t, err)
+			logged = append(logged, b...)
+		}
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(logged))
+	if !assert.NoError(t, err) {
+		return
+	}
+	decompressed, err := io.ReadAll(zr)
+	assert.NoError(t, err)
+	assert.Equal(t, transformTestData, string(decompressed))
+}
+
+func TestStreamTransformError(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	cmd := dexec.CommandContext(ctx, os.Args[0], "-test.run=TestStreamTransformHelperProcess")
+	cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+	cmd.Stdout = io.Discard
+	cmd.StdoutTransform = func(io.Reader) io.Reader { return errReader{io.ErrUnexpectedEOF} }
+	// Just like any other failing .Stdout, the command sees its
+	// writes fail (and so, here, exits with SIGPIPE); so we don't
+	// know exactly which error Run will report.
+	assert.Error(t, cmd.Run())
+}
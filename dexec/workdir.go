@@ -0,0 +1,33 @@
+package dexec
+
+import (
+	"context"
+	"path/filepath"
+)
+
+type workDirContextKey struct{}
+
+// WithWorkDir returns a copy of ctx that specifies a default working
+// directory for commands created with it.  See Cmd.WorkDir.
+func WithWorkDir(ctx context.Context, path string) context.Context {
+	return context.WithValue(ctx, workDirContextKey{}, path)
+}
+
+func getWorkDir(ctx context.Context) string {
+	dir, _ := ctx.Value(workDirContextKey{}).(string)
+	return dir
+}
+
+// resolveWorkDir returns the directory that the command should be run
+// in, based on .WorkDir and the Context's working directory.
+func (c *Cmd) resolveWorkDir() string {
+	ctxDir := getWorkDir(c.ctx)
+	switch {
+	case c.WorkDir == "":
+		return ctxDir
+	case filepath.IsAbs(c.WorkDir) || ctxDir == "":
+		return c.WorkDir
+	default:
+		return filepath.Join(ctxDir, c.WorkDir)
+	}
+}
@@ -0,0 +1,77 @@
+package dexec_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dexec"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestWorkDirHelperProcess(*testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	defer os.Exit(0)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(wd)
+}
+
+func TestWorkDir(t *testing.T) {
+	cwd, err := os.Getwd()
+	if !assert.NoError(t, err) {
+		return
+	}
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, os.Mkdir(filepath.Join(base, "sub"), 0755)) {
+		return
+	}
+	other, err := filepath.EvalSymlinks(t.TempDir())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	testcases := map[string]struct {
+		CtxDir   string
+		WorkDir  string
+		Dir      string
+		Expected string
+	}{
+		"neither":      {Expected: cwd},
+		"ctx":          {CtxDir: base, Expected: base},
+		"workdir":      {WorkDir: other, Expected: other},
+		"relative":     {CtxDir: base, WorkDir: "sub", Expected: filepath.Join(base, "sub")},
+		"absolute":     {CtxDir: base, WorkDir: other, Expected: other},
+		"dir-override": {CtxDir: base, WorkDir: "sub", Dir: other, Expected: other},
+	}
+	for tcName, tcData := range testcases {
+		tcData := tcData
+		t.Run(tcName, func(t *testing.T) {
+			ctx := dlog.NewTestContext(t, true)
+			if tcData.CtxDir != "" {
+				ctx = dexec.WithWorkDir(ctx, tcData.CtxDir)
+			}
+			cmd := dexec.CommandContext(ctx, os.Args[0], "-test.run=TestWorkDirHelperProcess")
+			cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1"}
+			cmd.WorkDir = tcData.WorkDir
+			cmd.Dir = tcData.Dir
+			output, err := cmd.Output()
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tcData.Expected, string(output))
+		})
+	}
+}
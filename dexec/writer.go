@@ -6,7 +6,7 @@ import (
 	"os"
 )
 
-func fixupWriter(o io.Writer, log func(error, []byte)) io.Writer {
+func fixupWriter(o io.Writer, log func(error, []byte), transform func(string) string) io.Writer {
 	if o == nil {
 		o = nilWriter{}
 	}
@@ -14,8 +14,9 @@ func fixupWriter(o io.Writer, log func(error, []byte)) io.Writer {
 		return o
 	}
 	o = &loggingWriter{
-		log:    log,
-		writer: o,
+		log:       log,
+		writer:    o,
+		transform: transform,
 	}
 	return o
 }
@@ -27,9 +28,19 @@ func (nilWriter) Write(p []byte) (int, error) { return len(p), nil }
 type loggingWriter struct {
 	log    func(error, []byte)
 	writer io.Writer
+
+	// If transform is non-nil, then output is buffered in to
+	// complete lines, and each line is passed through transform
+	// before being logged and written.
+	transform func(string) string
+	partial   []byte
 }
 
 func (l *loggingWriter) Write(p []byte) (n int, err error) {
+	if l.transform != nil {
+		return l.writeTransformed(p)
+	}
+
 	toLog := p
 	for len(toLog) > 0 {
 		nl := bytes.IndexByte(toLog, '\n')
@@ -50,3 +61,84 @@ func (l *loggingWriter) Write(p []byte) (n int, err error) {
 	}
 	return n, err
 }
+
+func (l *loggingWriter) writeTransformed(p []byte) (int, error) {
+	l.partial = append(l.partial, p...)
+	for {
+		nl := bytes.IndexByte(l.partial, '\n')
+		if nl < 0 {
+			break
+		}
+		line := []byte(l.transform(string(l.partial[:nl])) + "\n")
+		l.partial = l.partial[nl+1:]
+		if err := l.writeLine(line); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// flush writes out any buffered incomplete line, untransformed but
+// with a newline appended.  It is a no-op if transform is nil.
+func (l *loggingWriter) flush() error {
+	if len(l.partial) == 0 {
+		return nil
+	}
+	line := append(l.partial, '\n')
+	l.partial = nil
+	return l.writeLine(line)
+}
+
+func (l *loggingWriter) writeLine(line []byte) error {
+	l.log(nil, line)
+	if _, err := l.writer.Write(line); err != nil {
+		l.log(err, nil)
+		return err
+	}
+	return nil
+}
+
+// transformWriter feeds everything written to it through a
+// StdoutTransform or StderrTransform, writing the result to an
+// underlying writer.
+type transformWriter struct {
+	pw   *io.PipeWriter
+	done chan struct{}
+	err  error
+}
+
+func newTransformWriter(o io.Writer, transform func(io.Reader) io.Reader) *transformWriter {
+	if o == nil {
+		o = nilWriter{}
+	}
+	pr, pw := io.Pipe()
+	w := &transformWriter{
+		pw:   pw,
+		done: make(chan struct{}),
+	}
+	go func() {
+		defer close(w.done)
+		if _, w.err = io.Copy(o, transform(pr)); w.err != nil {
+			// Make further writes fail, rather than block.
+			_ = pr.CloseWithError(w.err)
+			return
+		}
+		// The transform finished without consuming all of its
+		// input; discard the rest so that the writes don't
+		// block.
+		_, _ = io.Copy(io.Discard, pr)
+	}()
+	return w
+}
+
+func (w *transformWriter) Write(p []byte) (int, error) {
+	return w.pw.Write(p)
+}
+
+// close signals the end of the stream to the transform, and waits for
+// the transformed output to be written.
+func (w *transformWriter) close() error {
+	_ = w.pw.Close()
+	<-w.done
+	return w.err
+}
@@ -0,0 +1,131 @@
+package dgroup
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/datawire/dlib/dcontext"
+)
+
+// workerCancel holds the functions to cancel an individual worker.
+type workerCancel struct {
+	softCancel  context.CancelFunc
+	hardCancel  context.CancelFunc
+	mergeCancel context.CancelFunc
+	killTimer   *time.Timer
+
+	// canceled is whether Cancel or Kill has been called for the
+	// worker; it is protected by Group.cancelsMu.
+	canceled bool
+}
+
+// withWorkerCancelLocked returns a copy of the worker Context that
+// may be individually canceled with Cancel and Kill, and registers it
+// under the worker's full goroutine name.  It must be called with
+// cancelsMu held, and the lock must not be released until the worker
+// has been passed to g.workers.Go; that way two concurrent workers
+// with the same name can't both get past the duplicate check.
+func (g *Group) withWorkerCancelLocked(ctx context.Context, name string) (context.Context, *workerCancel) {
+	if _, exists := g.workers.List()[name]; exists {
+		// derrgroup will refuse to launch it; don't clobber the
+		// existing worker's registration.
+		return ctx, nil
+	}
+
+	kill, hardCancel := context.WithCancel(context.Background())
+	stop, softCancel := context.WithCancel(dcontext.WithSoftness(kill))
+	ctx, mergeCancel := dcontext.Merge(ctx, stop)
+	wc := &workerCancel{
+		softCancel:  softCancel,
+		hardCancel:  hardCancel,
+		mergeCancel: mergeCancel,
+	}
+
+	if g.cancels == nil {
+		g.cancels = make(map[string]*workerCancel)
+	}
+	g.cancels[name] = wc
+	return ctx, wc
+}
+
+// finishWorkerCancel unregisters a worker that has exited, releases
+// the resources associated with its Context, and returns whether it
+// had been individually canceled.
+func (g *Group) finishWorkerCancel(name string, wc *workerCancel) (canceled bool) {
+	if wc == nil {
+		return false
+	}
+	g.cancelsMu.Lock()
+	defer g.cancelsMu.Unlock()
+	if g.cancels[name] == wc {
+		delete(g.cancels, name)
+	}
+	if wc.killTimer != nil {
+		wc.killTimer.Stop()
+	}
+	wc.mergeCancel()
+	wc.softCancel()
+	wc.hardCancel()
+	return wc.canceled
+}
+
+func (g *Group) lookupWorkerCancel(name string) (*workerCancel, error) {
+	fullName := getGoroutineName(WithGoroutineName(g.baseCtx, "/"+name))
+	wc, ok := g.cancels[fullName]
+	if !ok {
+		if _, exists := g.workers.List()[fullName]; exists {
+			return nil, errors.Errorf("worker %q has already exited", name)
+		}
+		return nil, errors.Errorf("no worker named %q", name)
+	}
+	return wc, nil
+}
+
+// Cancel soft-cancels the Context of the worker that was launched as
+// Go(name, …), without shutting down the rest of the group.  If the
+// Group does not have a hard/soft distinction (see
+// GroupConfig.EnableWithSoftness), then this cancels the worker's
+// Context entirely.  It returns an error if there is no such worker,
+// or if it has already exited.
+//
+// If the worker then returns an error that is (or wraps)
+// context.Canceled, it is treated as having exited without error, so
+// that it does not trigger a shutdown of the group (though if
+// ShutdownOnNonError is set, then the worker exiting will trigger a
+// shutdown anyway).
+func (g *Group) Cancel(name string) error {
+	g.cancelsMu.Lock()
+	defer g.cancelsMu.Unlock()
+	wc, err := g.lookupWorkerCancel(name)
+	if err != nil {
+		return err
+	}
+	wc.canceled = true
+	wc.softCancel()
+	return nil
+}
+
+// Kill is like Cancel, but if the worker has not exited within
+// timeout of being soft-canceled, then its hard Context is canceled
+// too.  Kill does not wait for the timeout to elapse; it returns
+// immediately.  A zero timeout hard-cancels the worker right away.
+func (g *Group) Kill(name string, timeout time.Duration) error {
+	g.cancelsMu.Lock()
+	defer g.cancelsMu.Unlock()
+	wc, err := g.lookupWorkerCancel(name)
+	if err != nil {
+		return err
+	}
+	wc.canceled = true
+	wc.softCancel()
+	if timeout <= 0 {
+		wc.hardCancel()
+		return nil
+	}
+	if wc.killTimer == nil {
+		wc.killTimer = time.AfterFunc(timeout, wc.hardCancel)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package dgroup_test
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/derrgroup"
+	"github.com/datawire/dlib/dgroup"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestCancel(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	group := dgroup.NewGroup(ctx, dgroup.GroupConfig{
+		EnableWithSoftness: true,
+	})
+
+	victimExited := make(chan struct{})
+	group.Go("victim", func(ctx context.Context) error {
+		defer close(victimExited)
+		<-ctx.Done()
+		assert.NoError(t, dcontext.HardContext(ctx).Err())
+		return ctx.Err()
+	})
+	bystanderCtx := make(chan context.Context, 1)
+	group.Go("bystander", func(ctx context.Context) error {
+		bystanderCtx <- ctx
+		<-ctx.Done()
+		return nil
+	})
+
+	assert.NoError(t, group.Cancel("victim"))
+	<-victimExited
+	for group.List()["/victim"] == derrgroup.GoroutineRunning {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, derrgroup.GoroutineExited, group.List()["/victim"])
+
+	// The rest of the group keeps running.
+	assert.NoError(t, (<-bystanderCtx).Err())
+	assert.Equal(t, derrgroup.GoroutineRunning, group.List()["/bystander"])
+
+	assert.EqualError(t, group.Cancel("victim"), `worker "victim" has already exited`)
+	assert.EqualError(t, group.Cancel("nonexistent"), `no worker named "nonexistent"`)
+	assert.EqualError(t, group.Kill("nonexistent", time.Second), `no worker named "nonexistent"`)
+
+	assert.NoError(t, group.Cancel("bystander"))
+	assert.NoError(t, group.Wait())
+}
+
+func TestKill(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	group := dgroup.NewGroup(ctx, dgroup.GroupConfig{
+		EnableWithSoftness: true,
+	})
+
+	softCanceled := make(chan time.Time, 1)
+	hardCanceled := make(chan time.Time, 1)
+	group.Go("stubborn", func(ctx context.Context) error {
+		<-ctx.Done()
+		softCanceled <- time.Now()
+		// Ignore the soft cancellation.
+		<-dcontext.HardContext(ctx).Done()
+		hardCanceled <- time.Now()
+		return dcontext.HardContext(ctx).Err()
+	})
+	bystanderCtx := make(chan context.Context, 1)
+	group.Go("bystander", func(ctx context.Context) error {
+		bystanderCtx <- ctx
+		<-ctx.Done()
+		return nil
+	})
+
+	start := time.Now()
+	assert.NoError(t, group.Kill("stubborn", 100*time.Millisecond))
+	assert.Less(t, time.Since(start), 50*time.Millisecond, "Kill should not block")
+	soft := <-softCanceled
+	hard := <-hardCanceled
+	assert.GreaterOrEqual(t, hard.Sub(soft), 50*time.Millisecond)
+
+	// The rest of the group keeps running.
+	bCtx := <-bystanderCtx
+	assert.NoError(t, bCtx.Err())
+	assert.NoError(t, dcontext.HardContext(bCtx).Err())
+
+	assert.NoError(t, group.Cancel("bystander"))
+	assert.NoError(t, group.Wait())
+	assert.Equal(t, map[string]derrgroup.GoroutineState{
+		"/stubborn":  derrgroup.GoroutineExited,
+		"/bystander": derrgroup.GoroutineExited,
+	}, group.List())
+}
+
+func TestCancelShutdownOnNonError(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	group := dgroup.NewGroup(ctx, dgroup.GroupConfig{
+		ShutdownOnNonError: true,
+	})
+	group.Go("victim", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	group.Go("bystander", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+	assert.NoError(t, group.Cancel("victim"))
+	// The victim exiting shuts down the bystander.
+	assert.NoError(t, group.Wait())
+}
+
+func TestWorkerContextReleased(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer cancel()
+	group := dgroup.NewGroup(ctx, dgroup.GroupConfig{
+		EnableWithSoftness: true,
+	})
+	// Launch a long-lived worker first, so that the Group doesn't
+	// shut down when the short-lived workers exit.
+	group.Go("long-lived", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+	for group.RunningLen() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	before := runtime.NumGoroutine()
+
+	const n = 1000
+	for i := 0; i < n; i++ {
+		group.Go(fmt.Sprintf("short-lived-%d", i), func(ctx context.Context) error {
+			return nil
+		})
+	}
+	for group.RunningLen() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The Contexts' goroutines exit asynchronously once they're
+	// released; give them a moment.
+	deadline := time.Now().Add(10 * time.Second)
+	for runtime.NumGoroutine() > before+n/10 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before+n/10)
+
+	cancel()
+	assert.NoError(t, group.Wait())
+}
+
+func TestCancelConcurrentDuplicate(t *testing.T) {
+	for trial := 0; trial < 100; trial++ {
+		const n = 8
+		// Hold each Go call up until all of them have been made,
+		// so that they race with each other.
+		var arrived sync.WaitGroup
+		arrived.Add(n)
+		ctx := dlog.NewTestContext(t, false)
+		group := dgroup.NewGroup(ctx, dgroup.GroupConfig{
+			EnableWithSoftness: true,
+			DisableLogging:     true,
+			WorkerContext: func(ctx context.Context, _ string) context.Context {
+				arrived.Done()
+				arrived.Wait()
+				return ctx
+			},
+		})
+
+		// Only one of the workers runs; the duplicates shut the
+		// group down (softly).
+		killed := make(chan bool, n)
+		var launched sync.WaitGroup
+		for i := 0; i < n; i++ {
+			launched.Add(1)
+			go func() {
+				defer launched.Done()
+				group.Go("dup", func(ctx context.Context) error {
+					select {
+					case <-dcontext.HardContext(ctx).Done():
+						killed <- true
+					case <-time.After(5 * time.Second):
+						killed <- false
+					}
+					return nil
+				})
+			}()
+		}
+		launched.Wait()
+
+		// Kill must reach the worker that is actually running.
+		assert.NoError(t, group.Kill("dup", 0))
+		if !assert.True(t, <-killed, "trial %d: Kill did not reach the running worker", trial) {
+			return
+		}
+		assert.Error(t, group.Wait())
+	}
+}
@@ -2,6 +2,7 @@ package dgroup_test
 
 import (
 	"context"
+	"errors"
 	"os"
 	"time"
 
@@ -83,6 +84,8 @@ func Example_signalHandling1() {
 		// the goroutines' "final" statuses and return.
 	}()
 
+	defer dgroup.SetDurationForTesting(1 * time.Second)()
+
 	ctx := baseContext()
 	group := dgroup.NewGroup(ctx, dgroup.GroupConfig{
 		EnableSignalHandling: true,
@@ -108,8 +111,8 @@ func Example_signalHandling1() {
 	// level=error msg="goroutine \":signal_handler:0\" exited with error: received signal interrupt (triggering graceful shutdown)" THREAD=":signal_handler:0"
 	// level=info msg="shutting down (gracefully)..." THREAD=":shutdown_logger"
 	// level=info msg="  final goroutine statuses:" THREAD=":shutdown_status"
-	// level=info msg="    /worker          : exited" THREAD=":shutdown_status"
-	// level=info msg="    :signal_handler:0: exited with error" THREAD=":shutdown_status"
+	// level=info msg="    /worker          : exited after 1s" THREAD=":shutdown_status"
+	// level=info msg="    :signal_handler:0: exited with error after 1s" THREAD=":shutdown_status"
 	// level=error msg="exiting with error: received signal interrupt (triggering graceful shutdown)"
 }
 
@@ -156,6 +159,8 @@ func Example_signalHandling2() {
 		// return.
 	}()
 
+	defer dgroup.SetDurationForTesting(1 * time.Second)()
+
 	ctx := baseContext()
 	group := dgroup.NewGroup(ctx, dgroup.GroupConfig{
 		EnableSignalHandling: true,
@@ -189,12 +194,12 @@ func Example_signalHandling2() {
 	// level=info msg="shutting down (gracefully)..." THREAD=":shutdown_logger"
 	// level=error msg="received signal interrupt (graceful shutdown already triggered; triggering not-so-graceful shutdown)" THREAD=":signal_handler:1"
 	// level=error msg="  goroutine statuses:" THREAD=":signal_handler:1"
-	// level=error msg="    /worker          : running" THREAD=":signal_handler:1"
-	// level=error msg="    :signal_handler:0: exited with error" THREAD=":signal_handler:1"
+	// level=error msg="    /worker          : running for 1s" THREAD=":signal_handler:1"
+	// level=error msg="    :signal_handler:0: exited with error after 1s" THREAD=":signal_handler:1"
 	// level=info msg="shutting down (not-so-gracefully)..." THREAD=":shutdown_logger"
 	// level=info msg="  final goroutine statuses:" THREAD=":shutdown_status"
-	// level=info msg="    /worker          : exited" THREAD=":shutdown_status"
-	// level=info msg="    :signal_handler:0: exited with error" THREAD=":shutdown_status"
+	// level=info msg="    /worker          : exited after 1s" THREAD=":shutdown_status"
+	// level=info msg="    :signal_handler:0: exited with error after 1s" THREAD=":shutdown_status"
 	// level=error msg="exiting with error: received signal interrupt (triggering graceful shutdown)"
 }
 
@@ -361,6 +366,7 @@ func Example_signalHandling3() {
 		close(exFinished)
 	}()
 	dgroup.SetStacktraceForTesting(exampleStackTrace)
+	defer dgroup.SetDurationForTesting(1 * time.Second)()
 
 	ctx := baseContext()
 	group := dgroup.NewGroup(ctx, dgroup.GroupConfig{
@@ -397,13 +403,13 @@ func Example_signalHandling3() {
 	// level=info msg="shutting down (gracefully)..." THREAD=":shutdown_logger"
 	// level=error msg="received signal interrupt (graceful shutdown already triggered; triggering not-so-graceful shutdown)" THREAD=":signal_handler:1"
 	// level=error msg="  goroutine statuses:" THREAD=":signal_handler:1"
-	// level=error msg="    /worker          : running" THREAD=":signal_handler:1"
-	// level=error msg="    :signal_handler:0: exited with error" THREAD=":signal_handler:1"
+	// level=error msg="    /worker          : running for 1s" THREAD=":signal_handler:1"
+	// level=error msg="    :signal_handler:0: exited with error after 1s" THREAD=":signal_handler:1"
 	// level=info msg="shutting down (not-so-gracefully)..." THREAD=":shutdown_logger"
 	// level=error msg="received signal interrupt (not-so-graceful shutdown already triggered)" THREAD=":signal_handler:2"
 	// level=error msg="  goroutine statuses:" THREAD=":signal_handler:2"
-	// level=error msg="    /worker          : running" THREAD=":signal_handler:2"
-	// level=error msg="    :signal_handler:0: exited with error" THREAD=":signal_handler:2"
+	// level=error msg="    /worker          : running for 1s" THREAD=":signal_handler:2"
+	// level=error msg="    :signal_handler:0: exited with error after 1s" THREAD=":signal_handler:2"
 	// level=error msg="  goroutine stack traces:" THREAD=":signal_handler:2"
 	// level=error msg="    goroutine 1405 [running]:" THREAD=":signal_handler:2"
 	// level=error msg="    runtime/pprof.writeGoroutineStacks(0x6575e0, 0xc0003803c0, 0x30, 0x7f56be200788)" THREAD=":signal_handler:2"
@@ -503,8 +509,8 @@ func Example_signalHandling3() {
 	// level=error msg="    created by github.com/datawire/dlib/dgroup.(*Group).Wait" THREAD=":signal_handler:2"
 	// level=error msg="    \t/home/lukeshu/src/github.com/datawire/apro/ambassador/pkg/dgroup/group.go:412 +0x85" THREAD=":signal_handler:2"
 	// level=info msg="  final goroutine statuses:" THREAD=":shutdown_status"
-	// level=info msg="    /worker          : running" THREAD=":shutdown_status"
-	// level=info msg="    :signal_handler:0: exited with error" THREAD=":shutdown_status"
+	// level=info msg="    /worker          : running for 1s" THREAD=":shutdown_status"
+	// level=info msg="    :signal_handler:0: exited with error after 1s" THREAD=":shutdown_status"
 	// level=error msg="  final goroutine stack traces:" THREAD=":shutdown_status"
 	// level=error msg="    goroutine 1405 [running]:" THREAD=":shutdown_status"
 	// level=error msg="    runtime/pprof.writeGoroutineStacks(0x6575e0, 0xc0003803c0, 0x30, 0x7f56be200788)" THREAD=":shutdown_status"
@@ -645,3 +651,50 @@ func Example_timeout() {
 	// level=info msg="oops, I've been a bad boy, I really do need to shut down now" THREAD=/b
 	// level=info msg="shutting down (not-so-gracefully)..." THREAD=":shutdown_logger"
 }
+
+func Example_disableShutdownLogging() {
+	ctx := baseContext()
+
+	group := dgroup.NewGroup(ctx, dgroup.GroupConfig{
+		// Log workers exiting, but not the "shutting down"
+		// messages.
+		DisableShutdownLogging: true,
+	})
+
+	group.Go("a", func(ctx context.Context) error {
+		return errors.New("oops")
+	})
+
+	if err := group.Wait(); err != nil {
+		dlog.Errorln(ctx, "exiting with error:", err)
+	}
+
+	// Output:
+	// level=error msg="goroutine \"/a\" exited with error: oops" THREAD=/a
+	// level=error msg="exiting with error: oops"
+}
+
+func Example_disableWorkerExitLogging() {
+	ctx := baseContext()
+	defer dgroup.SetDurationForTesting(1 * time.Second)()
+
+	group := dgroup.NewGroup(ctx, dgroup.GroupConfig{
+		// Keep the "shutting down" messages, but don't log each
+		// worker exiting.
+		DisableWorkerExitLogging: true,
+	})
+
+	group.Go("a", func(ctx context.Context) error {
+		return errors.New("oops")
+	})
+
+	if err := group.Wait(); err != nil {
+		dlog.Errorln(ctx, "exiting with error:", err)
+	}
+
+	// Output:
+	// level=info msg="shutting down..." THREAD=":shutdown_logger"
+	// level=info msg="  final goroutine statuses:" THREAD=":shutdown_status"
+	// level=info msg="    /a: exited with error after 1s" THREAD=":shutdown_status"
+	// level=error msg="exiting with error: oops"
+}
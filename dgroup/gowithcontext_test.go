@@ -0,0 +1,73 @@
+package dgroup_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dgroup"
+	"github.com/datawire/dlib/dlog"
+)
+
+type spanKey struct{}
+
+func TestGoWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, true))
+	defer cancel()
+	group := dgroup.NewGroup(ctx, dgroup.GroupConfig{
+		EnableWithSoftness: true,
+		WorkerContext: func(ctx context.Context, name string) context.Context {
+			return dlog.WithField(ctx, "worker", name)
+		},
+	})
+
+	type childInfo struct {
+		span      interface{}
+		name      []string
+		worker    interface{}
+		softness  bool
+		sameGroup bool
+	}
+	infoCh := make(chan childInfo, 1)
+	group.Go("parent", func(ctx context.Context) error {
+		ctx = context.WithValue(ctx, spanKey{}, "span-1")
+		dgroup.ParentGroup(ctx).GoWithContext("child", ctx, func(ctx context.Context) error {
+			worker, _ := dlog.FieldValue(ctx, "worker")
+			infoCh <- childInfo{
+				span:      ctx.Value(spanKey{}),
+				name:      dgroup.GetGoroutineNameComponents(ctx),
+				worker:    worker,
+				softness:  dcontext.IsSoftContext(ctx),
+				sameGroup: dgroup.ParentGroup(ctx) == group,
+			}
+			<-ctx.Done()
+			return nil
+		})
+		return nil
+	})
+
+	info := <-infoCh
+	assert.Equal(t, "span-1", info.span)
+	assert.Equal(t, []string{"child"}, info.name)
+	assert.Equal(t, "child", info.worker)
+	assert.True(t, info.softness)
+	assert.True(t, info.sameGroup)
+
+	// The child is subject to the Group's shutdown.
+	cancel()
+	assert.NoError(t, group.Wait())
+}
+
+func TestGoWithContextUnrelated(t *testing.T) {
+	group := dgroup.NewGroup(dlog.NewTestContext(t, false), dgroup.GroupConfig{})
+	ran := false
+	group.GoWithContext("stray", context.Background(), func(ctx context.Context) error {
+		ran = true
+		return nil
+	})
+	err := group.Wait()
+	assert.False(t, ran)
+	assert.EqualError(t, err, `dgroup.Group.GoWithContext: the Context for worker "stray" is not derived from the Group's Context`)
+}
@@ -30,6 +30,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -64,8 +65,9 @@ import (
 // also suitable to be nested somewhere deep inside of an application
 // (but signal handling should probably be disabled for that use).
 type Group struct {
-	cfg     GroupConfig
-	baseCtx context.Context
+	cfg       GroupConfig
+	baseCtx   context.Context
+	createdAt time.Time
 
 	shutdownTimedOut chan struct{}
 	waitFinished     chan struct{}
@@ -73,13 +75,23 @@ type Group struct {
 
 	workers     *derrgroup.Group
 	supervisors sync.WaitGroup
+
+	cancelsMu sync.Mutex
+	cancels   map[string]*workerCancel
+
+	waitOnce     sync.Once
+	waitErr      error
+	waitTimedOut bool
+
+	waitChanOnce sync.Once
+	waitChan     chan error
 }
 
 func logGoroutineStatuses(
 	ctx context.Context,
 	heading string,
 	printf func(ctx context.Context, format string, args ...interface{}),
-	list map[string]derrgroup.GoroutineState,
+	list map[string]derrgroup.GoroutineInfo,
 ) {
 	printf(ctx, "  %s:", heading)
 	names := make([]string, 0, len(list))
@@ -92,11 +104,23 @@ func logGoroutineStatuses(
 	}
 	sort.Strings(names)
 	for _, name := range names {
-		printf(ctx, "    %-*s: %s", nameWidth, name, list[name])
+		info := list[name]
+		duration := info.Duration
+		if d := time.Duration(atomic.LoadInt64(&durationForTesting)); d != 0 {
+			duration = d
+		}
+		preposition := "after"
+		if info.State == derrgroup.GoroutineRunning {
+			preposition = "for"
+		}
+		printf(ctx, "    %-*s: %s %s %v", nameWidth, name, info.State, preposition, duration.Round(time.Millisecond))
 	}
 }
 
-var stacktraceForTesting string
+var (
+	stacktraceForTesting string
+	durationForTesting   int64 // time.Duration; accessed atomically
+)
 
 func logGoroutineTraces(
 	ctx context.Context,
@@ -140,6 +164,13 @@ type GroupConfig struct {
 	EnableWithSoftness   bool
 	EnableSignalHandling bool // implies EnableWithSoftness
 
+	// EnableStatusDumpOnSIGUSR1 says whether receiving SIGUSR1
+	// should log the goroutine statuses and stack traces (at
+	// loglevel info), without triggering a shutdown.  This is
+	// logged even if DisableLogging is set.  It is silently
+	// ignored on Windows, which does not have SIGUSR1.
+	EnableStatusDumpOnSIGUSR1 bool
+
 	// Normally a worker exiting with an error triggers other
 	// goroutines to shutdown.  Setting ShutdownOnNonError causes
 	// a shutdown to be triggered whenever a goroutine exits, even
@@ -161,14 +192,76 @@ type GroupConfig struct {
 	HardShutdownTimeout time.Duration
 
 	DisablePanicRecovery bool
-	DisableLogging       bool
+
+	// DisableShutdownLogging suppresses the group's messages about
+	// shutdown: "shutting down...", repeated shutdown signals, and
+	// the final goroutine statuses if Wait returns an error.
+	//
+	// DisableWorkerExitLogging suppresses the per-worker messages
+	// about a worker exiting (or being restarted).
+	//
+	// DisableLogging is a shorthand for setting both of them.
+	DisableShutdownLogging   bool
+	DisableWorkerExitLogging bool
+	DisableLogging           bool
+
+	// WorkerPanicHandler, if set, is called when panic recovery
+	// catches a panic in a worker goroutine, and the error that
+	// it returns is used as the worker's exit error (returning
+	// nil treats the worker as having exited cleanly).  The
+	// Context passed to it already has the goroutine name set,
+	// and the name passed to it is the worker's full goroutine
+	// name (as it appears in List).  If WorkerPanicHandler is
+	// nil, then the recovered value is turned in to an error with
+	// derror.PanicToError.  It is not used if
+	// DisablePanicRecovery is set.
+	WorkerPanicHandler func(ctx context.Context, name string, recovered interface{}) error
+
+	// OnWorkerStart and OnWorkerFinish, if set, are called from
+	// inside of each worker goroutine, just before the worker
+	// function is called and just after it returns (with the
+	// worker's exit error, after any panic recovery).  Like
+	// WorkerPanicHandler, they are passed the worker's Context
+	// and full goroutine name.  They are called even if
+	// DisableLogging is set; but if DisablePanicRecovery is set
+	// and the worker panics, then OnWorkerFinish is not called.
+	OnWorkerStart  func(ctx context.Context, name string)
+	OnWorkerFinish func(ctx context.Context, name string, err error)
 
 	WorkerContext func(ctx context.Context, name string) context.Context
+
+	// RestartPolicy controls whether workers launched with Go are
+	// re-launched after they exit; the zero value is RestartNever.
+	// Use GoWithPolicy to set it for an individual worker.  A
+	// worker is not restarted once shutdown has been initiated.
+	RestartPolicy RestartPolicy
+	// MaxRestarts, if positive, is how many times a worker may be
+	// restarted within RestartWindow; if it exits again after
+	// that, then it is treated as having failed (with an error
+	// that wraps the worker's last error), initiating shutdown.
+	MaxRestarts int
+	// RestartWindow is the period over which restarts are counted
+	// against MaxRestarts.  A zero value means that all restarts
+	// for the lifetime of the worker are counted.
+	RestartWindow time.Duration
+	// RestartBackoff is how long to wait before restarting a
+	// worker after it exits; each consecutive restart waits twice
+	// as long as the one before it, up to RestartBackoffMax.  The
+	// delay goes back to RestartBackoff once a worker has run for
+	// at least RestartBackoffMax.  The delays are measured with
+	// the Context's dtime/v2 Clock.  The defaults are 100ms and
+	// 30s.
+	RestartBackoff    time.Duration
+	RestartBackoffMax time.Duration
 }
 
 // NewGroup returns a new Group.
 func NewGroup(ctx context.Context, cfg GroupConfig) *Group {
 	cfg.EnableWithSoftness = cfg.EnableWithSoftness || cfg.EnableSignalHandling || (cfg.SoftShutdownTimeout > 0)
+	if cfg.DisableLogging {
+		cfg.DisableShutdownLogging = true
+		cfg.DisableWorkerExitLogging = true
+	}
 
 	ctx, hardCancel := context.WithCancel(ctx)
 	var softCancel context.CancelFunc
@@ -182,6 +275,7 @@ func NewGroup(ctx context.Context, cfg GroupConfig) *Group {
 	g := &Group{
 		cfg: cfg,
 		//baseCtx: gets set below,
+		createdAt: time.Now(),
 
 		shutdownTimedOut: make(chan struct{}),
 		waitFinished:     make(chan struct{}),
@@ -201,7 +295,7 @@ func NewGroup(ctx context.Context, cfg GroupConfig) *Group {
 // "helper" goroutines that aren't of concern to the caller of dgroup,
 // but are internal to implementing dgroup's various features.
 func (g *Group) launchSupervisors() {
-	if !g.cfg.DisableLogging {
+	if !g.cfg.DisableShutdownLogging {
 		g.goSupervisor("shutdown_logger", func(ctx context.Context) {
 			// We should be as specific with logging as possible.
 
@@ -319,24 +413,42 @@ func (g *Group) launchSupervisors() {
 				} else if dcontext.HardContext(ctx).Err() == nil {
 					err := fmt.Errorf("received signal %v (graceful shutdown already triggered; triggering not-so-graceful shutdown)", sig)
 
-					if !g.cfg.DisableLogging {
+					if !g.cfg.DisableShutdownLogging {
 						dlog.Errorln(ctx, err)
-						logGoroutineStatuses(ctx, "goroutine statuses", dlog.Errorf, g.List())
+						logGoroutineStatuses(ctx, "goroutine statuses", dlog.Errorf, g.ListInfo())
 					}
 					g.hardCancel()
 
 				} else {
 					err := fmt.Errorf("received signal %v (not-so-graceful shutdown already triggered)", sig)
 
-					if !g.cfg.DisableLogging {
+					if !g.cfg.DisableShutdownLogging {
 						dlog.Errorln(ctx, err)
-						logGoroutineStatuses(ctx, "goroutine statuses", dlog.Errorf, g.List())
+						logGoroutineStatuses(ctx, "goroutine statuses", dlog.Errorf, g.ListInfo())
 						logGoroutineTraces(ctx, "goroutine stack traces", dlog.Errorf)
 					}
 				}
 			}
 		})
 	}
+
+	if g.cfg.EnableStatusDumpOnSIGUSR1 && len(statusDumpSignals) > 0 {
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, statusDumpSignals...)
+		g.goSupervisor("status_dumper", func(ctx context.Context) {
+			defer signal.Stop(sigs)
+			for {
+				select {
+				case <-g.waitFinished:
+					return
+				case sig := <-sigs:
+					dlog.Infof(ctx, "received signal %v (dumping goroutine statuses)", sig)
+					logGoroutineStatuses(ctx, "goroutine statuses", dlog.Infof, g.ListInfo())
+					logGoroutineTraces(ctx, "goroutine stack traces", dlog.Infof)
+				}
+			}
+		})
+	}
 }
 
 // Go calls the given function in a new named-worker-goroutine.
@@ -346,14 +458,51 @@ func (g *Group) launchSupervisors() {
 // a not-so-graceful shutdown.
 //
 // A worker may access its parent group by calling ParentGroup on its
-// Context.
+// Context.  Go may be called while Wait is running (such as by a
+// worker launching a sibling); Wait will not return until the newly
+// added worker has also finished.
+//
+// If GroupConfig.RestartPolicy is set, then the worker may be
+// restarted after it exits; see GoWithPolicy.
 func (g *Group) Go(name string, fn func(ctx context.Context) error) {
-	g.goWorker(name, fn)
+	g.goWorker(name, g.withRestarts(g.cfg.RestartPolicy, fn))
+}
+
+// GoWithContext is like Go, but the worker's Context is based on ctx
+// rather than on the Context that was passed to NewGroup; this allows
+// the caller to launch a worker that already has extra values (such
+// as a trace span, or dlog fields) in its Context.  Everything else is
+// the same as with Go: the worker is named "/"+name (regardless of any
+// goroutine name that ctx already has), GroupConfig.WorkerContext is
+// called, it is subject to the Group's soft and hard shutdown, panics
+// are recovered, and its exit is logged.
+//
+// ctx must be derived from the Group's own Context; for example, the
+// Context passed to another of the Group's workers.  This ensures that
+// the worker sees the Group's cancellation.  If ParentGroup(ctx) is
+// not this Group, then the worker is not run, and instead fails
+// immediately with an error.
+func (g *Group) GoWithContext(name string, ctx context.Context, fn func(ctx context.Context) error) {
+	if ParentGroup(ctx) != g {
+		err := errors.Errorf("dgroup.Group.GoWithContext: the Context for worker %q is not derived from the Group's Context", name)
+		g.goWorkerFrom(g.baseCtx, name, func(context.Context) error { return err })
+		return
+	}
+	// Clear any existing goroutine name, so that the worker gets the same name that Go
+	// would have given it.
+	ctx = context.WithValue(ctx, goroutineNameKey{}, "")
+	g.goWorkerFrom(ctx, name, g.withRestarts(g.cfg.RestartPolicy, fn))
 }
 
 // goWorker launches a worker goroutine for the user of dgroup.
 func (g *Group) goWorker(name string, fn func(ctx context.Context) error) {
-	ctx := WithGoroutineName(g.baseCtx, "/"+name)
+	g.goWorkerFrom(g.baseCtx, name, fn)
+}
+
+// goWorkerFrom is like goWorker(), except that the worker's Context
+// is based on ctx rather than on g.baseCtx.
+func (g *Group) goWorkerFrom(ctx context.Context, name string, fn func(ctx context.Context) error) {
+	ctx = WithGoroutineName(ctx, "/"+name)
 	if g.cfg.WorkerContext != nil {
 		ctx = g.cfg.WorkerContext(ctx, name)
 	}
@@ -363,14 +512,29 @@ func (g *Group) goWorker(name string, fn func(ctx context.Context) error) {
 // goWorkerCtx() is like goWorker(), except it takes an
 // already-created context.
 func (g *Group) goWorkerCtx(ctx context.Context, fn func(ctx context.Context) error) {
+	g.cancelsMu.Lock()
+	defer g.cancelsMu.Unlock()
+	ctx, wc := g.withWorkerCancelLocked(ctx, getGoroutineName(ctx))
 	g.workers.Go(getGoroutineName(ctx), func() (err error) {
+		if g.cfg.OnWorkerStart != nil {
+			g.cfg.OnWorkerStart(ctx, getGoroutineName(ctx))
+		}
+		returned := false
 		defer func() {
 			if !g.cfg.DisablePanicRecovery {
-				if _err := derror.PanicToError(recover()); _err != nil {
-					err = _err
+				if rec := recover(); rec != nil {
+					if g.cfg.WorkerPanicHandler != nil {
+						err = g.cfg.WorkerPanicHandler(ctx, getGoroutineName(ctx), rec)
+					} else {
+						err = derror.PanicToError(rec)
+					}
 				}
 			}
-			if !g.cfg.DisableLogging {
+			if g.finishWorkerCancel(getGoroutineName(ctx), wc) && errors.Is(err, context.Canceled) {
+				// It was canceled with Group.Cancel or Group.Kill; that's not an error.
+				err = nil
+			}
+			if !g.cfg.DisableWorkerExitLogging {
 				if err == nil {
 					dlog.Debugf(ctx, "goroutine %q exited", getGoroutineName(ctx))
 				} else {
@@ -380,9 +544,14 @@ func (g *Group) goWorkerCtx(ctx context.Context, fn func(ctx context.Context) er
 					dlog.Errorf(ctx, "goroutine %q exited with error: %+v", getGoroutineName(ctx), err)
 				}
 			}
+			if g.cfg.OnWorkerFinish != nil && (returned || !g.cfg.DisablePanicRecovery) {
+				g.cfg.OnWorkerFinish(ctx, getGoroutineName(ctx), err)
+			}
 		}()
 
-		return fn(ctx)
+		err = fn(ctx)
+		returned = true
+		return err
 	})
 }
 
@@ -427,7 +596,44 @@ func (g *Group) goSupervisorCtx(ctx context.Context, fn func(ctx context.Context
 // HardShutdownTimeout passed to NewGroup.  If a poorly-behaved
 // goroutine is still running at the end of that time, it is left
 // running, and an error is returned.
+//
+// Wait may be called more than once, and concurrently with itself and
+// with WaitChan; every call returns the same result.
 func (g *Group) Wait() error {
+	g.waitOnce.Do(func() {
+		g.waitErr = g.wait()
+	})
+	return g.waitErr
+}
+
+// WaitChan is like Wait, but rather than blocking it returns a
+// channel that receives the result of Wait (possibly nil) exactly once
+// and is then closed; this makes it possible to select on a Group:
+//
+//	select {
+//	case err := <-g.WaitChan():
+//		...
+//	case <-time.After(timeout):
+//		...
+//	}
+//
+// WaitChan starts waiting (if Wait has not already been called), so it
+// is not necessary to also call Wait.  Calling WaitChan more than once
+// returns the same channel; since that channel only receives one value,
+// if you need the result in multiple places then call Wait (or receive
+// from the channel once and pass the result along).
+func (g *Group) WaitChan() <-chan error {
+	g.waitChanOnce.Do(func() {
+		g.waitChan = make(chan error, 1)
+		go func() {
+			g.waitChan <- g.Wait()
+			close(g.waitChan)
+		}()
+	})
+	return g.waitChan
+}
+
+func (g *Group) wait() error {
 	// 1. Wait for the worker goroutines to finish (or time out)
 	shutdownCompleted := make(chan error)
 	go func() {
@@ -452,10 +658,12 @@ func (g *Group) Wait() error {
 	// running.
 	g.hardCancel()
 
+	g.waitTimedOut = timedOut
+
 	// 4. Log the result and return
-	if ret != nil && !g.cfg.DisableLogging {
+	if ret != nil && !g.cfg.DisableShutdownLogging {
 		ctx := WithGoroutineName(g.baseCtx, ":shutdown_status")
-		logGoroutineStatuses(ctx, "final goroutine statuses", dlog.Infof, g.List())
+		logGoroutineStatuses(ctx, "final goroutine statuses", dlog.Infof, g.ListInfo())
 		if timedOut {
 			logGoroutineTraces(ctx, "final goroutine stack traces", dlog.Errorf)
 		}
@@ -468,6 +676,53 @@ func (g *Group) List() map[string]derrgroup.GoroutineState {
 	return g.workers.List()
 }
 
+// ListInfo is like List, but also includes timing information about
+// when each goroutine was started and (if it has exited) when it
+// finished.
+func (g *Group) ListInfo() map[string]derrgroup.GoroutineInfo {
+	return g.workers.ListInfo()
+}
+
+// WorkerCount returns the number of worker goroutines launched with
+// .Go() that are currently running.  It is safe to call concurrently
+// with Wait.
+//
+// Deprecated: WorkerCount is the same as RunningLen; use RunningLen,
+// which goes with Len.
+func (g *Group) WorkerCount() int {
+	return g.RunningLen()
+}
+
+// Len returns the number of worker goroutines that have been launched
+// with .Go(), including ones that have exited; it is the same as
+// len(g.List()), but is cheaper, since it doesn't make a copy of the
+// list.  It is safe to call concurrently with Wait.
+func (g *Group) Len() int {
+	return g.workers.Len()
+}
+
+// RunningLen returns the number of worker goroutines launched with
+// .Go() that are currently running.  Like Len, it doesn't make a copy
+// of the list.  It is safe to call concurrently with Wait.
+func (g *Group) RunningLen() int {
+	return g.workers.RunningLen()
+}
+
+// Running returns whether the group is still active.  It returns
+// false once Wait has finished waiting and all of the worker
+// goroutines have exited; if Wait gave up because of
+// HardShutdownTimeout, then Running continues to return true for as
+// long as any of the poorly-behaved goroutines are left running.  It
+// is safe to call concurrently with Wait.
+func (g *Group) Running() bool {
+	select {
+	case <-g.waitFinished:
+		return g.WorkerCount() > 0
+	default:
+		return true
+	}
+}
+
 type groupKey struct{}
 
 // ParentGroup returns the Group that manages this goroutine/Context.
@@ -2,9 +2,16 @@ package dgroup
 
 import (
 	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/derrgroup"
+	"github.com/datawire/dlib/dlog"
 )
 
 // SetStacktraceForTesting overrides the stacktrace that would be
@@ -14,9 +21,201 @@ func SetStacktraceForTesting(trace string) {
 	stacktraceForTesting = trace
 }
 
+// SetDurationForTesting overrides the goroutine durations that would
+// be logged by dgroup, to set them to something fixed, to make
+// dgroup's unit tests simpler.  It returns a function that restores
+// the previous setting.
+func SetDurationForTesting(d time.Duration) (restore func()) {
+	prev := atomic.SwapInt64(&durationForTesting, int64(d))
+	return func() { atomic.StoreInt64(&durationForTesting, prev) }
+}
+
 func TestParentGroup(t *testing.T) {
 	// The example tests the positive case, so just test the
 	// negative case here.
 	group := ParentGroup(context.Background())
 	assert.Nil(t, group)
 }
+
+func TestWorkerPanicHandler(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+
+	var handledName string
+	var handledThread string
+	var handledValue interface{}
+	errHandled := errors.New("handled panic")
+	group := NewGroup(ctx, GroupConfig{
+		WorkerPanicHandler: func(ctx context.Context, name string, recovered interface{}) error {
+			handledName = name
+			handledThread = getGoroutineName(ctx)
+			handledValue = recovered
+			return errHandled
+		},
+	})
+	group.Go("worker", func(ctx context.Context) error {
+		panic("oops")
+	})
+	assert.Equal(t, errHandled, group.Wait())
+	assert.Equal(t, "/worker", handledName)
+	assert.Equal(t, "/worker", handledThread)
+	assert.Equal(t, "oops", handledValue)
+}
+
+func TestWorkerPanicHandlerNil(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+
+	group := NewGroup(ctx, GroupConfig{
+		WorkerPanicHandler: func(ctx context.Context, name string, recovered interface{}) error {
+			return nil
+		},
+	})
+	group.Go("worker", func(ctx context.Context) error {
+		panic("oops")
+	})
+	assert.NoError(t, group.Wait())
+	assert.Equal(t, map[string]derrgroup.GoroutineState{
+		"/worker": derrgroup.GoroutineExited,
+	}, group.List())
+}
+
+func TestRunning(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+
+	group := NewGroup(ctx, GroupConfig{})
+	assert.True(t, group.Running())
+	assert.Equal(t, 0, group.RunningLen())
+
+	release := make(chan struct{})
+	group.Go("a", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	group.Go("b", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	assert.True(t, group.Running())
+	assert.Equal(t, 2, group.RunningLen())
+
+	waitDone := make(chan error)
+	go func() {
+		waitDone <- group.Wait()
+	}()
+	assert.True(t, group.Running())
+
+	close(release)
+	assert.NoError(t, <-waitDone)
+	assert.False(t, group.Running())
+	assert.Equal(t, 0, group.RunningLen())
+}
+
+func TestRunningAfterTimeout(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+
+	group := NewGroup(ctx, GroupConfig{
+		HardShutdownTimeout: time.Second / 10,
+	})
+	release := make(chan struct{})
+	group.Go("hang", func(ctx context.Context) error {
+		<-release
+		return errors.New("oops")
+	})
+	group.hardCancel()
+
+	assert.Error(t, group.Wait())
+	assert.True(t, group.Running())
+	assert.Equal(t, 1, group.RunningLen())
+
+	close(release)
+	for group.Running() {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, 0, group.RunningLen())
+}
+
+func TestParentGroupGoDuringWait(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	group := NewGroup(ctx, GroupConfig{})
+
+	childRelease := make(chan struct{})
+	childFinished := false
+	spawned := make(chan struct{})
+	group.Go("parent", func(ctx context.Context) error {
+		<-spawned
+		ParentGroup(ctx).Go("child", func(ctx context.Context) error {
+			<-childRelease
+			childFinished = true
+			return nil
+		})
+		return nil
+	})
+
+	waitDone := make(chan error)
+	go func() {
+		waitDone <- group.Wait()
+	}()
+	close(spawned)
+
+	select {
+	case <-waitDone:
+		t.Fatal("Wait returned before the dynamically-added child finished")
+	case <-time.After(time.Second / 10):
+	}
+
+	close(childRelease)
+	assert.NoError(t, <-waitDone)
+	assert.True(t, childFinished)
+	assert.Equal(t, map[string]derrgroup.GoroutineState{
+		"/parent": derrgroup.GoroutineExited,
+		"/child":  derrgroup.GoroutineExited,
+	}, group.List())
+}
+
+func TestWorkerLifecycleHooks(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+
+	var mu sync.Mutex
+	var events []string
+	group := NewGroup(ctx, GroupConfig{
+		DisableLogging: true,
+		OnWorkerStart: func(ctx context.Context, name string) {
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(t, name, getGoroutineName(ctx))
+			events = append(events, "start "+name)
+		},
+		OnWorkerFinish: func(ctx context.Context, name string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(t, name, getGoroutineName(ctx))
+			if err != nil {
+				events = append(events, "finish "+name+": "+err.Error())
+			} else {
+				events = append(events, "finish "+name)
+			}
+		},
+	})
+
+	group.Go("ok", func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, "run /ok")
+		return nil
+	})
+	assert.NoError(t, group.Wait())
+
+	group = NewGroup(ctx, GroupConfig{
+		OnWorkerFinish: group.cfg.OnWorkerFinish,
+	})
+	group.Go("panic", func(ctx context.Context) error {
+		panic("oops")
+	})
+	assert.Error(t, group.Wait())
+
+	assert.Equal(t, []string{
+		"start /ok",
+		"run /ok",
+		"finish /ok",
+		"finish /panic: PANIC: oops",
+	}, events)
+}
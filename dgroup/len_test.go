@@ -0,0 +1,50 @@
+package dgroup_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/derrgroup"
+	"github.com/datawire/dlib/dgroup"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestLen(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	group := dgroup.NewGroup(ctx, dgroup.GroupConfig{})
+
+	// Before any workers.
+	assert.Equal(t, 0, group.Len())
+	assert.Equal(t, 0, group.RunningLen())
+
+	// With some running.
+	release := make(chan struct{})
+	for _, name := range []string{"a", "b", "c"} {
+		group.Go(name, func(ctx context.Context) error {
+			<-release
+			return nil
+		})
+	}
+	assert.Equal(t, 3, group.Len())
+	assert.Equal(t, 3, group.RunningLen())
+	assert.Equal(t, 3, group.WorkerCount())
+
+	// After some exit.
+	group.Go("d", func(ctx context.Context) error { return nil })
+	assert.Equal(t, 4, group.Len())
+	for group.List()["/d"] == derrgroup.GoroutineRunning {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, 4, group.Len())
+	assert.Equal(t, 3, group.RunningLen())
+
+	// After all exit.
+	close(release)
+	assert.NoError(t, group.Wait())
+	assert.Equal(t, 4, group.Len())
+	assert.Equal(t, 0, group.RunningLen())
+	assert.Equal(t, len(group.List()), group.Len())
+}
@@ -2,6 +2,7 @@ package dgroup
 
 import (
 	"context"
+	"strings"
 
 	"github.com/datawire/dlib/dlog"
 )
@@ -36,3 +37,29 @@ func WithGoroutineName(ctx context.Context, newName string) context.Context {
 	ctx = context.WithValue(ctx, goroutineNameKey{}, newName)
 	return ctx
 }
+
+// WithGoroutineNameComponent is like WithGoroutineName, but appends
+// a single component to the goroutine name path, adding the "/"
+// delimiter for you.  The component should not itself contain a "/".
+//
+// This is useful for applications that model hierarchies of workers
+// (for example "pool/worker-0/retry-1") and want to query the name
+// structurally with GetGoroutineNameComponents.
+func WithGoroutineNameComponent(ctx context.Context, component string) context.Context {
+	return WithGoroutineName(ctx, "/"+component)
+}
+
+// GetGoroutineNameComponents returns the individual "/"-delimited
+// components of the goroutine name associated with the context.
+// Empty components are omitted, so the leading "/" that Group.Go
+// adds does not result in an empty first component.  If the context
+// has no name, then nil is returned.
+func GetGoroutineNameComponents(ctx context.Context) []string {
+	var components []string
+	for _, component := range strings.Split(getGoroutineName(ctx), "/") {
+		if component != "" {
+			components = append(components, component)
+		}
+	}
+	return components
+}
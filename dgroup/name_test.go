@@ -0,0 +1,29 @@
+package dgroup_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dgroup"
+)
+
+func TestGoroutineNameComponents(t *testing.T) {
+	ctx := context.Background()
+	assert.Nil(t, dgroup.GetGoroutineNameComponents(ctx))
+
+	ctx = dgroup.WithGoroutineName(ctx, "pool")
+	assert.Equal(t, []string{"pool"}, dgroup.GetGoroutineNameComponents(ctx))
+
+	ctx = dgroup.WithGoroutineNameComponent(ctx, "worker-0")
+	ctx = dgroup.WithGoroutineNameComponent(ctx, "retry-1")
+	assert.Equal(t, []string{"pool", "worker-0", "retry-1"}, dgroup.GetGoroutineNameComponents(ctx))
+
+	group := dgroup.NewGroup(ctx, dgroup.GroupConfig{DisableLogging: true})
+	group.Go("child", func(ctx context.Context) error {
+		assert.Equal(t, []string{"pool", "worker-0", "retry-1", "child"}, dgroup.GetGoroutineNameComponents(ctx))
+		return nil
+	})
+	assert.NoError(t, group.Wait())
+}
@@ -0,0 +1,147 @@
+package dgroup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/datawire/dlib/derror"
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/dlib/dtime/v2"
+)
+
+// RestartPolicy controls whether a worker is re-launched after it
+// exits; see GroupConfig.RestartPolicy.
+type RestartPolicy int
+
+const (
+	// RestartNever means that a worker is never restarted.
+	RestartNever RestartPolicy = iota
+	// RestartOnError means that a worker is restarted if it exits
+	// with an error (including a recovered panic).
+	RestartOnError
+	// RestartAlways means that a worker is restarted whenever it
+	// exits.
+	RestartAlways
+)
+
+func (p RestartPolicy) String() string {
+	switch p {
+	case RestartNever:
+		return "never"
+	case RestartOnError:
+		return "on-error"
+	case RestartAlways:
+		return "always"
+	default:
+		return fmt.Sprintf("RestartPolicy(%d)", int(p))
+	}
+}
+
+// GoWithPolicy is like Go, but uses the given RestartPolicy for this
+// worker instead of GroupConfig.RestartPolicy.
+func (g *Group) GoWithPolicy(name string, policy RestartPolicy, fn func(ctx context.Context) error) {
+	g.goWorker(name, g.withRestarts(policy, fn))
+}
+
+// callWorkerOnce calls fn, recovering from any panic (unless
+// DisablePanicRecovery is set) so that the worker may be restarted.
+func (g *Group) callWorkerOnce(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if !g.cfg.DisablePanicRecovery {
+		defer func() {
+			if rec := recover(); rec != nil {
+				if g.cfg.WorkerPanicHandler != nil {
+					err = g.cfg.WorkerPanicHandler(ctx, getGoroutineName(ctx), rec)
+				} else {
+					err = derror.PanicToError(rec)
+				}
+			}
+		}()
+	}
+	return fn(ctx)
+}
+
+// restartBackoff returns the RestartBackoff and RestartBackoffMax,
+// or their defaults if they are unset.
+func (g *Group) restartBackoff() (initial, max time.Duration) {
+	initial, max = g.cfg.RestartBackoff, g.cfg.RestartBackoffMax
+	if initial <= 0 {
+		initial = 100 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 30 * time.Second
+	}
+	if max < initial {
+		max = initial
+	}
+	return initial, max
+}
+
+// withRestarts wraps a worker function such that it is re-run
+// according to the RestartPolicy, MaxRestarts, RestartWindow, and
+// RestartBackoff.
+// The restarts all happen within the same worker goroutine, so that
+// the worker shows as running in List for the whole time.
+func (g *Group) withRestarts(policy RestartPolicy, fn func(ctx context.Context) error) func(ctx context.Context) error {
+	if policy == RestartNever {
+		return fn
+	}
+	return func(ctx context.Context) error {
+		var restarts []time.Time
+		initialBackoff, maxBackoff := g.restartBackoff()
+		backoff := initialBackoff
+		for {
+			start := dtime.Now(ctx)
+			err := g.callWorkerOnce(ctx, fn)
+			if ctx.Err() != nil || (policy == RestartOnError && err == nil) {
+				// Don't restart if shutting down.
+				return err
+			}
+
+			now := time.Now()
+			if g.cfg.RestartWindow > 0 {
+				// Forget about restarts that have fallen out of the window.
+				cutoff := now.Add(-g.cfg.RestartWindow)
+				for len(restarts) > 0 && !restarts[0].After(cutoff) {
+					restarts = restarts[1:]
+				}
+			}
+			if g.cfg.MaxRestarts > 0 && len(restarts) >= g.cfg.MaxRestarts {
+				msg := fmt.Sprintf("goroutine %q restarted more than %d times", getGoroutineName(ctx), g.cfg.MaxRestarts)
+				if g.cfg.RestartWindow > 0 {
+					msg += fmt.Sprintf(" within %v", g.cfg.RestartWindow)
+				}
+				if err == nil {
+					return errors.New(msg)
+				}
+				return errors.Wrap(err, msg)
+			}
+			restarts = append(restarts, now)
+
+			if dtime.Since(ctx, start) >= maxBackoff {
+				// It ran for a good while; this isn't a
+				// crash loop.
+				backoff = initialBackoff
+			}
+			if !g.cfg.DisableWorkerExitLogging {
+				if err == nil {
+					dlog.Infof(ctx, "goroutine %q exited; restarting in %v", getGoroutineName(ctx), backoff)
+				} else {
+					dlog.Warnf(ctx, "goroutine %q exited with error; restarting in %v: %+v", getGoroutineName(ctx), backoff, err)
+				}
+			}
+			timer := dtime.NewTimer(ctx, backoff)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			}
+			if backoff *= 2; backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package dgroup_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dgroup"
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/dlib/dtime/v2"
+)
+
+func TestRestartOnError(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	group := dgroup.NewGroup(ctx, dgroup.GroupConfig{
+		RestartPolicy:  dgroup.RestartOnError,
+		MaxRestarts:    5,
+		RestartBackoff: time.Millisecond,
+	})
+	calls := 0
+	group.Go("flaky", func(ctx context.Context) error {
+		calls++
+		switch calls {
+		case 1:
+			return errors.New("oops")
+		case 2:
+			panic("oops")
+		default:
+			return nil
+		}
+	})
+	assert.NoError(t, group.Wait())
+	assert.Equal(t, 3, calls)
+}
+
+func TestRestartAlwaysMaxRestarts(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	group := dgroup.NewGroup(ctx, dgroup.GroupConfig{
+		MaxRestarts:    3,
+		RestartBackoff: time.Millisecond,
+	})
+	calls := 0
+	group.GoWithPolicy("looper", dgroup.RestartAlways, func(ctx context.Context) error {
+		calls++
+		return nil
+	})
+	bystanderCanceled := false
+	group.Go("bystander", func(ctx context.Context) error {
+		<-ctx.Done()
+		bystanderCanceled = true
+		return nil
+	})
+	err := group.Wait()
+	assert.EqualError(t, err, `goroutine "/looper" restarted more than 3 times`)
+	assert.Equal(t, 4, calls)
+	assert.True(t, bystanderCanceled, "escalation should shut down the group")
+}
+
+func TestRestartWindow(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	group := dgroup.NewGroup(ctx, dgroup.GroupConfig{
+		RestartPolicy:  dgroup.RestartOnError,
+		MaxRestarts:    1,
+		RestartWindow:  50 * time.Millisecond,
+		RestartBackoff: time.Millisecond,
+	})
+
+	// Failing slower than the window never escalates.
+	slowCalls := 0
+	group.Go("slow", func(ctx context.Context) error {
+		slowCalls++
+		if slowCalls == 4 {
+			return nil
+		}
+		time.Sleep(75 * time.Millisecond)
+		return errors.New("oops")
+	})
+	assert.NoError(t, group.Wait())
+	assert.Equal(t, 4, slowCalls)
+
+	// Failing faster than the window escalates.
+	group = dgroup.NewGroup(ctx, dgroup.GroupConfig{
+		RestartPolicy: dgroup.RestartOnError,
+		MaxRestarts:   1,
+		RestartWindow: time.Minute,
+	})
+	fastCalls := 0
+	group.Go("fast", func(ctx context.Context) error {
+		fastCalls++
+		return errors.New("oops")
+	})
+	assert.EqualError(t, group.Wait(), `goroutine "/fast" restarted more than 1 times within 1m0s: oops`)
+	assert.Equal(t, 2, fastCalls)
+}
+
+func TestRestartBackoff(t *testing.T) {
+	fc := dtime.NewFakeClock()
+	ctx := dtime.WithClock(dlog.NewTestContext(t, false), fc)
+	group := dgroup.NewGroup(ctx, dgroup.GroupConfig{
+		RestartPolicy:     dgroup.RestartOnError,
+		RestartBackoff:    time.Second,
+		RestartBackoffMax: 4 * time.Second,
+	})
+	var calls int32
+	group.Go("crasher", func(ctx context.Context) error {
+		if atomic.AddInt32(&calls, 1) == 6 {
+			return nil
+		}
+		return errors.New("oops")
+	})
+
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	for i, backoff := range []time.Duration{1, 2, 4, 4, 4} {
+		// The worker doesn't run again until the backoff has
+		// passed.
+		if !assert.NoError(t, fc.WaitFor(waitCtx, 1)) {
+			return
+		}
+		assert.Equal(t, int32(i+1), atomic.LoadInt32(&calls))
+		fc.Step(backoff*time.Second - time.Nanosecond)
+		assert.Equal(t, 1, fc.PendingTimers(), "restarted too early")
+		fc.Step(time.Nanosecond)
+	}
+	assert.NoError(t, group.Wait())
+	assert.Equal(t, int32(6), calls)
+}
+
+func TestRestartBackoffShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(dtime.WithClock(dlog.NewTestContext(t, false), dtime.NewFakeClock()))
+	group := dgroup.NewGroup(ctx, dgroup.GroupConfig{
+		RestartPolicy: dgroup.RestartAlways,
+	})
+	var calls int32
+	group.Go("crasher", func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	})
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	assert.NoError(t, group.Wait())
+	// The FakeClock never advances, so the worker never gets
+	// restarted; without the backoff, it would have spun.
+	assert.Equal(t, int32(1), calls)
+}
+
+func TestRestartNotDuringShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	group := dgroup.NewGroup(ctx, dgroup.GroupConfig{
+		RestartPolicy: dgroup.RestartAlways,
+	})
+	calls := 0
+	group.Go("worker", func(ctx context.Context) error {
+		calls++
+		<-ctx.Done()
+		return nil
+	})
+	group.GoWithPolicy("never", dgroup.RestartNever, func(ctx context.Context) error {
+		cancel()
+		return nil
+	})
+	assert.NoError(t, group.Wait())
+	assert.Equal(t, 1, calls)
+}
+
+func TestRestartPolicyString(t *testing.T) {
+	assert.Equal(t, "never", dgroup.RestartNever.String())
+	assert.Equal(t, "on-error", dgroup.RestartOnError.String())
+	assert.Equal(t, "always", dgroup.RestartAlways.String())
+}
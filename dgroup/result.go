@@ -0,0 +1,64 @@
+package dgroup
+
+import (
+	"sort"
+
+	"github.com/datawire/dlib/derror"
+)
+
+// GroupResult is a structured version of the result of Wait.
+type GroupResult struct {
+	// WorkerErrors maps the names (as in List) of the workers that
+	// exited with an error to those errors.  Workers that exited
+	// cleanly (or that were still running when Wait gave up on
+	// them) are not included.
+	WorkerErrors map[string]error
+	// TimedOut is whether Wait gave up waiting for the workers
+	// because the HardShutdownTimeout was reached.
+	TimedOut bool
+
+	timeoutErr error
+}
+
+// Err returns all of the errors in the GroupResult as a single error:
+// nil if there were none, or else a derror.MultiError of the worker
+// errors (sorted by worker name) followed by the timeout error (if
+// TimedOut).
+//
+// Note that this is not the same as the error returned by Wait, which
+// is only the first error to have happened.
+func (r GroupResult) Err() error {
+	names := make([]string, 0, len(r.WorkerErrors))
+	for name := range r.WorkerErrors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var err error
+	for _, name := range names {
+		err = derror.Append(err, r.WorkerErrors[name])
+	}
+	if r.TimedOut {
+		err = derror.Append(err, r.timeoutErr)
+	}
+	return err
+}
+
+// WaitResult is like Wait, but returns a GroupResult that tells
+// apart the different ways that the Group may have failed.
+func (g *Group) WaitResult() GroupResult {
+	err := g.Wait()
+	ret := GroupResult{
+		WorkerErrors: make(map[string]error),
+		TimedOut:     g.waitTimedOut,
+	}
+	if ret.TimedOut {
+		// If it timed out, then that's the error that Wait returns.
+		ret.timeoutErr = err
+	}
+	for name, info := range g.ListInfo() {
+		if info.Err != nil {
+			ret.WorkerErrors[name] = info.Err
+		}
+	}
+	return ret
+}
@@ -0,0 +1,91 @@
+package dgroup_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/derror"
+	"github.com/datawire/dlib/dgroup"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestWaitResult(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	group := dgroup.NewGroup(ctx, dgroup.GroupConfig{})
+
+	errA := errors.New("a failed")
+	errB := errors.New("b failed")
+	group.Go("a", func(ctx context.Context) error {
+		return errA
+	})
+	group.Go("b", func(ctx context.Context) error {
+		<-ctx.Done()
+		return errB
+	})
+	group.Go("c", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+
+	result := group.WaitResult()
+	assert.False(t, result.TimedOut)
+	assert.Equal(t, map[string]error{
+		"/a": errA,
+		"/b": errB,
+	}, result.WorkerErrors)
+
+	err := result.Err()
+	var multi derror.MultiError
+	if assert.True(t, errors.As(err, &multi)) {
+		assert.Equal(t, derror.MultiError{errA, errB}, multi)
+	}
+
+	// WaitResult and Wait agree.
+	assert.Equal(t, errA, group.Wait())
+	assert.Equal(t, result, group.WaitResult())
+}
+
+func TestWaitResultClean(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	group := dgroup.NewGroup(ctx, dgroup.GroupConfig{})
+	group.Go("a", func(ctx context.Context) error {
+		return nil
+	})
+
+	result := group.WaitResult()
+	assert.False(t, result.TimedOut)
+	assert.Empty(t, result.WorkerErrors)
+	assert.NoError(t, result.Err())
+}
+
+func TestWaitResultTimedOut(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	group := dgroup.NewGroup(ctx, dgroup.GroupConfig{
+		HardShutdownTimeout: 100 * time.Millisecond,
+	})
+
+	release := make(chan struct{})
+	defer close(release)
+	errA := errors.New("a failed")
+	group.Go("a", func(ctx context.Context) error {
+		return errA
+	})
+	group.Go("stuck", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+
+	result := group.WaitResult()
+	assert.True(t, result.TimedOut)
+	assert.Equal(t, map[string]error{"/a": errA}, result.WorkerErrors)
+
+	var multi derror.MultiError
+	if assert.True(t, errors.As(result.Err(), &multi)) && assert.Len(t, multi, 2) {
+		assert.Equal(t, errA, multi[0])
+		assert.Contains(t, multi[1].Error(), "failed to shut down within the 100ms shutdown timeout")
+	}
+}
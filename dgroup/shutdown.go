@@ -0,0 +1,48 @@
+package dgroup
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/datawire/dlib/dcontext"
+)
+
+// ShutdownGroup returns a copy of ctx that has a hard/soft
+// distinction (see dcontext.WithSoftness), along with a function
+// that shuts it down; for when you want "when this function returns,
+// shut down everything that I started" without setting up a whole
+// Group.
+//
+// The first call to cancel initiates a graceful shutdown by canceling
+// the returned (soft) Context; any later call initiates a hard
+// shutdown by canceling the hard Context too.  Canceling ctx itself
+// also initiates a hard shutdown.  The hard Context's resources are
+// only released by a hard shutdown, so cancel must be called twice
+// (unless ctx is canceled); for example:
+//
+//	ctx, cancel := dgroup.ShutdownGroup(ctx)
+//	defer cancel() // hard shutdown
+//
+//	… start things with ctx …
+//
+//	cancel() // graceful shutdown
+//	… wait for things to finish …
+//
+// If the function might return before reaching the graceful shutdown,
+// then defer cancel twice.
+//
+// This is just dcontext.WithSoftness and a couple of calls to
+// context.WithCancel, but giving it a name documents the intent and
+// saves the boilerplate.
+func ShutdownGroup(ctx context.Context) (context.Context, context.CancelFunc) {
+	hardCtx, hardCancel := context.WithCancel(ctx)
+	softCtx, softCancel := context.WithCancel(dcontext.WithSoftness(hardCtx))
+	var calls int32
+	return softCtx, func() {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			softCancel()
+		} else {
+			hardCancel()
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package dgroup_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dgroup"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestShutdownGroup(t *testing.T) {
+	t.Run("soft-then-hard", func(t *testing.T) {
+		ctx, cancel := dgroup.ShutdownGroup(dlog.NewTestContext(t, false))
+		hardCtx := dcontext.HardContext(ctx)
+		assert.NoError(t, ctx.Err())
+		assert.NoError(t, hardCtx.Err())
+
+		cancel()
+		assert.ErrorIs(t, ctx.Err(), context.Canceled)
+		assert.NoError(t, hardCtx.Err())
+
+		cancel()
+		assert.ErrorIs(t, hardCtx.Err(), context.Canceled)
+
+		// Further calls are no-ops.
+		cancel()
+	})
+	t.Run("one-call", func(t *testing.T) {
+		// A single call (such as a lone deferred cancel) is
+		// only a graceful shutdown.
+		ctx, cancel := dgroup.ShutdownGroup(dlog.NewTestContext(t, false))
+		cancel()
+		assert.ErrorIs(t, ctx.Err(), context.Canceled)
+		assert.NoError(t, dcontext.HardContext(ctx).Err())
+		cancel()
+	})
+	t.Run("parent", func(t *testing.T) {
+		parent, parentCancel := context.WithCancel(dlog.NewTestContext(t, false))
+		ctx, cancel := dgroup.ShutdownGroup(parent)
+		defer cancel()
+		defer cancel()
+
+		parentCancel()
+		assert.ErrorIs(t, ctx.Err(), context.Canceled)
+		assert.ErrorIs(t, dcontext.HardContext(ctx).Err(), context.Canceled)
+	})
+	t.Run("group", func(t *testing.T) {
+		ctx, cancel := dgroup.ShutdownGroup(dlog.NewTestContext(t, false))
+		defer cancel()
+
+		grp := dgroup.NewGroup(ctx, dgroup.GroupConfig{})
+		grp.Go("worker", func(ctx context.Context) error {
+			<-ctx.Done()
+			// A graceful shutdown; the hard Context is still live.
+			return dcontext.HardContext(ctx).Err()
+		})
+		cancel()
+		assert.NoError(t, grp.Wait())
+	})
+}
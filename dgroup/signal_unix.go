@@ -0,0 +1,10 @@
+// +build !windows
+
+package dgroup
+
+import (
+	"os"
+	"syscall"
+)
+
+var statusDumpSignals = []os.Signal{syscall.SIGUSR1}
@@ -0,0 +1,85 @@
+// +build !windows
+
+package dgroup_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dgroup"
+	"github.com/datawire/dlib/dlog"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestStatusDumpOnSIGUSR1(t *testing.T) {
+	var logOutput syncBuffer
+	logger := logrus.New()
+	logger.SetOutput(&logOutput)
+	logger.SetFormatter(&logrus.TextFormatter{
+		DisableTimestamp: true,
+	})
+	ctx := dlog.WithLogger(context.Background(), dlog.WrapLogrus(logger))
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	group := dgroup.NewGroup(ctx, dgroup.GroupConfig{
+		EnableWithSoftness:        true,
+		EnableStatusDumpOnSIGUSR1: true,
+	})
+
+	workerCtx := make(chan context.Context)
+	group.Go("worker", func(ctx context.Context) error {
+		workerCtx <- ctx
+		<-ctx.Done()
+		return nil
+	})
+	ctx = <-workerCtx
+
+	self, err := os.FindProcess(os.Getpid())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, self.Signal(syscall.SIGUSR1))
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !strings.Contains(logOutput.String(), "goroutine stack traces:") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	output := logOutput.String()
+	assert.Contains(t, output, "received signal user defined signal 1 (dumping goroutine statuses)")
+	assert.Contains(t, output, "/worker: running for ")
+	assert.Contains(t, output, "goroutine stack traces:")
+
+	assert.NoError(t, ctx.Err())
+	assert.NoError(t, dcontext.HardContext(ctx).Err())
+	assert.True(t, group.Running())
+
+	cancel()
+	assert.NoError(t, group.Wait())
+}
@@ -0,0 +1,8 @@
+package dgroup
+
+import (
+	"os"
+)
+
+// SIGUSR1 is not available on Windows, so status dumping is skipped.
+var statusDumpSignals []os.Signal
@@ -0,0 +1,87 @@
+package dgroup
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/datawire/dlib/derrgroup"
+)
+
+// GroupState is the overall state of a Group, as reported by
+// Group.Snapshot.
+type GroupState int
+
+const (
+	// GroupStarting means that no workers have been launched yet.
+	GroupStarting GroupState = iota
+	// GroupRunning means that workers have been launched, and
+	// shutdown has not been initiated.
+	GroupRunning
+	// GroupShuttingDown means that shutdown has been initiated,
+	// but Wait has not yet finished.
+	GroupShuttingDown
+	// GroupDone means that Wait has finished.
+	GroupDone
+)
+
+func (s GroupState) String() string {
+	switch s {
+	case GroupStarting:
+		return "starting"
+	case GroupRunning:
+		return "running"
+	case GroupShuttingDown:
+		return "shutting_down"
+	case GroupDone:
+		return "done"
+	default:
+		return fmt.Sprintf("GroupState(%d)", int(s))
+	}
+}
+
+// GroupSnapshot is a point-in-time view of a Group, as returned by
+// Group.Snapshot.
+type GroupSnapshot struct {
+	// State is the overall state of the group.
+	State GroupState
+	// CreatedAt is when NewGroup was called.
+	CreatedAt time.Time
+	// Elapsed is how long it had been since CreatedAt at the time
+	// that the snapshot was taken.
+	Elapsed time.Duration
+	// Workers is the state and timing information of each worker
+	// launched with .Go(), the same as ListInfo returns.
+	Workers map[string]derrgroup.GoroutineInfo
+}
+
+// Snapshot returns a point-in-time view of the group and its workers,
+// which is useful for debugging, and for tests that want to inspect
+// the group without parsing log output.  It is safe to call from any
+// goroutine at any time; the returned GroupSnapshot does not share any
+// memory with the Group.
+func (g *Group) Snapshot() GroupSnapshot {
+	now := time.Now()
+	workers := g.workers.ListInfo()
+
+	var state GroupState
+	select {
+	case <-g.waitFinished:
+		state = GroupDone
+	default:
+		switch {
+		case g.baseCtx.Err() != nil:
+			state = GroupShuttingDown
+		case len(workers) == 0:
+			state = GroupStarting
+		default:
+			state = GroupRunning
+		}
+	}
+
+	return GroupSnapshot{
+		State:     state,
+		CreatedAt: g.createdAt,
+		Elapsed:   now.Sub(g.createdAt),
+		Workers:   workers,
+	}
+}
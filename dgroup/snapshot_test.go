@@ -0,0 +1,67 @@
+package dgroup_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/derrgroup"
+	"github.com/datawire/dlib/dgroup"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestSnapshot(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer cancel()
+	before := time.Now()
+	group := dgroup.NewGroup(ctx, dgroup.GroupConfig{})
+
+	snap := group.Snapshot()
+	assert.Equal(t, dgroup.GroupStarting, snap.State)
+	assert.False(t, snap.CreatedAt.Before(before))
+	assert.Empty(t, snap.Workers)
+
+	release := make(chan struct{})
+	exited := make(chan struct{})
+	group.Go("quick", func(ctx context.Context) error {
+		defer close(exited)
+		return nil
+	})
+	group.Go("slow", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	<-exited
+	for group.List()["/quick"] == derrgroup.GoroutineRunning {
+		time.Sleep(time.Millisecond)
+	}
+
+	snap = group.Snapshot()
+	assert.Equal(t, dgroup.GroupRunning, snap.State)
+	assert.Equal(t, derrgroup.GoroutineExited, snap.Workers["/quick"].State)
+	assert.Equal(t, derrgroup.GoroutineRunning, snap.Workers["/slow"].State)
+	assert.Greater(t, snap.Elapsed, time.Duration(0))
+
+	// The snapshot is a copy.
+	delete(snap.Workers, "/slow")
+	assert.Contains(t, group.Snapshot().Workers, "/slow")
+
+	cancel()
+	assert.Equal(t, dgroup.GroupShuttingDown, group.Snapshot().State)
+
+	close(release)
+	assert.NoError(t, group.Wait())
+	snap = group.Snapshot()
+	assert.Equal(t, dgroup.GroupDone, snap.State)
+	assert.Equal(t, derrgroup.GoroutineExited, snap.Workers["/slow"].State)
+}
+
+func TestGroupStateString(t *testing.T) {
+	assert.Equal(t, "starting", dgroup.GroupStarting.String())
+	assert.Equal(t, "running", dgroup.GroupRunning.String())
+	assert.Equal(t, "shutting_down", dgroup.GroupShuttingDown.String())
+	assert.Equal(t, "done", dgroup.GroupDone.String())
+	assert.Equal(t, "GroupState(99)", dgroup.GroupState(99).String())
+}
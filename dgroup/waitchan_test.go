@@ -0,0 +1,85 @@
+package dgroup_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dgroup"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestWaitChan(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	group := dgroup.NewGroup(ctx, dgroup.GroupConfig{})
+
+	release := make(chan struct{})
+	group.Go("worker", func(ctx context.Context) error {
+		<-release
+		return errors.New("oops")
+	})
+
+	ch := group.WaitChan()
+	assert.Equal(t, ch, group.WaitChan())
+	select {
+	case err := <-ch:
+		t.Fatalf("WaitChan returned before the worker exited: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case err := <-ch:
+		assert.Error(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for WaitChan")
+	}
+	// Exactly one value, then closed.
+	_, ok := <-ch
+	assert.False(t, ok)
+}
+
+func TestWaitChanConcurrentWithWait(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer cancel()
+	group := dgroup.NewGroup(ctx, dgroup.GroupConfig{})
+	group.Go("worker", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 5; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			errs <- group.Wait()
+		}()
+		go func() {
+			defer wg.Done()
+			select {
+			case err, ok := <-group.WaitChan():
+				if ok {
+					errs <- err
+				}
+			case <-time.After(10 * time.Second):
+				errs <- errors.New("timed out")
+			}
+		}()
+	}
+	cancel()
+	wg.Wait()
+	close(errs)
+	n := 0
+	for err := range errs {
+		assert.NoError(t, err)
+		n++
+	}
+	// All 5 Wait calls, plus the one receiver that got the WaitChan value.
+	assert.Equal(t, 6, n)
+}
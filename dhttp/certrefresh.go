@@ -0,0 +1,45 @@
+package dhttp
+
+import (
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileCertRefresh returns a function suitable for use as ServerConfig.TLSCertRefresh, that reads
+// the certificate and matching private key from the given files.  The loaded certificate is
+// cached, and the files are only re-read when the modification time of either of them changes.
+func FileCertRefresh(certFile, keyFile string) func() (*tls.Certificate, error) {
+	var (
+		mu          sync.Mutex
+		cached      *tls.Certificate
+		certModTime time.Time
+		keyModTime  time.Time
+	)
+	return func() (*tls.Certificate, error) {
+		certInfo, err := os.Stat(certFile)
+		if err != nil {
+			return nil, err
+		}
+		keyInfo, err := os.Stat(keyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if cached != nil && certInfo.ModTime().Equal(certModTime) && keyInfo.ModTime().Equal(keyModTime) {
+			return cached, nil
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		cached = &cert
+		certModTime = certInfo.ModTime()
+		keyModTime = keyInfo.ModTime()
+		return cached, nil
+	}
+}
@@ -0,0 +1,129 @@
+package dhttp_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+)
+
+func genTestCert(t *testing.T, serial int64) (certPEM, keyPEM, certDER []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{Organization: []string{"Acme Co"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+	}
+	certDER, err = x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, certDER
+}
+
+func TestTLSCertRefresh(t *testing.T) {
+	tmpdir, err := os.MkdirTemp("", "dhttp-test.")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(tmpdir)
+	certFile := filepath.Join(tmpdir, "cert.pem")
+	keyFile := filepath.Join(tmpdir, "key.pem")
+	writeCert := func(certPEM, keyPEM []byte, mtime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(certFile, certPEM, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+			t.Fatal(err)
+		}
+		// Set the mtime explicitly, so that the test doesn't depend on the filesystem's
+		// timestamp resolution.
+		if err := os.Chtimes(certFile, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(keyFile, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	certPEM1, keyPEM1, certDER1 := genTestCert(t, 1)
+	certPEM2, keyPEM2, certDER2 := genTestCert(t, 2)
+	now := time.Now()
+	writeCert(certPEM1, keyPEM1, now.Add(-time.Minute))
+
+	ctx, hardCancel := context.WithCancel(dlog.NewTestContext(t, true))
+	defer hardCancel()
+	ctx, softCancel := context.WithCancel(dcontext.WithSoftness(ctx))
+	defer softCancel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	sc := &dhttp.ServerConfig{
+		Handler:        http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		TLSCertRefresh: dhttp.FileCertRefresh(certFile, keyFile),
+	}
+	serverExited := make(chan struct{})
+	go func() {
+		defer close(serverExited)
+		assert.NoError(t, sc.ServeTLS(ctx, ln, "", ""))
+	}()
+
+	getPeerCert := func() []byte {
+		t.Helper()
+		client := &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+				DisableKeepAlives: true,
+			},
+		}
+		resp, err := client.Get("https://" + ln.Addr().String() + "/")
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		resp.Body.Close()
+		return resp.TLS.PeerCertificates[0].Raw
+	}
+
+	assert.Equal(t, certDER1, getPeerCert())
+	assert.Equal(t, certDER1, getPeerCert())
+
+	writeCert(certPEM2, keyPEM2, now)
+	assert.Equal(t, certDER2, getPeerCert())
+
+	softCancel()
+	<-serverExited
+}
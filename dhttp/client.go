@@ -0,0 +1,125 @@
+package dhttp
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+type clientConfig struct {
+	tlsConfig       *tls.Config
+	maxIdleConns    int
+	idleConnTimeout time.Duration
+	dialTimeout     time.Duration
+	timeout         time.Duration
+	transport       func(*http.Transport)
+}
+
+// A ClientOption adjusts the *http.Client created by NewClient.
+type ClientOption func(*clientConfig)
+
+// WithClientTLSConfig sets the TLS configuration used for HTTPS requests.
+func WithClientTLSConfig(cfg *tls.Config) ClientOption {
+	return func(c *clientConfig) {
+		c.tlsConfig = cfg
+	}
+}
+
+// WithClientMaxIdleConns sets the maximum number of idle (keep-alive) connections across all
+// hosts, and per host.  Zero means no limit.
+func WithClientMaxIdleConns(n int) ClientOption {
+	return func(c *clientConfig) {
+		c.maxIdleConns = n
+	}
+}
+
+// WithClientIdleConnTimeout sets how long an idle (keep-alive) connection is kept before being
+// closed.  Zero means no limit.
+func WithClientIdleConnTimeout(d time.Duration) ClientOption {
+	return func(c *clientConfig) {
+		c.idleConnTimeout = d
+	}
+}
+
+// WithClientDialTimeout sets the maximum amount of time that establishing a TCP connection may
+// take.
+func WithClientDialTimeout(d time.Duration) ClientOption {
+	return func(c *clientConfig) {
+		c.dialTimeout = d
+	}
+}
+
+// WithClientTimeout sets an overall time limit for each request, including reading the response
+// body; see http.Client.Timeout.  This is in addition to any deadline on the request's Context.
+// Zero means no limit other than the Context's.
+func WithClientTimeout(d time.Duration) ClientOption {
+	return func(c *clientConfig) {
+		c.timeout = d
+	}
+}
+
+// WithClientTransport allows arbitrary adjustment of the *http.Transport, for settings that
+// don't have a dedicated ClientOption.  It is called after all other options have been applied.
+func WithClientTransport(fn func(*http.Transport)) ClientOption {
+	return func(c *clientConfig) {
+		c.transport = fn
+	}
+}
+
+// NewClient returns an *http.Client that is ready for production use with Contexts and dlog:
+//
+//   - Each request is logged using the request's Context, and dlog fields that have been marked
+//     with MarkFieldForPropagation are sent as headers; see NewLoggingRoundTripper.  So requests
+//     should be made with http.NewRequestWithContext, rather than with the (*http.Client).Get
+//     family of methods.
+//
+//   - Dialing and TLS handshakes have timeouts (30s and 10s by default), and requests are
+//     canceled when their Context is.
+//
+//   - When ctx is done, the client's idle connections are closed.  Requests made with the client
+//     after that still work (they use their own Contexts), but should be avoided.
+//
+// Use ClientOptions to adjust the defaults.
+func NewClient(ctx context.Context, opts ...ClientOption) *http.Client {
+	cfg := clientConfig{
+		maxIdleConns:    100,
+		idleConnTimeout: 90 * time.Second,
+		dialTimeout:     30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   cfg.dialTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSClientConfig:       cfg.tlsConfig,
+		MaxIdleConns:          cfg.maxIdleConns,
+		MaxIdleConnsPerHost:   cfg.maxIdleConns,
+		IdleConnTimeout:       cfg.idleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	if cfg.transport != nil {
+		cfg.transport(transport)
+	}
+
+	if done := ctx.Done(); done != nil {
+		go func() {
+			<-done
+			transport.CloseIdleConnections()
+		}()
+	}
+
+	return &http.Client{
+		Transport: NewLoggingRoundTripper(transport),
+		Timeout:   cfg.timeout,
+	}
+}
@@ -0,0 +1,96 @@
+package dhttp_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestNewClient(t *testing.T) {
+	var gotRequestID string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotRequestID = r.Header.Get("X-Request-Id")
+		_, _ = io.WriteString(w, "hello")
+	}))
+	defer srv.Close()
+
+	var logOutput bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&logOutput)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	ctx, cancel := context.WithCancel(dlog.WithLogger(context.Background(), dlog.WrapLogrus(logger)))
+	defer cancel()
+
+	client := dhttp.NewClient(ctx, dhttp.WithClientMaxIdleConns(2))
+
+	reqCtx := dhttp.MarkFieldForPropagation(ctx, "request_id", "X-Request-Id")
+	reqCtx = dlog.WithField(reqCtx, "request_id", "abc123")
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, srv.URL+"/path", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	resp, err := client.Do(req)
+	if !assert.NoError(t, err) {
+		return
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(body))
+	assert.Equal(t, "abc123", gotRequestID)
+
+	var entry map[string]interface{}
+	if !assert.NoError(t, json.Unmarshal(logOutput.Bytes(), &entry)) {
+		return
+	}
+	assert.Equal(t, "info", entry["level"])
+	assert.Equal(t, "GET", entry["dhttp.method"])
+	assert.Equal(t, srv.URL+"/path", entry["dhttp.url"])
+	assert.Equal(t, float64(http.StatusOK), entry["dhttp.status"])
+	assert.Equal(t, "abc123", entry["request_id"])
+}
+
+func TestNewClientTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	ctx := dlog.NewTestContext(t, false)
+	client := dhttp.NewClient(ctx, dhttp.WithClientTimeout(100*time.Millisecond))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	start := time.Now()
+	_, err = client.Do(req)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	// The request's Context deadline is also honored.
+	client = dhttp.NewClient(ctx)
+	reqCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	req, err = http.NewRequestWithContext(reqCtx, http.MethodGet, srv.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = client.Do(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,95 @@
+package dhttp_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestConnLimit(t *testing.T) {
+	ctx, hardCancel := context.WithCancel(dlog.NewTestContext(t, true))
+	defer hardCancel()
+	ctx, softCancel := context.WithCancel(dcontext.WithSoftness(ctx))
+	defer softCancel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	sc := &dhttp.ServerConfig{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, "hello world")
+		}),
+		ConnLimit:    1,
+		DisableHTTP2: true,
+	}
+	serverExited := make(chan struct{})
+	go func() {
+		defer close(serverExited)
+		assert.NoError(t, sc.Serve(ctx, ln))
+	}()
+
+	doRequest := func(conn net.Conn, timeout time.Duration) (*http.Response, error) {
+		req, _ := http.NewRequest(http.MethodGet, "http://"+ln.Addr().String()+"/", nil)
+		if err := req.Write(conn); err != nil {
+			return nil, err
+		}
+		if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+			return nil, err
+		}
+		resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+		if err != nil {
+			return nil, err
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return resp, nil
+	}
+
+	// The 1st connection gets accepted and served, and then is kept open.
+	conn1, err := net.Dial("tcp", ln.Addr().String())
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn1.Close()
+	resp, err := doRequest(conn1, 10*time.Second)
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	// The 2nd connection is held pending...
+	conn2, err := net.Dial("tcp", ln.Addr().String())
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn2.Close()
+	_, err = doRequest(conn2, time.Second/4)
+	var netErr net.Error
+	if assert.ErrorAs(t, err, &netErr) {
+		assert.True(t, netErr.Timeout())
+	}
+
+	// ... until the 1st connection is closed.
+	assert.NoError(t, conn1.Close())
+	if assert.NoError(t, conn2.SetReadDeadline(time.Now().Add(10*time.Second))) {
+		resp, err := http.ReadResponse(bufio.NewReader(conn2), nil)
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			resp.Body.Close()
+		}
+	}
+	assert.NoError(t, conn2.Close())
+
+	softCancel()
+	<-serverExited
+}
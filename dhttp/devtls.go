@@ -0,0 +1,113 @@
+package dhttp
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+// generateDevCert generates a self-signed certificate for "localhost" and "127.0.0.1", for use by
+// ServeTLSDev.
+func generateDevCert() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}, nil
+}
+
+// certFingerprint returns the SHA-256 fingerprint of a DER-encoded certificate, formatted as
+// colon-separated hex bytes (the same format as `openssl x509 -fingerprint -sha256`).
+func certFingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+// ServeTLSDev is like ServeTLS, but rather than taking a certificate and key, it generates a fresh
+// in-memory self-signed certificate for "localhost" and "127.0.0.1".  This is intended for local
+// development, where managing a real certificate is more trouble than it is worth; clients will
+// need to skip verification or pin the certificate.  The certificate's SHA-256 fingerprint is
+// logged at Info level, so that it may be pinned.
+//
+// A new certificate is generated each time ServeTLSDev is called; it is never written to disk.
+// The generated certificate replaces any TLSConfig.Certificates, but if TLSConfig.GetCertificate
+// or TLSCertRefresh is set then that takes precedence.
+//
+// ServeTLSDev always closes the Listener before returning.
+func (sc *ServerConfig) ServeTLSDev(ctx context.Context, ln net.Listener) error {
+	defer ln.Close()
+
+	cert, err := generateDevCert()
+	if err != nil {
+		return fmt.Errorf("dhttp: generating self-signed certificate: %w", err)
+	}
+	dlog.Infof(ctx, "using self-signed development certificate for localhost and 127.0.0.1; SHA-256 fingerprint: %s",
+		certFingerprint(cert.Certificate[0]))
+
+	ln = sc.limitListener(ln)
+	return sc.serve(ctx, func(srv *http.Server) error {
+		srv.TLSConfig = srv.TLSConfig.Clone()
+		if srv.TLSConfig == nil {
+			srv.TLSConfig = new(tls.Config)
+		}
+		srv.TLSConfig.Certificates = []tls.Certificate{cert}
+		return srv.ServeTLS(ln, "", "")
+	})
+}
+
+// ListenAndServeTLSDev is like ServeTLSDev, but rather than taking an existing Listener object, it
+// takes a TCP address to listen on.  If an empty address is given, then ":https" is used.
+func (sc *ServerConfig) ListenAndServeTLSDev(ctx context.Context, addr string) error {
+	if addr == "" {
+		addr = ":https"
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	// As with ListenAndServeTLS, ServeTLSDev takes care of closing ln.
+
+	return sc.ServeTLSDev(ctx, ln)
+}
@@ -0,0 +1,71 @@
+package dhttp_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestServeTLSDev(t *testing.T) {
+	var log strings.Builder
+	ctx, hardCancel := context.WithCancel(dlog.NewTestContextWithOpts(t, dlog.WithMirrorWriter(&log)))
+	defer hardCancel()
+	ctx, softCancel := context.WithCancel(dcontext.WithSoftness(ctx))
+	defer softCancel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	sc := &dhttp.ServerConfig{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, "Hello, TLS!")
+		}),
+	}
+	serverExited := make(chan struct{})
+	go func() {
+		defer close(serverExited)
+		assert.NoError(t, sc.ServeTLSDev(ctx, ln))
+	}()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			DisableKeepAlives: true,
+		},
+	}
+	var fingerprint string
+	resp, err := client.Get("https://" + ln.Addr().String() + "/")
+	if assert.NoError(t, err) {
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.NoError(t, err)
+		assert.Equal(t, "Hello, TLS!", string(body))
+
+		if assert.Len(t, resp.TLS.PeerCertificates, 1) {
+			cert := resp.TLS.PeerCertificates[0]
+			assert.NoError(t, cert.VerifyHostname("localhost"))
+			assert.NoError(t, cert.VerifyHostname("127.0.0.1"))
+			sum := sha256.Sum256(cert.Raw)
+			fingerprint = strings.ReplaceAll(fmt.Sprintf("% X", sum[:]), " ", ":")
+		}
+	}
+
+	softCancel()
+	<-serverExited
+	if fingerprint != "" {
+		assert.Contains(t, log.String(), "SHA-256 fingerprint: "+fingerprint)
+	}
+}
@@ -0,0 +1,215 @@
+package dhttp
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// gzipMinSize is the minimum response size to bother compressing; a response smaller than this
+// fits in a single packet on a typical network (MTU), so compressing it wouldn't save anything.
+const gzipMinSize = 1400
+
+// isCompressibleContentType returns whether it is worth trying to compress a response with the
+// given Content-Type.
+func isCompressibleContentType(contentType string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	switch {
+	case mediaType == "image/svg+xml":
+		return true
+	case strings.HasPrefix(mediaType, "image/"),
+		strings.HasPrefix(mediaType, "video/"),
+		strings.HasPrefix(mediaType, "audio/"):
+		return false
+	}
+	switch mediaType {
+	case "application/gzip",
+		"application/x-gzip",
+		"application/zip",
+		"application/zstd",
+		"application/x-bzip2",
+		"application/x-xz",
+		"application/x-7z-compressed",
+		"application/x-rar-compressed":
+		return false
+	}
+	return true
+}
+
+// acceptsGzip returns whether the request's Accept-Encoding header includes gzip with a non-zero
+// q-value.
+func acceptsGzip(r *http.Request) bool {
+	for _, header := range r.Header.Values("Accept-Encoding") {
+		for _, coding := range strings.Split(header, ",") {
+			parts := strings.Split(coding, ";")
+			if !strings.EqualFold(strings.TrimSpace(parts[0]), "gzip") {
+				continue
+			}
+			for _, param := range parts[1:] {
+				name, value, _ := strings.Cut(param, "=")
+				if !strings.EqualFold(strings.TrimSpace(name), "q") {
+					continue
+				}
+				q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+				if err != nil || q <= 0 {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// gzipWriter wraps an http.ResponseWriter to gzip the response.  It buffers the beginning of the
+// response in order to decide whether compression is worthwhile.
+type gzipWriter struct {
+	http.ResponseWriter
+	level int
+
+	status   int
+	buf      []byte
+	decided  bool
+	gz       *gzip.Writer
+	hijacked bool
+}
+
+func (w *gzipWriter) WriteHeader(status int) {
+	if w.decided || w.status != 0 {
+		return
+	}
+	w.status = status
+	if status < 200 || status == http.StatusNoContent || status == http.StatusNotModified {
+		// No body, so nothing to compress.
+		w.decide(false)
+	}
+}
+
+func (w *gzipWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, p...)
+		if len(w.buf) < gzipMinSize {
+			return len(p), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if w.gz != nil {
+		return w.gz.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// decide sends the response headers, deciding whether to compress the response, and writes any
+// buffered data.
+func (w *gzipWriter) decide(compress bool) error {
+	w.decided = true
+	header := w.Header()
+	if len(w.buf) > 0 && header.Get("Content-Type") == "" {
+		header.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	if header.Get("Content-Encoding") != "" || !isCompressibleContentType(header.Get("Content-Type")) {
+		compress = false
+	}
+	if compress {
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", "gzip")
+		w.gz, _ = gzip.NewWriterLevel(w.ResponseWriter, w.level) // level was validated by WithGzip
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.gz != nil {
+		_, err = w.gz.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+func (w *gzipWriter) Flush() {
+	if !w.decided {
+		// We don't know how large the response will end up being; assume that it's a
+		// streaming response that's worth compressing.
+		_ = w.decide(true)
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *gzipWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// close finishes the response after the Handler has returned.
+func (w *gzipWriter) close() {
+	if w.hijacked {
+		// The Handler has taken over the connection.
+		return
+	}
+	if !w.decided {
+		if w.status == 0 && len(w.buf) == 0 {
+			// The Handler didn't write anything; leave it to net/http.
+			return
+		}
+		// The whole response is smaller than gzipMinSize.
+		_ = w.decide(false)
+	}
+	if w.gz != nil {
+		_ = w.gz.Close()
+	}
+}
+
+// Unwrap allows http.ResponseController to access the underlying ResponseWriter.
+func (w *gzipWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// WithGzip wraps a Handler such that responses are transparently compressed with gzip (at the
+// given compression level; see compress/gzip) when the client sends "Accept-Encoding: gzip".
+// Responses smaller than 1400 bytes (which would fit in a single packet anyway), responses that
+// already have a Content-Encoding, and responses with a Content-Type that is generally already
+// compressed (images, audio, video, and archives) are not compressed.
+//
+// WithGzip panics if the level is not a valid gzip compression level.
+func WithGzip(next http.Handler, level int) http.Handler {
+	if _, err := gzip.NewWriterLevel(io.Discard, level); err != nil {
+		panic(err)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Method == http.MethodHead || !acceptsGzip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		gw := &gzipWriter{ResponseWriter: w, level: level}
+		defer gw.close()
+		next.ServeHTTP(gw, r)
+	})
+}
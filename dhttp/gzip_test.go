@@ -0,0 +1,117 @@
+package dhttp_test
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dhttp"
+)
+
+func TestWithGzip(t *testing.T) {
+	bigBody := strings.Repeat("hello world\n", 1000)
+	testcases := map[string]struct {
+		AcceptEncoding string
+		ContentType    string
+		Body           string
+		Compressed     bool
+	}{
+		"big":          {"gzip, deflate", "", bigBody, true},
+		"big-no-gzip":  {"deflate", "", bigBody, false},
+		"big-q0":       {"gzip;q=0", "", bigBody, false},
+		"big-q0.0":     {"gzip; q=0.0", "", bigBody, false},
+		"big-q0.000":   {"deflate, gzip;q=0.000", "", bigBody, false},
+		"big-q0.5":     {"gzip;q=0.5", "", bigBody, true},
+		"big-q1":       {"gzip; q=1", "", bigBody, true},
+		"small":        {"gzip", "", "hello world", false},
+		"big-image":    {"gzip", "image/png", bigBody, false},
+		"big-svg":      {"gzip", "image/svg+xml", bigBody, true},
+		"big-archive":  {"gzip", "application/zip", bigBody, false},
+		"empty":        {"gzip", "", "", false},
+		"big-explicit": {"gzip", "text/plain; charset=utf-8", bigBody, true},
+	}
+	for tcName, tcData := range testcases {
+		tcData := tcData
+		t.Run(tcName, func(t *testing.T) {
+			handler := dhttp.WithGzip(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tcData.ContentType != "" {
+					w.Header().Set("Content-Type", tcData.ContentType)
+				}
+				// Write in several chunks, to make sure that buffering works.
+				for body := tcData.Body; body != ""; {
+					n := 100
+					if n > len(body) {
+						n = len(body)
+					}
+					_, _ = io.WriteString(w, body[:n])
+					body = body[n:]
+				}
+			}), gzip.BestSpeed)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", tcData.AcceptEncoding)
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusOK, resp.Code)
+			assert.Equal(t, "Accept-Encoding", resp.Header().Get("Vary"))
+			var body []byte
+			if tcData.Compressed {
+				assert.Equal(t, "gzip", resp.Header().Get("Content-Encoding"))
+				assert.Less(t, resp.Body.Len(), len(tcData.Body))
+				gz, err := gzip.NewReader(resp.Body)
+				if !assert.NoError(t, err) {
+					return
+				}
+				body, err = io.ReadAll(gz)
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, "", resp.Header().Get("Content-Encoding"))
+				body = resp.Body.Bytes()
+			}
+			assert.Equal(t, tcData.Body, string(body))
+		})
+	}
+}
+
+func TestWithGzipInvalidLevel(t *testing.T) {
+	assert.Panics(t, func() {
+		dhttp.WithGzip(http.NotFoundHandler(), 100)
+	})
+}
+
+func TestWithGzipHijack(t *testing.T) {
+	srv := httptest.NewServer(dhttp.WithGzip(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hijacker, ok := w.(http.Hijacker)
+		if !assert.True(t, ok, "ResponseWriter is not an http.Hijacker") {
+			return
+		}
+		conn, rw, err := hijacker.Hijack()
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+		_, _ = rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		_ = rw.Flush()
+	}), gzip.BestSpeed))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "hijacked", string(body))
+}
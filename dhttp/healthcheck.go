@@ -0,0 +1,55 @@
+package dhttp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// withHealthChecks wraps a Handler to serve sc.LivenessCheck and sc.ReadinessCheck.  The softCtx
+// is the Context passed to Serve; once it is Done, the readiness endpoint reports that the server
+// is not ready.
+func (sc *ServerConfig) withHealthChecks(softCtx context.Context, next http.Handler) http.Handler {
+	if sc.LivenessCheck == nil && sc.ReadinessCheck == nil {
+		return next
+	}
+	livenessCheck := sc.LivenessCheck
+	livenessPath := sc.LivenessPath
+	if livenessPath == "" {
+		livenessPath = "/healthz"
+	}
+	readinessCheck := sc.ReadinessCheck
+	readinessPath := sc.ReadinessPath
+	if readinessPath == "" {
+		readinessPath = "/readyz"
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case livenessCheck != nil && r.URL.Path == livenessPath:
+			serveHealthCheck(w, livenessCheck(r.Context()))
+		case readinessCheck != nil && r.URL.Path == readinessPath:
+			if softCtx.Err() != nil {
+				serveHealthCheck(w, errShuttingDown)
+				return
+			}
+			serveHealthCheck(w, readinessCheck(r.Context()))
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+var errShuttingDown = errors.New("shutting down")
+
+func serveHealthCheck(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = io.WriteString(w, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.WriteString(w, "ok")
+}
@@ -0,0 +1,95 @@
+package dhttp_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestHealthChecks(t *testing.T) {
+	ctx, hardCancel := context.WithCancel(dlog.NewTestContext(t, true))
+	defer hardCancel()
+	ctx, softCancel := context.WithCancel(dcontext.WithSoftness(ctx))
+	defer softCancel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	var ready atomic.Bool
+	sc := &dhttp.ServerConfig{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, "main")
+		}),
+		LivenessCheck: func(context.Context) error { return nil },
+		ReadinessCheck: func(context.Context) error {
+			if !ready.Load() {
+				return errors.New("warming up")
+			}
+			return nil
+		},
+		ReadinessPath: "/ready",
+		ShutdownDelay: time.Second,
+	}
+	serverExited := make(chan struct{})
+	go func() {
+		defer close(serverExited)
+		assert.NoError(t, sc.Serve(ctx, ln))
+	}()
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	get := func(path string) (int, string) {
+		t.Helper()
+		resp, err := client.Get("http://" + ln.Addr().String() + path)
+		if !assert.NoError(t, err) {
+			return 0, ""
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	code, body := get("/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", body)
+
+	code, body = get("/ready")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "warming up", body)
+
+	ready.Store(true)
+	code, _ = get("/ready")
+	assert.Equal(t, http.StatusOK, code)
+
+	// The default readiness path isn't used when ReadinessPath is set.
+	code, body = get("/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "main", body)
+
+	// During soft shutdown, readiness fails even though ReadinessCheck would succeed; but
+	// everything else keeps working.
+	softCancel()
+	time.Sleep(100 * time.Millisecond)
+	code, body = get("/ready")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "shutting down", body)
+	code, _ = get("/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	code, body = get("/")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "main", body)
+
+	<-serverExited
+}
@@ -0,0 +1,65 @@
+package dhttp
+
+import (
+	"golang.org/x/net/http2"
+)
+
+// HTTP2Options is the subset of the HTTP/2 server configuration that most users will actually
+// want to tune.  A zero value for any of the fields means to use the golang.org/x/net/http2
+// default for that setting.
+type HTTP2Options struct {
+	// MaxConcurrentStreams is the number of concurrent streams (requests) that each client
+	// connection may have open at once; this is advertised to the client as
+	// SETTINGS_MAX_CONCURRENT_STREAMS, and streams beyond it are refused.  The
+	// golang.org/x/net/http2 default is 250.
+	MaxConcurrentStreams uint32
+
+	// InitialWindowSize is the flow-control window that is advertised for each stream; it is
+	// the number of bytes of request body that the client may send on a stream before the
+	// Handler reads them.  It is advertised to the client as SETTINGS_INITIAL_WINDOW_SIZE.  The
+	// golang.org/x/net/http2 default is 1MiB.
+	InitialWindowSize int32
+
+	// MaxFrameSize is the largest frame that the server is willing to read; it is advertised
+	// to the client as SETTINGS_MAX_FRAME_SIZE, and must be between 16KiB and 16MiB.  The
+	// golang.org/x/net/http2 default is 1MiB.
+	MaxFrameSize uint32
+}
+
+// NewHTTP2Options returns HTTP2Options with values that are reasonable for a production server.
+// Compared to the golang.org/x/net/http2 defaults, this allows fewer concurrent streams per
+// connection (100, which is the minimum that RFC 7540 recommends), limiting how much work a
+// single client can pile on to the server.
+func NewHTTP2Options() *HTTP2Options {
+	return &HTTP2Options{
+		MaxConcurrentStreams: 100,
+		InitialWindowSize:    1 << 20,
+		MaxFrameSize:         1 << 20,
+	}
+}
+
+// http2Config returns the *http2.Server to pass to configureHTTP2; it is a copy of the
+// (deprecated) HTTP2Config, with any non-zero HTTP2Options applied on top of it.
+func (sc *ServerConfig) http2Config() *http2.Server {
+	var cfg *http2.Server
+	if sc.HTTP2Config != nil {
+		// shallow copy (there's nothing deep inside of it)
+		_cfg := *sc.HTTP2Config
+		cfg = &_cfg
+	}
+	if opts := sc.HTTP2Options; opts != nil {
+		if cfg == nil {
+			cfg = new(http2.Server)
+		}
+		if opts.MaxConcurrentStreams != 0 {
+			cfg.MaxConcurrentStreams = opts.MaxConcurrentStreams
+		}
+		if opts.InitialWindowSize != 0 {
+			cfg.MaxUploadBufferPerStream = opts.InitialWindowSize
+		}
+		if opts.MaxFrameSize != 0 {
+			cfg.MaxReadFrameSize = opts.MaxFrameSize
+		}
+	}
+	return cfg
+}
@@ -0,0 +1,147 @@
+package dhttp_test
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestHTTP2OptionsMaxConcurrentStreams(t *testing.T) {
+	ctx, hardCancel := context.WithCancel(dlog.NewTestContext(t, true))
+	defer hardCancel()
+	ctx, softCancel := context.WithCancel(dcontext.WithSoftness(ctx))
+	defer softCancel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	handlerStarted := make(chan struct{})
+	handlerRelease := make(chan struct{})
+	sc := &dhttp.ServerConfig{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlerStarted <- struct{}{}
+			<-handlerRelease
+			w.WriteHeader(http.StatusOK)
+		}),
+		HTTP2Options: &dhttp.HTTP2Options{
+			MaxConcurrentStreams: 1,
+		},
+	}
+	serverExited := make(chan struct{})
+	go func() {
+		defer close(serverExited)
+		assert.NoError(t, sc.Serve(ctx, ln))
+	}()
+	defer func() {
+		softCancel()
+		<-serverExited
+	}()
+
+	// Speak h2c "with prior knowledge" by hand, since http2.Transport would (correctly) refuse
+	// to exceed the server's advertised limit.
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	if _, err := conn.Write([]byte(http2.ClientPreface)); !assert.NoError(t, err) {
+		return
+	}
+	framer := http2.NewFramer(conn, conn)
+	if !assert.NoError(t, framer.WriteSettings()) {
+		return
+	}
+
+	// Check that the server advertises the limit.
+	for {
+		frame, err := framer.ReadFrame()
+		if !assert.NoError(t, err) {
+			return
+		}
+		settings, ok := frame.(*http2.SettingsFrame)
+		if !ok || settings.IsAck() {
+			continue
+		}
+		val, ok := settings.Value(http2.SettingMaxConcurrentStreams)
+		assert.True(t, ok)
+		assert.Equal(t, uint32(1), val)
+		if !assert.NoError(t, framer.WriteSettingsAck()) {
+			return
+		}
+		break
+	}
+
+	writeRequest := func(streamID uint32) error {
+		var hdrs bytes.Buffer
+		enc := hpack.NewEncoder(&hdrs)
+		_ = enc.WriteField(hpack.HeaderField{Name: ":method", Value: "GET"})
+		_ = enc.WriteField(hpack.HeaderField{Name: ":scheme", Value: "http"})
+		_ = enc.WriteField(hpack.HeaderField{Name: ":authority", Value: ln.Addr().String()})
+		_ = enc.WriteField(hpack.HeaderField{Name: ":path", Value: "/"})
+		return framer.WriteHeaders(http2.HeadersFrameParam{
+			StreamID:      streamID,
+			BlockFragment: hdrs.Bytes(),
+			EndStream:     true,
+			EndHeaders:    true,
+		})
+	}
+
+	// The 1st stream is accepted and held open by the Handler.
+	if !assert.NoError(t, writeRequest(1)) {
+		return
+	}
+	<-handlerStarted
+
+	// The 2nd concurrent stream is refused.
+	if !assert.NoError(t, writeRequest(3)) {
+		return
+	}
+	for {
+		frame, err := framer.ReadFrame()
+		if !assert.NoError(t, err) {
+			return
+		}
+		if rst, ok := frame.(*http2.RSTStreamFrame); ok {
+			assert.Equal(t, uint32(3), rst.StreamID)
+			break
+		}
+	}
+
+	// The 1st stream is still served.
+	close(handlerRelease)
+	for {
+		frame, err := framer.ReadFrame()
+		if !assert.NoError(t, err) {
+			return
+		}
+		if headers, ok := frame.(*http2.HeadersFrame); ok {
+			assert.Equal(t, uint32(1), headers.StreamID)
+			break
+		}
+		if rst, ok := frame.(*http2.RSTStreamFrame); ok {
+			t.Errorf("unexpected reset of stream %d", rst.StreamID)
+			return
+		}
+	}
+}
+
+func TestNewHTTP2Options(t *testing.T) {
+	opts := dhttp.NewHTTP2Options()
+	assert.NotZero(t, opts.MaxConcurrentStreams)
+	assert.NotZero(t, opts.InitialWindowSize)
+	assert.GreaterOrEqual(t, opts.MaxFrameSize, uint32(16<<10))
+	assert.LessOrEqual(t, opts.MaxFrameSize, uint32(16<<20))
+}
@@ -0,0 +1,92 @@
+package dhttp
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+// statusRecorder wraps an http.ResponseWriter to record the status code and the number of body
+// bytes written.
+type statusRecorder struct {
+	http.ResponseWriter
+	status       int
+	bytesWritten int64
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytesWritten += int64(n)
+	return n, err
+}
+
+func (w *statusRecorder) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		flusher.Flush()
+	}
+}
+
+func (w *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	return hijacker.Hijack()
+}
+
+// Unwrap allows http.ResponseController to access the underlying ResponseWriter.
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// WithLogging wraps a Handler such that each request is logged (using dlog with the request's
+// Context) after it completes, with the fields "dhttp.method", "dhttp.path", "dhttp.status",
+// "dhttp.latency", "dhttp.bytes_written", and "dhttp.remote_addr".  Requests are logged at
+// LogLevelInfo, except that 4xx responses are logged at LogLevelWarn and 5xx responses are logged
+// at LogLevelError.
+func WithLogging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		latency := time.Since(start)
+
+		status := rec.status
+		if status == 0 {
+			// The handler didn't write anything; net/http will send a 200.
+			status = http.StatusOK
+		}
+		level := dlog.LogLevelInfo
+		switch {
+		case status >= 500:
+			level = dlog.LogLevelError
+		case status >= 400:
+			level = dlog.LogLevelWarn
+		}
+
+		ctx := r.Context()
+		ctx = dlog.WithField(ctx, "dhttp.method", r.Method)
+		ctx = dlog.WithField(ctx, "dhttp.path", r.URL.Path)
+		ctx = dlog.WithField(ctx, "dhttp.status", status)
+		ctx = dlog.WithField(ctx, "dhttp.latency", latency)
+		ctx = dlog.WithField(ctx, "dhttp.bytes_written", rec.bytesWritten)
+		ctx = dlog.WithField(ctx, "dhttp.remote_addr", r.RemoteAddr)
+		dlog.Logf(ctx, level, "%s %s %d", r.Method, r.URL.Path, status)
+	})
+}
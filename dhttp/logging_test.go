@@ -0,0 +1,78 @@
+package dhttp_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestWithLogging(t *testing.T) {
+	testcases := map[string]struct {
+		Status        int
+		ExpectedLevel string
+	}{
+		"200": {http.StatusOK, "info"},
+		"404": {http.StatusNotFound, "warning"},
+		"503": {http.StatusServiceUnavailable, "error"},
+	}
+	for tcName, tcData := range testcases {
+		tcData := tcData
+		t.Run(tcName, func(t *testing.T) {
+			var logOutput bytes.Buffer
+			logger := logrus.New()
+			logger.SetOutput(&logOutput)
+			logger.SetFormatter(&logrus.JSONFormatter{})
+			ctx := dlog.WithLogger(context.Background(), dlog.WrapLogrus(logger))
+
+			handler := dhttp.WithLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tcData.Status)
+				_, _ = io.WriteString(w, "hello")
+			}))
+			req := httptest.NewRequest(http.MethodPost, "/some/path", nil).WithContext(ctx)
+			req.RemoteAddr = "192.0.2.1:1234"
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			var entry map[string]interface{}
+			if !assert.NoError(t, json.Unmarshal(logOutput.Bytes(), &entry)) {
+				return
+			}
+			assert.Equal(t, tcData.ExpectedLevel, entry["level"])
+			assert.Equal(t, "POST /some/path "+tcName, entry["msg"])
+			assert.Equal(t, "POST", entry["dhttp.method"])
+			assert.Equal(t, "/some/path", entry["dhttp.path"])
+			assert.Equal(t, float64(tcData.Status), entry["dhttp.status"])
+			assert.Contains(t, entry, "dhttp.latency")
+			assert.Equal(t, float64(5), entry["dhttp.bytes_written"])
+			assert.Equal(t, "192.0.2.1:1234", entry["dhttp.remote_addr"])
+		})
+	}
+}
+
+func TestWithLoggingImplicitStatus(t *testing.T) {
+	var logOutput bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&logOutput)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	ctx := dlog.WithLogger(context.Background(), dlog.WrapLogrus(logger))
+
+	handler := dhttp.WithLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry map[string]interface{}
+	if assert.NoError(t, json.Unmarshal(logOutput.Bytes(), &entry)) {
+		assert.Equal(t, "info", entry["level"])
+		assert.Equal(t, float64(http.StatusOK), entry["dhttp.status"])
+		assert.Equal(t, float64(0), entry["dhttp.bytes_written"])
+	}
+}
@@ -0,0 +1,60 @@
+package dhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// writeRequestTooLarge writes a "413 Request Entity Too Large" response with a JSON body.
+func writeRequestTooLarge(w http.ResponseWriter, limit int64) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Connection", "close")
+	w.WriteHeader(http.StatusRequestEntityTooLarge)
+	_ = json.NewEncoder(w).Encode(struct {
+		Error    string `json:"error"`
+		MaxBytes int64  `json:"max_bytes"`
+	}{
+		Error:    http.StatusText(http.StatusRequestEntityTooLarge),
+		MaxBytes: limit,
+	})
+}
+
+// withMaxRequestBodySize wraps a Handler to apply sc.MaxRequestBodySize to each request.
+func (sc *ServerConfig) withMaxRequestBodySize(next http.Handler) http.Handler {
+	limit := sc.MaxRequestBodySize
+	if limit <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.ContentLength > limit:
+			writeRequestTooLarge(w, limit)
+			return
+		case r.ContentLength < 0:
+			// The length isn't known up front (a chunked request), so we have to read the
+			// body in order to know whether it fits; buffer it so that the Handler never
+			// sees part of an oversized body.
+			body, err := io.ReadAll(&io.LimitedReader{R: r.Body, N: limit + 1})
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+			if int64(len(body)) > limit {
+				writeRequestTooLarge(w, limit)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		default:
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{
+				Reader: &io.LimitedReader{R: r.Body, N: limit},
+				Closer: r.Body,
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
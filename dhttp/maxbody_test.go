@@ -0,0 +1,97 @@
+package dhttp_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestMaxRequestBodySize(t *testing.T) {
+	const limit = 16
+	testcases := map[string]struct {
+		Size    int
+		Chunked bool
+		Allowed bool
+	}{
+		"under":          {Size: limit - 1, Allowed: true},
+		"at-limit":       {Size: limit, Allowed: true},
+		"over":           {Size: limit + 1, Allowed: false},
+		"chunked-at":     {Size: limit, Chunked: true, Allowed: true},
+		"chunked-over":   {Size: 4 * limit, Chunked: true, Allowed: false},
+		"chunked-under":  {Size: 1, Chunked: true, Allowed: true},
+		"chunked-empty":  {Size: 0, Chunked: true, Allowed: true},
+		"way-over-limit": {Size: 1024 * limit, Allowed: false},
+	}
+	for tcName, tcData := range testcases {
+		tcData := tcData
+		t.Run(tcName, func(t *testing.T) {
+			httpScenarios(t, func(t *testing.T, url string, client *http.Client, server func(context.Context, *dhttp.ServerConfig) error) {
+				ctx, hardCancel := context.WithCancel(dlog.NewTestContext(t, false))
+				defer hardCancel()
+				ctx, softCancel := context.WithCancel(dcontext.WithSoftness(ctx))
+				defer softCancel()
+
+				handlerCalled := false
+				sc := &dhttp.ServerConfig{
+					Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						handlerCalled = true
+						body, err := io.ReadAll(r.Body)
+						if err != nil {
+							w.WriteHeader(http.StatusInternalServerError)
+							return
+						}
+						_, _ = w.Write(body)
+					}),
+					MaxRequestBodySize: limit,
+				}
+				serverExited := make(chan struct{})
+				go func() {
+					defer close(serverExited)
+					assert.NoError(t, server(ctx, sc))
+				}()
+
+				reqBody := strings.Repeat("x", tcData.Size)
+				var bodyReader io.Reader = strings.NewReader(reqBody)
+				if tcData.Chunked {
+					// Hide the length from net/http.
+					bodyReader = io.MultiReader(bodyReader)
+				}
+				req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bodyReader)
+				if !assert.NoError(t, err) {
+					return
+				}
+				resp, err := client.Do(req)
+				if assert.NoError(t, err) {
+					body, err := io.ReadAll(resp.Body)
+					resp.Body.Close()
+					assert.NoError(t, err)
+					if tcData.Allowed {
+						assert.Equal(t, http.StatusOK, resp.StatusCode)
+						assert.Equal(t, reqBody, string(body))
+						assert.True(t, handlerCalled)
+					} else {
+						assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+						assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+						var errBody map[string]interface{}
+						if assert.NoError(t, json.Unmarshal(body, &errBody)) {
+							assert.Equal(t, float64(limit), errBody["max_bytes"])
+						}
+						assert.False(t, handlerCalled)
+					}
+				}
+
+				softCancel()
+				<-serverExited
+			})
+		})
+	}
+}
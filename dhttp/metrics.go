@@ -0,0 +1,23 @@
+package dhttp
+
+import (
+	"net/http"
+)
+
+// metricsPath is the path that sc.MetricsHandler is served at.
+const metricsPath = "/metrics"
+
+// withMetrics wraps a Handler to route requests for metricsPath to sc.MetricsHandler.
+func (sc *ServerConfig) withMetrics(next http.Handler) http.Handler {
+	metrics := sc.MetricsHandler
+	if metrics == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == metricsPath {
+			metrics.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
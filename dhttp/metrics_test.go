@@ -0,0 +1,75 @@
+package dhttp_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	httpScenarios(t, func(t *testing.T, url string, client *http.Client, server func(context.Context, *dhttp.ServerConfig) error) {
+		ctx, hardCancel := context.WithCancel(dlog.NewTestContext(t, true))
+		defer hardCancel()
+		ctx, softCancel := context.WithCancel(dcontext.WithSoftness(ctx))
+		defer softCancel()
+
+		sc := &dhttp.ServerConfig{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.WriteString(w, "main "+r.URL.Path)
+			}),
+			MetricsHandler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.WriteString(w, "requests_total 1\n")
+			}),
+			OnRequest: func(w http.ResponseWriter, r *http.Request) *http.Request {
+				w.Header().Set("X-On-Request", "yes")
+				return r
+			},
+		}
+		serverExited := make(chan struct{})
+		go func() {
+			defer close(serverExited)
+			assert.NoError(t, server(ctx, sc))
+		}()
+		defer func() {
+			softCancel()
+			<-serverExited
+		}()
+
+		get := func(path string) (*http.Response, string, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+path, nil)
+			if err != nil {
+				return nil, "", err
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				return nil, "", err
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			return resp, string(body), err
+		}
+
+		resp, body, err := get("/metrics")
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "requests_total 1\n", body)
+			assert.Empty(t, resp.Header.Get("X-On-Request"), "/metrics should bypass OnRequest")
+		}
+
+		for _, path := range []string{"/", "/other", "/metrics/sub"} {
+			resp, body, err := get(path)
+			if assert.NoError(t, err) {
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				assert.Equal(t, "main "+path, body)
+				assert.Equal(t, "yes", resp.Header.Get("X-On-Request"))
+			}
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package dhttp
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+)
+
+type tlsConnContextKey struct{}
+
+// WithMTLS returns a function that configures a ServerConfig to require mutual TLS: clients must
+// present a certificate that verifies against caPool, or the handshake fails.  The verified client
+// certificate is made available to handlers via ClientCertFromContext.
+//
+// The ServerConfig's existing TLSConfig (if any) is cloned rather than modified in-place, and any
+// existing ConnContext is preserved.
+//
+//	sc := &dhttp.ServerConfig{Handler: h}
+//	dhttp.WithMTLS(caPool)(sc)
+func WithMTLS(caPool *x509.CertPool) func(*ServerConfig) {
+	return func(sc *ServerConfig) {
+		if sc.TLSConfig == nil {
+			sc.TLSConfig = &tls.Config{}
+		} else {
+			sc.TLSConfig = sc.TLSConfig.Clone()
+		}
+		sc.TLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		sc.TLSConfig.ClientCAs = caPool
+
+		sc.ConnContext = concatConnContext(
+			sc.ConnContext,
+			func(ctx context.Context, conn net.Conn) context.Context {
+				if tlsConn, ok := conn.(*tls.Conn); ok {
+					ctx = context.WithValue(ctx, tlsConnContextKey{}, tlsConn)
+				}
+				return ctx
+			},
+		)
+	}
+}
+
+// ClientCertFromContext returns the verified client certificate for the connection that a request
+// arrived on, if the server was configured with WithMTLS.  The ctx should be the request's Context
+// (or derived from it).
+func ClientCertFromContext(ctx context.Context) (*x509.Certificate, bool) {
+	tlsConn, ok := ctx.Value(tlsConnContextKey{}).(*tls.Conn)
+	if !ok {
+		return nil, false
+	}
+	// ConnContext is called before the TLS handshake, so we can't inspect the certificate
+	// there; but by the time a request is being handled, the handshake has completed.
+	state := tlsConn.ConnectionState()
+	if !state.HandshakeComplete {
+		return nil, false
+	}
+	if len(state.VerifiedChains) > 0 && len(state.VerifiedChains[0]) > 0 {
+		return state.VerifiedChains[0][0], true
+	}
+	return nil, false
+}
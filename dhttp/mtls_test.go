@@ -0,0 +1,164 @@
+package dhttp_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func genTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) issue(t *testing.T, serial int64, cn string, usage x509.ExtKeyUsage) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestMTLS(t *testing.T) {
+	ca := genTestCA(t)
+	caPool := x509.NewCertPool()
+	caPool.AddCert(ca.cert)
+	serverCert := ca.issue(t, 2, "server", x509.ExtKeyUsageServerAuth)
+	clientCert := ca.issue(t, 3, "client", x509.ExtKeyUsageClientAuth)
+
+	// Don't fail on error logs; the rejected handshake below gets logged.
+	ctx, hardCancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer hardCancel()
+	ctx, softCancel := context.WithCancel(dcontext.WithSoftness(ctx))
+	defer softCancel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	sc := &dhttp.ServerConfig{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cert, ok := dhttp.ClientCertFromContext(r.Context())
+			if !ok {
+				http.Error(w, "no client cert", http.StatusForbidden)
+				return
+			}
+			_, _ = io.WriteString(w, cert.Subject.CommonName)
+		}),
+		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{serverCert},
+		},
+	}
+	dhttp.WithMTLS(caPool)(sc)
+	serverExited := make(chan struct{})
+	go func() {
+		defer close(serverExited)
+		assert.NoError(t, sc.ServeTLS(ctx, ln, "", ""))
+	}()
+
+	newClient := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					RootCAs:      caPool,
+					Certificates: certs,
+				},
+				ForceAttemptHTTP2: true,
+				DisableKeepAlives: true,
+			},
+		}
+	}
+	url := "https://" + ln.Addr().String() + "/"
+
+	t.Run("withCert", func(t *testing.T) {
+		resp, err := newClient(clientCert).Get(url)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "client", string(body))
+	})
+	t.Run("withoutCert", func(t *testing.T) {
+		resp, err := newClient().Get(url)
+		if err == nil {
+			resp.Body.Close()
+		}
+		assert.Error(t, err)
+	})
+	t.Run("wrongCA", func(t *testing.T) {
+		otherCert := genTestCA(t).issue(t, 2, "intruder", x509.ExtKeyUsageClientAuth)
+		resp, err := newClient(otherCert).Get(url)
+		if err == nil {
+			resp.Body.Close()
+		}
+		assert.Error(t, err)
+	})
+
+	softCancel()
+	<-serverExited
+}
+
+func TestClientCertFromContextMissing(t *testing.T) {
+	cert, ok := dhttp.ClientCertFromContext(context.Background())
+	assert.False(t, ok)
+	assert.Nil(t, cert)
+}
@@ -0,0 +1,21 @@
+package dhttp
+
+import (
+	"net/http"
+)
+
+// withOnRequest wraps a Handler to call sc.OnRequest before each request.
+func (sc *ServerConfig) withOnRequest(next http.Handler) http.Handler {
+	onRequest := sc.OnRequest
+	if onRequest == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = onRequest(w, r)
+		if r == nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
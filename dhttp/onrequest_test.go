@@ -0,0 +1,74 @@
+package dhttp_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestOnRequest(t *testing.T) {
+	type enrichedKey struct{}
+	httpScenarios(t, func(t *testing.T, url string, client *http.Client, server func(context.Context, *dhttp.ServerConfig) error) {
+		ctx, hardCancel := context.WithCancel(dlog.NewTestContext(t, false))
+		defer hardCancel()
+		ctx, softCancel := context.WithCancel(dcontext.WithSoftness(ctx))
+		defer softCancel()
+
+		sc := &dhttp.ServerConfig{
+			OnRequest: func(w http.ResponseWriter, r *http.Request) *http.Request {
+				if r.URL.Path == "/reject" {
+					return nil
+				}
+				w.Header().Set("X-On-Request", "yes")
+				return r.WithContext(context.WithValue(r.Context(), enrichedKey{}, r.URL.Path))
+			},
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				val, _ := r.Context().Value(enrichedKey{}).(string)
+				_, _ = io.WriteString(w, "enriched="+val)
+			}),
+		}
+		serverExited := make(chan struct{})
+		go func() {
+			defer close(serverExited)
+			assert.NoError(t, server(ctx, sc))
+		}()
+		defer func() {
+			softCancel()
+			<-serverExited
+		}()
+
+		get := func(path string) (*http.Response, string, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+path, nil)
+			if err != nil {
+				return nil, "", err
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				return nil, "", err
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			return resp, string(body), err
+		}
+
+		resp, body, err := get("/path")
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "yes", resp.Header.Get("X-On-Request"))
+			assert.Equal(t, "enriched=/path", body)
+		}
+
+		resp, body, err = get("/reject")
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+			assert.NotContains(t, body, "enriched")
+		}
+	})
+}
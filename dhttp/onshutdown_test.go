@@ -0,0 +1,58 @@
+package dhttp_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestOnShutdownOrdered(t *testing.T) {
+	ctx, hardCancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer hardCancel()
+	ctx, softCancel := context.WithCancel(dcontext.WithSoftness(ctx))
+	defer softCancel()
+
+	var mu sync.Mutex
+	var calls []int
+	var wg sync.WaitGroup
+	hook := func(i int, err error) func(context.Context) error {
+		return func(ctx context.Context) error {
+			defer wg.Done()
+			mu.Lock()
+			calls = append(calls, i)
+			mu.Unlock()
+			return err
+		}
+	}
+	wg.Add(3)
+	sc := &dhttp.ServerConfig{
+		OnShutdownOrdered: []func(context.Context) error{
+			hook(0, nil),
+			hook(1, errors.New("oops")),
+			hook(2, nil),
+		},
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	serverExited := make(chan struct{})
+	go func() {
+		defer close(serverExited)
+		assert.NoError(t, sc.Serve(ctx, ln))
+	}()
+
+	softCancel()
+	wg.Wait()
+	<-serverExited
+	assert.Equal(t, []int{0, 1, 2}, calls)
+}
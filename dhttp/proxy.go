@@ -0,0 +1,39 @@
+package dhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+// NewProxyHandler returns an http.Handler that reverse-proxies requests to target, in the manner
+// of httputil.NewSingleHostReverseProxy, but integrated with dlog:
+//
+//   - Upstream requests are made through NewLoggingRoundTripper, so each one is logged with the
+//     incoming request's Context (at LogLevelInfo, or LogLevelWarn for 4xx responses, or
+//     LogLevelError for 5xx responses), and any dlog fields that have been marked with
+//     MarkFieldForPropagation are sent upstream as headers.
+//
+//   - Failures to reach the upstream are logged once, by NewLoggingRoundTripper (rather than to
+//     the log package's standard logger), and answered with "502 Bad Gateway".  Errors that happen after
+//     the response has started (such as the upstream hanging up partway through the body) have
+//     no request Context available to httputil.ReverseProxy, and so are logged with dlog's
+//     fallback logger.
+//
+// The upstream request uses the incoming request's Context, so it is canceled if the client goes
+// away.  When served by a ServerConfig, that Context is derived from the hard Context, so
+// in-progress proxied requests are allowed to finish during a soft shutdown, and are canceled by
+// a hard shutdown.
+func NewProxyHandler(target *url.URL) http.Handler {
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Transport = NewLoggingRoundTripper(nil)
+	proxy.ErrorLog = dlog.StdLogger(context.Background(), dlog.LogLevelError)
+	proxy.ErrorHandler = func(w http.ResponseWriter, _ *http.Request, _ error) {
+		// NewLoggingRoundTripper has already logged the error.
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	return proxy
+}
@@ -0,0 +1,97 @@
+package dhttp_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestProxyHandler(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			_, _ = io.WriteString(w, "trace="+r.Header.Get("X-Trace-Id"))
+		case "/missing":
+			http.NotFound(w, r)
+		default:
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}
+	}))
+	defer upstream.Close()
+	target, err := url.Parse(upstream.URL)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var logOutput bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&logOutput)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	baseCtx := dlog.WithLogger(context.Background(), dlog.WrapLogrus(logger))
+	baseCtx = dhttp.MarkFieldForPropagation(baseCtx, "trace_id", "X-Trace-Id")
+	baseCtx = dlog.WithField(baseCtx, "trace_id", "abc123")
+
+	proxy := dhttp.NewProxyHandler(target)
+	do := func(path string) (int, string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "http://proxy.example"+path, nil).WithContext(baseCtx)
+		rec := httptest.NewRecorder()
+		proxy.ServeHTTP(rec, req)
+		return rec.Code, rec.Body.String()
+	}
+
+	code, body := do("/ok")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "trace=abc123", body)
+
+	code, _ = do("/missing")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, _ = do("/broken")
+	assert.Equal(t, http.StatusInternalServerError, code)
+
+	var levels []string
+	scanner := bufio.NewScanner(&logOutput)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		if !assert.NoError(t, json.Unmarshal(scanner.Bytes(), &entry)) {
+			return
+		}
+		assert.Equal(t, "abc123", entry["trace_id"])
+		levels = append(levels, entry["level"].(string))
+	}
+	assert.Equal(t, []string{"info", "warning", "error"}, levels)
+}
+
+func TestProxyHandlerUnreachable(t *testing.T) {
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	target, err := url.Parse(upstream.URL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	upstream.Close()
+
+	var logOutput bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&logOutput)
+	ctx := dlog.WithLogger(context.Background(), dlog.WrapLogrus(logger))
+
+	req := httptest.NewRequest(http.MethodGet, "http://proxy.example/", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	dhttp.NewProxyHandler(target).ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+	assert.Equal(t, 1, strings.Count(logOutput.String(), "level=error"), "the error should be logged exactly once")
+}
@@ -0,0 +1,37 @@
+package dhttp
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/datawire/dlib/derror"
+)
+
+// WithRecovery wraps a Handler such that if it panics, the panic is recovered and turned in to an
+// error with derror.PanicToError, a "500 Internal Server Error" response is written, and then
+// onPanic (if non-nil) is called with the request's Context and the error.
+//
+// Without this, a panicking Handler is recovered by net/http itself, which logs the panic value
+// but gives the application no chance to do anything with it.  (dgroup's panic recovery does not
+// cover Handlers, as they run in goroutines managed by net/http.)
+//
+// As with net/http, a panic with the value http.ErrAbortHandler is not recovered.
+func WithRecovery(next http.Handler, onPanic func(ctx context.Context, err error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			err := derror.PanicToError(rec)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			if onPanic != nil {
+				onPanic(r.Context(), err)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,71 @@
+package dhttp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestWithRecovery(t *testing.T) {
+	ctx := dlog.NewTestContext(t, true)
+
+	var panicCtx context.Context
+	var panicErr error
+	handler := dhttp.WithRecovery(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("oops")
+		}),
+		func(ctx context.Context, err error) {
+			panicCtx = ctx
+			panicErr = err
+		})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	resp := httptest.NewRecorder()
+	assert.NotPanics(t, func() {
+		handler.ServeHTTP(resp, req)
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.Equal(t, ctx, panicCtx)
+	if assert.Error(t, panicErr) {
+		assert.Equal(t, "PANIC: oops", panicErr.Error())
+	}
+}
+
+func TestWithRecoveryNoPanic(t *testing.T) {
+	called := false
+	handler := dhttp.WithRecovery(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}),
+		func(ctx context.Context, err error) {
+			called = true
+		})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(dlog.NewTestContext(t, true))
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	assert.False(t, called)
+}
+
+func TestWithRecoveryAbortHandler(t *testing.T) {
+	handler := dhttp.WithRecovery(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}),
+		nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(dlog.NewTestContext(t, true))
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	})
+}
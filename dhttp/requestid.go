@@ -0,0 +1,51 @@
+package dhttp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dlog"
+)
+
+type requestIDContextKey struct{}
+
+// newRequestID returns a random 16-byte hex string.
+func newRequestID() string {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		// crypto/rand.Read only fails if the OS's randomness source is broken, in which case
+		// there's not much that we can sensibly do.
+		panic(err)
+	}
+	return hex.EncodeToString(id[:])
+}
+
+// WithRequestID wraps a Handler such that every request carries a correlation ID.  If the request
+// has an "X-Request-ID" header, then that is used as the ID; otherwise a new random ID is
+// generated.  The ID is added to the request's Context as the "dhttp.request_id" dlog field (and
+// may be read with RequestIDFromContext), and is sent back to the client as the "X-Request-ID"
+// response header.
+//
+// The ID is attached with dcontext.WithValue, so it survives dcontext.WithoutCancelPreserving.
+func WithRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if id == "" {
+			id = newRequestID()
+		}
+		ctx := dlog.WithField(r.Context(), "dhttp.request_id", id)
+		ctx = dcontext.WithValue(ctx, requestIDContextKey{}, id)
+		w.Header().Set("X-Request-ID", id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestIDFromContext returns the request ID that was attached to the Context by WithRequestID.
+// The boolean is false if there is no request ID.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDContextKey{}).(string)
+	return id, ok
+}
@@ -0,0 +1,49 @@
+package dhttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestWithRequestID(t *testing.T) {
+	var handlerID string
+	var handlerOK bool
+	handler := dhttp.WithRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerID, handlerOK = dhttp.RequestIDFromContext(r.Context())
+	}))
+
+	t.Run("propagate", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(dlog.NewTestContext(t, true))
+		req.Header.Set("X-Request-ID", "my-request-id")
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+
+		assert.True(t, handlerOK)
+		assert.Equal(t, "my-request-id", handlerID)
+		assert.Equal(t, "my-request-id", resp.Header().Get("X-Request-ID"))
+	})
+
+	t.Run("generate", func(t *testing.T) {
+		var ids []string
+		for i := 0; i < 2; i++ {
+			req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(dlog.NewTestContext(t, true))
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+
+			assert.True(t, handlerOK)
+			assert.Len(t, handlerID, 32)
+			assert.Equal(t, handlerID, resp.Header().Get("X-Request-ID"))
+			ids = append(ids, handlerID)
+		}
+		assert.NotEqual(t, ids[0], ids[1])
+	})
+
+	_, ok := dhttp.RequestIDFromContext(dlog.NewTestContext(t, true))
+	assert.False(t, ok)
+}
@@ -0,0 +1,104 @@
+package dhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dlog"
+)
+
+type propagatedField struct {
+	key        string
+	headerName string
+}
+
+type propagationContextKey struct{}
+
+// MarkFieldForPropagation returns a copy of ctx in which the dlog field key (as set with
+// dlog.WithField, either before or after calling MarkFieldForPropagation) is sent as the
+// headerName header on outbound requests made through a RoundTripper from
+// NewLoggingRoundTripper.  The field value is formatted with fmt.Sprint.
+//
+// The mark is attached with dcontext.WithValue, so it survives dcontext.WithoutCancelPreserving.
+func MarkFieldForPropagation(ctx context.Context, key, headerName string) context.Context {
+	prev, _ := ctx.Value(propagationContextKey{}).([]propagatedField)
+	fields := make([]propagatedField, 0, len(prev)+1)
+	fields = append(fields, prev...)
+	fields = append(fields, propagatedField{key: key, headerName: http.CanonicalHeaderKey(headerName)})
+	return dcontext.WithValue(ctx, propagationContextKey{}, fields)
+}
+
+type loggingRoundTripper struct {
+	base http.RoundTripper
+}
+
+// NewLoggingRoundTripper wraps an http.RoundTripper such that each outbound request is logged
+// (using dlog with the request's Context) after the response headers are received, with the
+// fields "dhttp.method", "dhttp.url", "dhttp.status", and "dhttp.latency".  Requests are logged at
+// LogLevelInfo, except that 4xx responses are logged at LogLevelWarn, and 5xx responses and
+// transport errors are logged at LogLevelError.  A request that failed because its Context was
+// canceled is logged at LogLevelInfo, since that is usually the caller's doing rather than a
+// problem with the upstream.
+//
+// Any dlog fields that have been marked with MarkFieldForPropagation are added to the outbound
+// request as headers (unless the request already has that header set).
+//
+// If base is nil, http.DefaultTransport is used.
+func NewLoggingRoundTripper(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &loggingRoundTripper{base: base}
+}
+
+func (rt *loggingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	// A RoundTripper must not modify the request, so clone it before adding any headers.
+	cloned := false
+	fields, _ := ctx.Value(propagationContextKey{}).([]propagatedField)
+	for _, field := range fields {
+		if req.Header.Get(field.headerName) != "" {
+			continue
+		}
+		val, ok := dlog.FieldValue(ctx, field.key)
+		if !ok {
+			continue
+		}
+		if !cloned {
+			req = req.Clone(ctx)
+			cloned = true
+		}
+		req.Header.Set(field.headerName, fmt.Sprint(val))
+	}
+
+	start := time.Now()
+	resp, err := rt.base.RoundTrip(req)
+	latency := time.Since(start)
+
+	ctx = dlog.WithField(ctx, "dhttp.method", req.Method)
+	ctx = dlog.WithField(ctx, "dhttp.url", req.URL.String())
+	ctx = dlog.WithField(ctx, "dhttp.latency", latency)
+	if err != nil {
+		level := dlog.LogLevelError
+		if errors.Is(err, context.Canceled) {
+			level = dlog.LogLevelInfo
+		}
+		dlog.Logf(ctx, level, "%s %s: %v", req.Method, req.URL, err)
+		return resp, err
+	}
+	level := dlog.LogLevelInfo
+	switch {
+	case resp.StatusCode >= 500:
+		level = dlog.LogLevelError
+	case resp.StatusCode >= 400:
+		level = dlog.LogLevelWarn
+	}
+	ctx = dlog.WithField(ctx, "dhttp.status", resp.StatusCode)
+	dlog.Logf(ctx, level, "%s %s %d", req.Method, req.URL, resp.StatusCode)
+	return resp, nil
+}
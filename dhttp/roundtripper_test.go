@@ -0,0 +1,126 @@
+package dhttp_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestLoggingRoundTripper(t *testing.T) {
+	var gotHeaders http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = r.Header.Clone()
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, "hello")
+	}))
+	defer srv.Close()
+
+	var logOutput bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&logOutput)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	ctx := dlog.WithLogger(context.Background(), dlog.WrapLogrus(logger))
+	ctx = dhttp.MarkFieldForPropagation(ctx, "trace_id", "traceparent")
+	ctx = dlog.WithField(ctx, "trace_id", "00-abc-def-01")
+	ctx = dhttp.MarkFieldForPropagation(ctx, "unset", "X-Unset")
+	ctx = dlog.WithField(ctx, "not_propagated", "secret")
+
+	client := &http.Client{Transport: dhttp.NewLoggingRoundTripper(nil)}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/some/path", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	resp, err := client.Do(req)
+	if !assert.NoError(t, err) {
+		return
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(body))
+
+	// Headers
+	assert.Equal(t, "00-abc-def-01", gotHeaders.Get("Traceparent"))
+	assert.NotContains(t, gotHeaders, "X-Unset")
+	for _, vals := range gotHeaders {
+		assert.NotContains(t, vals, "secret")
+	}
+	assert.Empty(t, req.Header, "the caller's request should not be modified")
+
+	// Logging
+	var entry map[string]interface{}
+	if !assert.NoError(t, json.Unmarshal(logOutput.Bytes(), &entry)) {
+		return
+	}
+	assert.Equal(t, "warning", entry["level"])
+	assert.Equal(t, "GET "+srv.URL+"/some/path 404", entry["msg"])
+	assert.Equal(t, "GET", entry["dhttp.method"])
+	assert.Equal(t, srv.URL+"/some/path", entry["dhttp.url"])
+	assert.Equal(t, float64(http.StatusNotFound), entry["dhttp.status"])
+	assert.Contains(t, entry, "dhttp.latency")
+	assert.Equal(t, "00-abc-def-01", entry["trace_id"])
+}
+
+func TestLoggingRoundTripperError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	var logOutput bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&logOutput)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	ctx := dlog.WithLogger(context.Background(), dlog.WrapLogrus(logger))
+
+	client := &http.Client{Transport: dhttp.NewLoggingRoundTripper(nil)}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = client.Do(req)
+	assert.Error(t, err)
+
+	var entry map[string]interface{}
+	if !assert.NoError(t, json.Unmarshal(logOutput.Bytes(), &entry)) {
+		return
+	}
+	assert.Equal(t, "error", entry["level"])
+	assert.Equal(t, "GET", entry["dhttp.method"])
+	assert.NotContains(t, entry, "dhttp.status")
+}
+
+func TestLoggingRoundTripperCanceled(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	var logOutput bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&logOutput)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	ctx, cancel := context.WithCancel(dlog.WithLogger(context.Background(), dlog.WrapLogrus(logger)))
+	cancel()
+
+	client := &http.Client{Transport: dhttp.NewLoggingRoundTripper(nil)}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = client.Do(req)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	var entry map[string]interface{}
+	if !assert.NoError(t, json.Unmarshal(logOutput.Bytes(), &entry)) {
+		return
+	}
+	assert.Equal(t, "info", entry["level"])
+}
@@ -35,12 +35,18 @@ package dhttp
 import (
 	"context"
 	"crypto/tls"
+	"errors"
+	"fmt"
 	"log"
 	"net"
 	"net/http"
+	"os"
+	"os/user"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync/atomic"
+	"syscall"
 	"time"
 
 	"golang.org/x/net/http2"
@@ -152,7 +158,9 @@ type testHookContextKey struct{}
 //
 // [2]: https://xkcd.com/1172/
 type ServerConfig struct {
-	// These fields exactly mimic http.Server; see the documentation there.
+	// These fields exactly mimic http.Server; see the documentation there.  (Except that
+	// WriteTimeout is applied per-request, the same as WriteTimeoutFn, rather than to the
+	// underlying connection.)
 	Handler           http.Handler
 	TLSConfig         *tls.Config
 	ReadTimeout       time.Duration
@@ -163,6 +171,18 @@ type ServerConfig struct {
 	ConnState         func(net.Conn, http.ConnState)
 	ConnContext       func(ctx context.Context, c net.Conn) context.Context
 
+	// WriteTimeoutFn, if set, is called for each request to determine the maximum duration
+	// that the Handler may take to write the response; once it expires, the request's Context
+	// is canceled and further writes fail with http.ErrHandlerTimeout (in which case the
+	// response is aborted, rather than being sent as if it were complete).  For HTTP/1, it is
+	// also set as the write deadline of the connection, so that a write that is blocked on a
+	// slow client is interrupted.  A zero or negative duration means no timeout.  Unlike WriteTimeout, this is evaluated per-request, so that
+	// (for example) streaming responses can be given a longer timeout than other responses.
+	// If WriteTimeoutFn is nil, then WriteTimeout is used as the timeout for every request.
+	//
+	// (This is not in http.Server at all.)
+	WriteTimeoutFn func(r *http.Request) time.Duration
+
 	// TLSNextProto (mostly mimicking http.Server.TLSNextProto) optionally specifies a function
 	// to take over ownership of the provided TLS connection when an ALPN protocol upgrade has
 	// occurred.  The map key is the protocol name negotiated.  The Handler argument should be
@@ -189,13 +209,96 @@ type ServerConfig struct {
 	// (This is not in http.Server at all.)
 	DisableHTTP2 bool
 
-	// HTTP2Config contains the HTTP/2-specific configuration (except for whether HTTP/2 is
-	// enabled at all; use DisableHTTP2 for that).  HTTP2Config may be nil, and HTTP/2 will
-	// still be enabled.
+	// HTTP2Options contains the HTTP/2-specific configuration (except for whether HTTP/2 is
+	// enabled at all; use DisableHTTP2 for that).  HTTP2Options may be nil, and HTTP/2 will
+	// still be enabled.  See NewHTTP2Options for recommended values.
 	//
 	// (This is not in http.Server at all.)
+	HTTP2Options *HTTP2Options
+
+	// HTTP2Config is the full golang.org/x/net/http2 server configuration.  If both
+	// HTTP2Config and HTTP2Options are set, then the non-zero fields of HTTP2Options take
+	// precedence.
+	//
+	// Deprecated: Use HTTP2Options instead; most of the fields in http2.Server are not useful
+	// to set.
 	HTTP2Config *http2.Server
 
+	// TLSCertRefresh, if set, is called on each new TLS handshake to obtain the certificate to
+	// present to the client; it is wired in as TLSConfig.GetCertificate (overriding any
+	// GetCertificate that TLSConfig may already have).  This allows the certificate to be
+	// refreshed (for example, from disk with FileCertRefresh, or from a secret store) without
+	// restarting the server.
+	//
+	// (This is not in http.Server at all.)
+	TLSCertRefresh func() (*tls.Certificate, error)
+
+	// ConnLimit, if positive, is the maximum number of connections that may be open at once;
+	// once that many connections are open, no more are accepted until one of them is closed.
+	// Connections that are still in the TLS-handshake phase count against the limit.
+	//
+	// (This is not in http.Server at all.)
+	ConnLimit int
+
+	// MaxRequestBodySize, if positive, is the maximum size in bytes of a request body.  A
+	// request with a larger body is rejected with a "413 Request Entity Too Large" response
+	// (with a JSON body) before the Handler is called, so the Handler never sees part of an
+	// oversized body.  Request bodies of unknown length (chunked requests) are read in to
+	// memory in order to check their size.
+	//
+	// (This is not in http.Server at all.)
+	MaxRequestBodySize int64
+
+	// OnRequest, if set, is called for each request before the Handler, and the request that
+	// it returns is what is passed to the Handler; so it may be used to instrument requests
+	// (for example, by attaching values to the request's Context with r.WithContext) without
+	// having to wrap the Handler.  If OnRequest returns nil, then the request is rejected with a
+	// "500 Internal Server Error" response and the Handler is not called; in that case
+	// OnRequest should not write a response itself.
+	//
+	// (This is not in http.Server at all.)
+	OnRequest func(w http.ResponseWriter, r *http.Request) *http.Request
+
+	// MetricsHandler, if set, is served at the path "/metrics" (for example, a Prometheus
+	// exposition handler), and the Handler receives requests for all other paths.  This avoids
+	// needing to run a separate server just for metrics.  Requests for "/metrics" bypass
+	// WriteTimeout, WriteTimeoutFn, MaxRequestBodySize, and OnRequest, and are served for as
+	// long as the server is accepting connections (including during ShutdownDelay), so that
+	// scraping continues until the server is stopped.
+	//
+	// (This is not in http.Server at all.)
+	MetricsHandler http.Handler
+
+	// LivenessCheck and ReadinessCheck, if set, are served at LivenessPath and ReadinessPath
+	// (which default to "/healthz" and "/readyz"), for use as Kubernetes liveness and readiness
+	// probes; the Handler receives requests for all other paths.  Each is called with the
+	// request's Context; the endpoint responds with "200 OK" if it returns nil, or with "503
+	// Service Unavailable" and the error message as the body if it returns an error.  Once the
+	// soft Context passed to Serve is Done, the readiness endpoint responds with 503 without
+	// calling ReadinessCheck, so that load balancers stop sending traffic (see also
+	// ShutdownDelay).  Like MetricsHandler, these endpoints bypass the other per-request
+	// settings.
+	//
+	// (These are not in http.Server at all.)
+	LivenessCheck  func(ctx context.Context) error
+	ReadinessCheck func(ctx context.Context) error
+	LivenessPath   string
+	ReadinessPath  string
+
+	// UnixSocketMode is the permissions that ListenAndServeUNIX gives the socket file.  If
+	// zero, then 0600 is used.
+	//
+	// (This is not in http.Server at all.)
+	UnixSocketMode os.FileMode
+
+	// UnixSocketGroup, if non-empty, is the name of a group that ListenAndServeUNIX changes the
+	// socket file's group ownership to, so that (combined with UnixSocketMode) other processes
+	// in that group may connect to it.  If the group can't be looked up or the ownership can't
+	// be changed, ListenAndServeUNIX returns an error without accepting any connections.
+	//
+	// (This is not in http.Server at all.)
+	UnixSocketGroup string
+
 	// OnShutdown is an array of functions that are each called once when shutdown is initiated.
 	// Use this when hijacking connections; your OnShutdown should notify your hijacking Handler
 	// that a graceful shutdown has been initiated, and your Handler should respond by closing
@@ -205,6 +308,26 @@ type ServerConfig struct {
 	//
 	// (This replaces the RegisterOnShutdown method of *http.Server.)
 	OnShutdown []func()
+
+	// OnShutdownOrdered is like OnShutdown, but rather than the functions all being called
+	// concurrently, they are called sequentially in index order (in a single goroutine that is
+	// itself concurrent with the OnShutdown functions), each being passed the hard Context.  An
+	// error returned from one of the functions is logged, but does not prevent the subsequent
+	// functions from being called.  Use this when the shutdown hooks depend on each other (for
+	// example, "stop accepting new work" before "drain the queue").
+	//
+	// (This is not in http.Server at all.)
+	OnShutdownOrdered []func(ctx context.Context) error
+
+	// ShutdownDelay, if positive, is how long to keep serving normally after the soft Context
+	// becomes Done, before actually starting to shut down (closing the listener, calling the
+	// OnShutdown functions, and so on).  This is useful when running behind a load balancer
+	// that takes some time to stop routing traffic to the server once it has been told that
+	// the server is going away (the "pre-stop hook" pattern in Kubernetes).  If the hard
+	// Context becomes Done during the delay, then the delay is cut short.
+	//
+	// (This is not in http.Server at all.)
+	ShutdownDelay time.Duration
 }
 
 func (sc *ServerConfig) serve(ctx context.Context, serveFn func(*http.Server) error) error {
@@ -217,7 +340,7 @@ func (sc *ServerConfig) serve(ctx context.Context, serveFn func(*http.Server) er
 	var connCnt uint64
 	server := &http.Server{
 		// Pass along the verbatim fields
-		Handler:           sc.Handler,
+		Handler:           sc.withHealthChecks(ctx, sc.withMetrics(sc.withWriteTimeout(sc.withMaxRequestBodySize(sc.withOnRequest(sc.Handler))))),
 		TLSConfig:         sc.TLSConfig, // don't worry about deep-copying the TLS config, net/http will do it
 		ReadTimeout:       sc.ReadTimeout,
 		ReadHeaderTimeout: sc.ReadHeaderTimeout,
@@ -238,6 +361,7 @@ func (sc *ServerConfig) serve(ctx context.Context, serveFn func(*http.Server) er
 				if connAddr == listAddr || strings.Contains(connAddr, "/") {
 					name = strconv.FormatUint(atomic.AddUint64(&connCnt, 1), 10)
 				}
+				ctx = context.WithValue(ctx, netConnContextKey{}, conn)
 				return dgroup.WithGoroutineName(ctx, "/conn="+name)
 			},
 			sc.ConnContext,
@@ -258,12 +382,32 @@ func (sc *ServerConfig) serve(ctx context.Context, serveFn func(*http.Server) er
 	for k, v := range sc.TLSNextProto {
 		server.TLSNextProto[k] = v
 	}
+	if certRefresh := sc.TLSCertRefresh; certRefresh != nil {
+		// We're about to mutate the TLS config, so we do need to deep-copy it here.
+		server.TLSConfig = server.TLSConfig.Clone()
+		if server.TLSConfig == nil {
+			server.TLSConfig = new(tls.Config)
+		}
+		server.TLSConfig.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return certRefresh()
+		}
+	}
 	if server.ErrorLog == nil {
 		server.ErrorLog = dlog.StdLogger(ctx, dlog.LogLevelError)
 	}
 	for _, onShutdown := range sc.OnShutdown {
 		server.RegisterOnShutdown(onShutdown)
 	}
+	if len(sc.OnShutdownOrdered) > 0 {
+		onShutdownOrdered := append([]func(context.Context) error(nil), sc.OnShutdownOrdered...)
+		server.RegisterOnShutdown(func() {
+			for i, onShutdown := range onShutdownOrdered {
+				if err := onShutdown(hardCtx); err != nil {
+					dlog.Errorf(ctx, "OnShutdownOrdered[%d]: %v", i, err)
+				}
+			}
+		})
+	}
 
 	// Part 3: Configure HTTP/2.
 	//
@@ -271,13 +415,7 @@ func (sc *ServerConfig) serve(ctx context.Context, serveFn func(*http.Server) er
 	// because they show as hijacked (see the doc comment on configureHTTP2).  We'll address
 	// that below with configureHijackTracking.
 	if !sc.DisableHTTP2 {
-		cfg := sc.HTTP2Config
-		if cfg != nil {
-			// shallow copy (there's nothing deep inside of it)
-			_cfg := *cfg
-			cfg = &_cfg
-		}
-		if err := configureHTTP2(server, cfg); err != nil {
+		if err := configureHTTP2(server, sc.http2Config()); err != nil {
 			return err
 		}
 	}
@@ -310,9 +448,24 @@ func (sc *ServerConfig) serve(ctx context.Context, serveFn func(*http.Server) er
 		hardCancel()
 		_ = server.Shutdown(hardCtx)
 	case <-ctx.Done():
-		// A soft shutdown has been initiated; call server.Shutdown().
+		// A soft shutdown has been initiated; call server.Shutdown() (after waiting
+		// ShutdownDelay, if set).
+		var serveErr error
+		if sc.ShutdownDelay > 0 {
+			dlog.Infof(ctx, "waiting %v before shutting down", sc.ShutdownDelay)
+			timer := time.NewTimer(sc.ShutdownDelay)
+			select {
+			case <-timer.C:
+			case <-hardCtx.Done():
+			case serveErr = <-serverCh:
+			}
+			timer.Stop()
+		}
 		err = server.Shutdown(hardCtx)
 		<-serverCh // server returns immediately upon calling .Shutdown; don't leak the channel
+		if serveErr != nil {
+			err = serveErr
+		}
 	}
 
 	// At this point, everything managed by the http.Server has finished, but hijacked
@@ -353,6 +506,14 @@ func (sc *ServerConfig) serve(ctx context.Context, serveFn func(*http.Server) er
 	return err
 }
 
+// limitListener applies sc.ConnLimit to a Listener.
+func (sc *ServerConfig) limitListener(ln net.Listener) net.Listener {
+	if sc.ConnLimit <= 0 {
+		return ln
+	}
+	return newLimitListener(ln, sc.ConnLimit)
+}
+
 // Serve accepts incoming connections on the Listener ln, creating a new worker goroutine for each.
 // The worker goroutines read requests and call sc.Handler to reply to them.
 //
@@ -370,6 +531,7 @@ func (sc *ServerConfig) serve(ctx context.Context, serveFn func(*http.Server) er
 //
 // Serve always closes the Listener before returning.
 func (sc *ServerConfig) Serve(ctx context.Context, ln net.Listener) error {
+	ln = sc.limitListener(ln)
 	return sc.serve(ctx, func(srv *http.Server) error { return srv.Serve(ln) })
 }
 
@@ -380,7 +542,8 @@ func (sc *ServerConfig) Serve(ctx context.Context, ln net.Listener) error {
 // neither the ServerConfig's TLSConfig.Certificates nor TLSConfig.GetCertificate are populated.  If
 // the certificate is signed by a certificate authority, the certFile should be the concatenation of
 // the server's certificate, any intermediates, and the CA's certificate.  If TLSConfig.Certificates
-// are TLSConfig.GetCertificate are populated, then you may pass empty strings as the filenames.
+// are TLSConfig.GetCertificate are populated (or if TLSCertRefresh is set), then you may pass empty
+// strings as the filenames.
 //
 // ServeTLS always closes the Listener before returning (this is slightly different than
 // *http.Server.ServeTLS, which does not close the Listener if returning early during setup due to
@@ -390,6 +553,7 @@ func (sc *ServerConfig) ServeTLS(ctx context.Context, ln net.Listener, certFile,
 	// it if it returns early during setup due to being passed invalid cert or key files.
 	defer ln.Close()
 
+	ln = sc.limitListener(ln)
 	return sc.serve(ctx, func(srv *http.Server) error { return srv.ServeTLS(ln, certFile, keyFile) })
 }
 
@@ -427,3 +591,107 @@ func (sc *ServerConfig) ListenAndServeTLS(ctx context.Context, addr, certFile, k
 
 	return sc.ServeTLS(ctx, ln, certFile, keyFile)
 }
+
+// ListenAndServeUNIX is like Serve, but rather than taking an existing Listener object, it takes a
+// filesystem path of a Unix domain socket to listen on.  If a stale socket file (one that nothing is
+// listening on) already exists at that path, it is removed before binding; if another server is
+// listening on it, or if a non-socket file exists there, then an error is returned.  The socket
+// file is given UnixSocketMode permissions (0600 by default) and UnixSocketGroup group ownership
+// (if set) before it is made available at path, so that there is no window in which it is
+// connectable with looser permissions; and it is removed when ListenAndServeUNIX returns.
+func (sc *ServerConfig) ListenAndServeUNIX(ctx context.Context, path string) error {
+	if err := removeStaleSocket(path); err != nil {
+		return err
+	}
+
+	ln, err := sc.listenUNIX(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(path)
+	}()
+
+	return sc.Serve(ctx, ln)
+}
+
+// removeStaleSocket removes the socket file at path if nothing is listening on it.
+func removeStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		// Most likely it doesn't exist; if it's something else, then net.Listen will
+		// report it.
+		return nil
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("listen unix %s: file exists and is not a socket", path)
+	}
+	conn, err := net.Dial("unix", path)
+	if err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("listen unix %s: socket is in use by another server", path)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("listen unix %s: unable to determine whether existing socket is stale: %w", path, err)
+	}
+	return os.Remove(path)
+}
+
+// listenUNIX binds a Unix domain socket, sets its permissions, and only then moves it in to place
+// at path.  It is bound inside of a private (0700) temporary directory next to path, so that
+// nothing else can connect to it before its permissions have been set.
+func (sc *ServerConfig) listenUNIX(path string) (net.Listener, error) {
+	tmpdir, err := os.MkdirTemp(filepath.Dir(path), ".dhttp")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpdir)
+	}()
+	tmpPath := filepath.Join(tmpdir, "sock")
+
+	ln, err := net.Listen("unix", tmpPath)
+	if err != nil {
+		return nil, err
+	}
+	// The socket won't be at tmpPath by the time it's closed; ListenAndServeUNIX removes it
+	// from path instead.
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+
+	if err := sc.setUNIXSocketPerms(tmpPath); err != nil {
+		_ = ln.Close()
+		return nil, err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// setUNIXSocketPerms applies UnixSocketGroup and UnixSocketMode to the socket file at path.
+func (sc *ServerConfig) setUNIXSocketPerms(path string) error {
+	if sc.UnixSocketGroup != "" {
+		if err := chgrp(path, sc.UnixSocketGroup); err != nil {
+			return err
+		}
+	}
+	mode := sc.UnixSocketMode
+	if mode == 0 {
+		mode = 0600
+	}
+	return os.Chmod(path, mode)
+}
+
+// chgrp changes the group ownership of path to the named group.
+func chgrp(path, groupName string) error {
+	grp, err := user.LookupGroup(groupName)
+	if err != nil {
+		return err
+	}
+	gid, err := strconv.Atoi(grp.Gid)
+	if err != nil {
+		return fmt.Errorf("group %q: invalid gid %q", groupName, grp.Gid)
+	}
+	return os.Chown(path, -1, gid)
+}
@@ -0,0 +1,102 @@
+// +build !windows
+
+package dhttp_test
+
+import (
+	"context"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+)
+
+// chgrpTarget returns a group that the current user is permitted to change file group ownership
+// to, other than the user's primary group (so that the change is observable).
+func chgrpTarget(t *testing.T) *user.Group {
+	t.Helper()
+	var candidates []int
+	if os.Geteuid() == 0 {
+		candidates = append(candidates, 1, 2, 3)
+	} else {
+		gids, err := os.Getgroups()
+		if err != nil {
+			t.Skipf("cannot list supplementary groups: %v", err)
+		}
+		candidates = gids
+	}
+	for _, gid := range candidates {
+		if gid == os.Getgid() {
+			continue
+		}
+		if grp, err := user.LookupGroupId(strconv.Itoa(gid)); err == nil {
+			return grp
+		}
+	}
+	t.Skipf("no group available that uid=%d may chgrp files to", os.Geteuid())
+	return nil
+}
+
+func TestListenAndServeUNIXGroup(t *testing.T) {
+	grp := chgrpTarget(t)
+
+	tmpdir, err := os.MkdirTemp("", "dhttp-test.")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(tmpdir)
+	sockPath := filepath.Join(tmpdir, "sock")
+
+	ctx, hardCancel := context.WithCancel(dlog.NewTestContext(t, true))
+	defer hardCancel()
+	ctx, softCancel := context.WithCancel(dcontext.WithSoftness(ctx))
+	defer softCancel()
+
+	sc := &dhttp.ServerConfig{
+		UnixSocketGroup: grp.Name,
+		UnixSocketMode:  0660,
+	}
+	serverExited := make(chan struct{})
+	go func() {
+		defer close(serverExited)
+		assert.NoError(t, sc.ListenAndServeUNIX(ctx, sockPath))
+	}()
+
+	var fi os.FileInfo
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if fi, err = os.Stat(sockPath); err == nil && fi.Mode().Perm() == 0660 {
+			break
+		}
+	}
+	if assert.NoError(t, err) {
+		assert.Equal(t, os.FileMode(0660), fi.Mode().Perm())
+		assert.Equal(t, grp.Gid, strconv.Itoa(int(fi.Sys().(*syscall.Stat_t).Gid)))
+	}
+
+	softCancel()
+	<-serverExited
+}
+
+func TestListenAndServeUNIXBadGroup(t *testing.T) {
+	tmpdir, err := os.MkdirTemp("", "dhttp-test.")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(tmpdir)
+	sockPath := filepath.Join(tmpdir, "sock")
+
+	sc := &dhttp.ServerConfig{
+		UnixSocketGroup: "dhttp-test-no-such-group",
+	}
+	assert.Error(t, sc.ListenAndServeUNIX(dlog.NewTestContext(t, false), sockPath))
+	_, err = os.Stat(sockPath)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,145 @@
+package dhttp_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestListenAndServeUNIX(t *testing.T) {
+	tmpdir, err := os.MkdirTemp("", "dhttp-test.")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(tmpdir)
+	sockPath := filepath.Join(tmpdir, "sock")
+
+	// Leave a stale socket file behind, to make sure that it gets removed.
+	staleLn, err := net.Listen("unix", sockPath)
+	if !assert.NoError(t, err) {
+		return
+	}
+	staleLn.(*net.UnixListener).SetUnlinkOnClose(false)
+	if !assert.NoError(t, staleLn.Close()) {
+		return
+	}
+	_, err = os.Stat(sockPath)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ctx, hardCancel := context.WithCancel(dlog.NewTestContext(t, true))
+	defer hardCancel()
+	ctx, softCancel := context.WithCancel(dcontext.WithSoftness(ctx))
+	defer softCancel()
+
+	sc := &dhttp.ServerConfig{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, "hello world")
+		}),
+	}
+	serverExited := make(chan struct{})
+	go func() {
+		defer close(serverExited)
+		assert.NoError(t, sc.ListenAndServeUNIX(ctx, sockPath))
+	}()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", sockPath)
+			},
+		},
+	}
+	defer client.CloseIdleConnections()
+
+	var resp *http.Response
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if resp, err = client.Get("http://unix/"); err == nil {
+			break
+		}
+	}
+	if !assert.NoError(t, err) {
+		return
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", string(body))
+
+	if fi, err := os.Stat(sockPath); assert.NoError(t, err) {
+		assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+	}
+
+	// The socket was bound in a temporary directory, which has been cleaned up.
+	if entries, err := os.ReadDir(tmpdir); assert.NoError(t, err) && assert.Len(t, entries, 1) {
+		assert.Equal(t, "sock", entries[0].Name())
+	}
+
+	client.CloseIdleConnections()
+	softCancel()
+	<-serverExited
+	_, err = os.Stat(sockPath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestListenAndServeUNIXNotSocket(t *testing.T) {
+	tmpdir, err := os.MkdirTemp("", "dhttp-test.")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(tmpdir)
+	path := filepath.Join(tmpdir, "file")
+	if !assert.NoError(t, os.WriteFile(path, nil, 0600)) {
+		return
+	}
+
+	sc := &dhttp.ServerConfig{}
+	assert.Error(t, sc.ListenAndServeUNIX(dlog.NewTestContext(t, false), path))
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+}
+
+func TestListenAndServeUNIXInUse(t *testing.T) {
+	tmpdir, err := os.MkdirTemp("", "dhttp-test.")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(tmpdir)
+	sockPath := filepath.Join(tmpdir, "sock")
+
+	// A live server is already listening on the socket.
+	liveLn, err := net.Listen("unix", sockPath)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer liveLn.Close()
+
+	sc := &dhttp.ServerConfig{}
+	assert.EqualError(t, sc.ListenAndServeUNIX(dlog.NewTestContext(t, false), sockPath),
+		"listen unix "+sockPath+": socket is in use by another server")
+
+	// The live server's socket is left alone, and is still connectable.
+	conn, err := net.Dial("unix", sockPath)
+	if assert.NoError(t, err) {
+		conn.Close()
+	}
+
+	// No temporary files are left behind.
+	entries, err := os.ReadDir(tmpdir)
+	if assert.NoError(t, err) && assert.Len(t, entries, 1) {
+		assert.Equal(t, "sock", entries[0].Name())
+	}
+}
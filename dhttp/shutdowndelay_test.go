@@ -0,0 +1,97 @@
+package dhttp_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestShutdownDelay(t *testing.T) {
+	const delay = 500 * time.Millisecond
+
+	ctx, hardCancel := context.WithCancel(dlog.NewTestContext(t, true))
+	defer hardCancel()
+	ctx, softCancel := context.WithCancel(dcontext.WithSoftness(ctx))
+	defer softCancel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	sc := &dhttp.ServerConfig{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := r.Context().Err(); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+		}),
+		ShutdownDelay: delay,
+	}
+	serverExited := make(chan struct{})
+	go func() {
+		defer close(serverExited)
+		assert.NoError(t, sc.Serve(ctx, ln))
+	}()
+
+	client := &http.Client{
+		Transport: &http.Transport{DisableKeepAlives: true},
+	}
+	get := func() error {
+		resp, err := client.Get("http://" + ln.Addr().String() + "/")
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		return nil
+	}
+	assert.NoError(t, get())
+
+	start := time.Now()
+	softCancel()
+
+	// New connections are still accepted during the delay.
+	time.Sleep(delay / 2)
+	assert.NoError(t, get())
+
+	<-serverExited
+	assert.GreaterOrEqual(t, time.Since(start), delay)
+	assert.Error(t, get())
+}
+
+func TestShutdownDelayHardCancel(t *testing.T) {
+	ctx, hardCancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer hardCancel()
+	ctx, softCancel := context.WithCancel(dcontext.WithSoftness(ctx))
+	defer softCancel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	sc := &dhttp.ServerConfig{
+		Handler:       http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		ShutdownDelay: time.Hour,
+	}
+	serverExited := make(chan struct{})
+	go func() {
+		defer close(serverExited)
+		_ = sc.Serve(ctx, ln)
+	}()
+
+	softCancel()
+	time.Sleep(100 * time.Millisecond)
+	hardCancel()
+	select {
+	case <-serverExited:
+	case <-time.After(10 * time.Second):
+		t.Error("hard cancel did not cut the ShutdownDelay short")
+	}
+}
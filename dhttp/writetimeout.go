@@ -0,0 +1,122 @@
+package dhttp
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// netConnContextKey is the Context key under which serve() stores the net.Conn that a request
+// arrived on.
+type netConnContextKey struct{}
+
+// timeoutWriter wraps an http.ResponseWriter such that once the timeout has expired, all further
+// writes fail.
+type timeoutWriter struct {
+	http.ResponseWriter
+	expired int32 // atomic
+
+	// rejected is set if a write was rejected because the timeout had expired; it is only
+	// accessed from the handler's goroutine.
+	rejected bool
+}
+
+func (w *timeoutWriter) isExpired() bool {
+	return atomic.LoadInt32(&w.expired) != 0
+}
+
+func (w *timeoutWriter) WriteHeader(status int) {
+	if w.isExpired() {
+		w.rejected = true
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *timeoutWriter) Write(p []byte) (int, error) {
+	if w.isExpired() {
+		w.rejected = true
+		return 0, http.ErrHandlerTimeout
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *timeoutWriter) Flush() {
+	if w.isExpired() {
+		return
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	return hijacker.Hijack()
+}
+
+// Unwrap allows http.ResponseController to access the underlying ResponseWriter.
+func (w *timeoutWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// withWriteTimeout wraps a Handler to apply sc.WriteTimeoutFn (or sc.WriteTimeout) to each request.
+//
+// The timeout is enforced in two ways: once it expires the request's Context is canceled and
+// further writes to the ResponseWriter fail with http.ErrHandlerTimeout; and for HTTP/1 the write
+// deadline of the underlying connection is set, so that a handler that is blocked writing to a
+// slow or stalled client is interrupted too.  (For HTTP/2, the connection is shared between
+// requests, so its deadline is left alone.)
+func (sc *ServerConfig) withWriteTimeout(next http.Handler) http.Handler {
+	timeoutFn := sc.WriteTimeoutFn
+	if timeoutFn == nil {
+		if sc.WriteTimeout <= 0 {
+			return next
+		}
+		writeTimeout := sc.WriteTimeout
+		timeoutFn = func(*http.Request) time.Duration { return writeTimeout }
+	}
+	if next == nil {
+		next = http.DefaultServeMux
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout := timeoutFn(r)
+		if conn, ok := r.Context().Value(netConnContextKey{}).(net.Conn); ok && r.ProtoMajor == 1 {
+			// Always set the deadline (even if it's to "none"), since the connection may
+			// have been used for an earlier request with a different timeout.  Don't clear
+			// it when the handler returns, since net/http may still need to flush the
+			// response.
+			var deadline time.Time
+			if timeout > 0 {
+				deadline = time.Now().Add(timeout)
+			}
+			_ = conn.SetWriteDeadline(deadline)
+		}
+		if timeout <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		tw := &timeoutWriter{ResponseWriter: w}
+		timer := time.AfterFunc(timeout, func() {
+			atomic.StoreInt32(&tw.expired, 1)
+			cancel()
+		})
+		next.ServeHTTP(tw, r.WithContext(ctx))
+		timer.Stop()
+		if tw.rejected {
+			// The handler tried to write after the timeout expired; abort the response so
+			// that the client sees an error rather than a response that looks complete but
+			// is truncated.  (If the handler had already finished writing when the timeout
+			// expired, then the response is complete, and there's nothing to abort.)
+			panic(http.ErrAbortHandler)
+		}
+	})
+}
@@ -0,0 +1,168 @@
+package dhttp_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestWriteTimeoutFn(t *testing.T) {
+	streamingHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 10; i++ {
+			if _, err := io.WriteString(w, "x"); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				// Keep going; the next write will fail.
+			case <-time.After(50 * time.Millisecond):
+			}
+		}
+	})
+	testcases := map[string]struct {
+		Timeout time.Duration
+		Killed  bool
+	}{
+		"killed":    {200 * time.Millisecond, true},
+		"completed": {10 * time.Second, false},
+	}
+	for tcName, tcData := range testcases {
+		tcData := tcData
+		t.Run(tcName, func(t *testing.T) {
+			httpScenarios(t, func(t *testing.T, url string, client *http.Client, server func(context.Context, *dhttp.ServerConfig) error) {
+				ctx, hardCancel := context.WithCancel(dlog.NewTestContext(t, false))
+				defer hardCancel()
+				ctx, softCancel := context.WithCancel(dcontext.WithSoftness(ctx))
+				defer softCancel()
+
+				sc := &dhttp.ServerConfig{
+					Handler: streamingHandler,
+					WriteTimeoutFn: func(r *http.Request) time.Duration {
+						return tcData.Timeout
+					},
+				}
+				serverExited := make(chan struct{})
+				go func() {
+					defer close(serverExited)
+					assert.NoError(t, server(ctx, sc))
+				}()
+
+				resp, err := client.Get(url)
+				if assert.NoError(t, err) {
+					body, err := io.ReadAll(resp.Body)
+					resp.Body.Close()
+					if tcData.Killed {
+						assert.Error(t, err)
+						assert.Less(t, len(body), 10)
+					} else {
+						assert.NoError(t, err)
+						assert.Equal(t, "xxxxxxxxxx", string(body))
+					}
+				}
+
+				softCancel()
+				<-serverExited
+			})
+		})
+	}
+}
+
+func TestWriteTimeoutStalledClient(t *testing.T) {
+	ctx, hardCancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer hardCancel()
+	ctx, softCancel := context.WithCancel(dcontext.WithSoftness(ctx))
+	defer softCancel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	handlerErr := make(chan error, 1)
+	sc := &dhttp.ServerConfig{
+		// A handler that doesn't pay attention to its Context, and just writes until a
+		// write fails.
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			chunk := make([]byte, 64*1024)
+			for {
+				if _, err := w.Write(chunk); err != nil {
+					handlerErr <- err
+					return
+				}
+			}
+		}),
+		WriteTimeout: 200 * time.Millisecond,
+	}
+	serverExited := make(chan struct{})
+	go func() {
+		defer close(serverExited)
+		assert.NoError(t, sc.Serve(ctx, ln))
+	}()
+
+	// A client that sends a request, but never reads the response.
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	_, err = io.WriteString(conn, "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n")
+	assert.NoError(t, err)
+
+	select {
+	case err := <-handlerErr:
+		assert.Error(t, err)
+	case <-time.After(10 * time.Second):
+		t.Error("handler was not interrupted")
+	}
+
+	conn.Close()
+	softCancel()
+	<-serverExited
+}
+
+func TestWriteTimeoutAfterFinished(t *testing.T) {
+	// The handler has finished writing (and flushing) the response by the time that the
+	// timeout expires, but hasn't yet returned; the response should not be aborted.
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "8")
+		_, _ = io.WriteString(w, "complete")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	httpScenarios(t, func(t *testing.T, url string, client *http.Client, server func(context.Context, *dhttp.ServerConfig) error) {
+		ctx, hardCancel := context.WithCancel(dlog.NewTestContext(t, false))
+		defer hardCancel()
+		ctx, softCancel := context.WithCancel(dcontext.WithSoftness(ctx))
+		defer softCancel()
+
+		sc := &dhttp.ServerConfig{
+			Handler:      handler,
+			WriteTimeout: 100 * time.Millisecond,
+		}
+		serverExited := make(chan struct{})
+		go func() {
+			defer close(serverExited)
+			assert.NoError(t, server(ctx, sc))
+		}()
+
+		resp, err := client.Get(url)
+		if assert.NoError(t, err) {
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			assert.NoError(t, err)
+			assert.Equal(t, "complete", string(body))
+		}
+
+		softCancel()
+		<-serverExited
+	})
+}
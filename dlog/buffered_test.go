@@ -0,0 +1,72 @@
+package dlog_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestBufferedLogger(t *testing.T) {
+	var out testLog
+	buf := dlog.NewBufferedLogger(testLogger{log: &out}, 0)
+	ctx := dlog.WithLogger(context.Background(), buf)
+
+	dlog.Info(ctx, "one")
+	dlog.Warn(dlog.WithField(ctx, "k", "v"), "two")
+	dlog.Error(ctx, "three")
+
+	assert.Empty(t, out.entries)
+	assert.Equal(t, 3, buf.Len())
+	assert.Equal(t, 0, buf.Dropped())
+
+	buf.Flush()
+	assert.Equal(t, []testLogEntry{
+		{level: dlog.LogLevelInfo, fields: map[string]interface{}{}, message: "one"},
+		{level: dlog.LogLevelWarn, fields: map[string]interface{}{"k": "v"}, message: "two"},
+		{level: dlog.LogLevelError, fields: map[string]interface{}{}, message: "three"},
+	}, out.entries)
+	assert.Equal(t, 0, buf.Len())
+
+	// Flushing again does nothing.
+	buf.Flush()
+	assert.Len(t, out.entries, 3)
+}
+
+func TestBufferedLoggerMaxEntries(t *testing.T) {
+	var out testLog
+	buf := dlog.NewBufferedLogger(testLogger{log: &out}, 2)
+
+	buf.Log(dlog.LogLevelInfo, "a")
+	buf.Log(dlog.LogLevelInfo, "b")
+	buf.Log(dlog.LogLevelInfo, "c")
+	buf.Log(dlog.LogLevelInfo, "d")
+	assert.Equal(t, 2, buf.Len())
+	assert.Equal(t, 2, buf.Dropped())
+
+	buf.Flush()
+	if !assert.Len(t, out.entries, 2) {
+		return
+	}
+	assert.Equal(t, "c", out.entries[0].message)
+	assert.Equal(t, "d", out.entries[1].message)
+	assert.Equal(t, 2, buf.Dropped())
+}
+
+func TestBufferedLoggerFlushCtx(t *testing.T) {
+	var underlying, other testLog
+	buf := dlog.NewBufferedLogger(testLogger{log: &underlying}, 0)
+	buf.WithField("k", 1).Log(dlog.LogLevelInfo, "msg")
+
+	ctx := dlog.WithLogger(context.Background(), testLogger{log: &other})
+	buf.FlushCtx(dlog.WithField(ctx, "outer", true))
+	assert.Empty(t, underlying.entries)
+	assert.Equal(t, []testLogEntry{
+		{level: dlog.LogLevelInfo, fields: map[string]interface{}{"k": 1, "outer": true}, message: "msg"},
+	}, other.entries)
+
+	buf.FlushCtx(ctx)
+	assert.Len(t, other.entries, 1)
+}
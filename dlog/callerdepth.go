@@ -0,0 +1,53 @@
+package dlog
+
+import (
+	"context"
+
+	"github.com/datawire/dlib/dcontext"
+)
+
+type callerDepthContextKey struct{}
+
+// callerDepthLogger is implemented by Loggers that are able to skip
+// extra stack frames when reporting the caller of a log call.
+type callerDepthLogger interface {
+	withCallerDepth(skip int) Logger
+}
+
+// WithCallerDepth returns a copy of ctx whose Logger skips an extra
+// skip stack frames when reporting which function a log call came
+// from.  This is for library code that wraps dlog: a wrapper that is
+// called by the user and then calls dlog.Info should pass a skip of
+// 1, so that the log line is attributed to the user's call site
+// rather than to the wrapper.
+//
+// WithCallerDepth replaces any skip that was previously set on ctx;
+// use AddCallerDepth to add to it instead.
+//
+// The skip is honored by Loggers created with WrapLogrus (when caller
+// reporting is enabled on the logrus.Logger); other Loggers ignore it.
+func WithCallerDepth(ctx context.Context, skip int) context.Context {
+	ctx = context.WithValue(ctx, callerDepthContextKey{}, skip)
+	return WithLogger(ctx, loggerWithCallerDepth(getLogger(ctx), skip))
+}
+
+// AddCallerDepth is like WithCallerDepth, but adds n to the skip that
+// is already set on ctx, rather than replacing it.  This allows
+// wrappers to be layered on top of each other.
+func AddCallerDepth(ctx context.Context, n int) context.Context {
+	skip, _ := ctx.Value(callerDepthContextKey{}).(int)
+	return WithCallerDepth(ctx, skip+n)
+}
+
+// loggerWithCallerDepth returns a copy of logger that skips skip
+// extra stack frames, or logger itself if it doesn't support that.
+func loggerWithCallerDepth(logger Logger, skip int) Logger {
+	if cl, ok := logger.(callerDepthLogger); ok {
+		return cl.withCallerDepth(skip)
+	}
+	return logger
+}
+
+func init() {
+	dcontext.RegisterDetachable(callerDepthContextKey{})
+}
@@ -0,0 +1,60 @@
+package dlog_test
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+type callerHook struct {
+	callers []string
+}
+
+func (*callerHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *callerHook) Fire(entry *logrus.Entry) error {
+	if entry.Caller == nil {
+		h.callers = append(h.callers, "")
+	} else {
+		h.callers = append(h.callers, entry.Caller.Function)
+	}
+	return nil
+}
+
+func logFromWrapper(ctx context.Context) {
+	dlog.Info(dlog.WithCallerDepth(ctx, 1), "one level")
+}
+
+func logFromOuterWrapper(ctx context.Context) {
+	logFromInnerWrapper(dlog.AddCallerDepth(ctx, 1))
+}
+
+func logFromInnerWrapper(ctx context.Context) {
+	dlog.Info(dlog.AddCallerDepth(ctx, 1), "two levels")
+}
+
+func TestCallerDepth(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.SetReportCaller(true)
+	ctx := dlog.WithLogger(context.Background(), dlog.WrapLogrus(logger))
+	hook := new(callerHook)
+	logger.AddHook(hook)
+
+	const self = "github.com/datawire/dlib/dlog_test.TestCallerDepth"
+
+	logFromWrapper(ctx)
+	logFromOuterWrapper(ctx)
+	logFromOuterWrapper(dlog.WithPrefix(dlog.WithField(ctx, "k", "v"), "prefix: "))
+	dlog.Info(ctx, "no wrapper")
+	dlog.Info(dlog.WithCallerDepth(dlog.WithCallerDepth(ctx, 5), 0), "reset")
+
+	assert.Equal(t, []string{self, self, self, self, self}, hook.callers)
+}
@@ -6,10 +6,28 @@ import (
 	"context"
 	"fmt"
 	"log"
+
+	"github.com/datawire/dlib/dcontext"
 )
 
 type loggerContextKey struct{}
 
+// loggerValue is what is stored in a Context under loggerContextKey.
+// The fields are kept alongside the Logger, rather than under a
+// separate key, so that WithField only adds a single layer to the
+// Context.
+type loggerValue struct {
+	logger Logger
+	// fields are the fields that have been attached with
+	// WithField or WithScope.
+	fields *fieldList
+}
+
+func getLoggerValue(ctx context.Context) *loggerValue {
+	val, _ := ctx.Value(loggerContextKey{}).(*loggerValue)
+	return val
+}
+
 // getLogger returns the logger associated with the Context, or else the fallback logger.
 //
 // You may be asking "Why isn't this exported?  In some cases there might be debug or trace logging
@@ -27,11 +45,11 @@ type loggerContextKey struct{}
 // eachother (often, you'll do the former, not updating the ctx, then later someone passes the ctx
 // to another function, so that function's logger doesn't have the updates).  This is a misuse.
 func getLogger(ctx context.Context) Logger {
-	logger := ctx.Value(loggerContextKey{})
-	if logger == nil {
+	val := getLoggerValue(ctx)
+	if val == nil {
 		return getFallbackLogger()
 	}
-	return logger.(Logger)
+	return val.logger
 }
 
 // WithLogger returns a copy of ctx with logger associated with it,
@@ -44,14 +62,85 @@ func getLogger(ctx context.Context) Logger {
 // If the logger implements OptimizedLogger, then dlog will take
 // advantage of that.
 func WithLogger(ctx context.Context, logger Logger) context.Context {
-	return context.WithValue(ctx, loggerContextKey{}, logger)
+	var fields *fieldList
+	if prev := getLoggerValue(ctx); prev != nil {
+		fields = prev.fields
+	}
+	return context.WithValue(ctx, loggerContextKey{}, &loggerValue{
+		logger: logger,
+		fields: fields,
+	})
 }
 
 // WithField returns a copy of ctx with the logger field key=value
 // associated with it, for future calls to
 // {Trace,Debug,Info,Print,Warn,Error}{f,ln,}() and StdLogger().
 func WithField(ctx context.Context, key string, value interface{}) context.Context {
-	return WithLogger(ctx, getLogger(ctx).WithField(key, value))
+	return withField(ctx, key, value, nil, func(logger Logger) Logger {
+		return logger.WithField(key, value)
+	})
+}
+
+// withField implements WithField and WithScope; wrap returns the
+// Logger to use, given the current one.
+func withField(ctx context.Context, key string, value interface{}, s *scope, wrap func(Logger) Logger) context.Context {
+	logger := getFallbackLogger()
+	var fields *fieldList
+	if prev := getLoggerValue(ctx); prev != nil {
+		logger, fields = prev.logger, prev.fields
+	}
+	return context.WithValue(ctx, loggerContextKey{}, &loggerValue{
+		logger: wrap(logger),
+		fields: &fieldList{
+			parent: fields,
+			key:    key,
+			value:  value,
+			scope:  s,
+		},
+	})
+}
+
+// fieldList is a linked list of the fields that have been attached
+// with WithField, most recent first.
+type fieldList struct {
+	parent *fieldList
+	key    string
+	value  interface{}
+	// scope is non-nil if the field was attached with WithScope.
+	scope *scope
+}
+
+// active returns whether the field should still be attached to log
+// entries; it is false once the field's WithScope has ended.
+func (f *fieldList) active() bool {
+	return f.scope == nil || !f.scope.ended.Load()
+}
+
+func getFieldList(ctx context.Context) *fieldList {
+	if val := getLoggerValue(ctx); val != nil {
+		return val.fields
+	}
+	return nil
+}
+
+// FieldValue returns the value of the logger field key that was most recently associated with
+// ctx by WithField.  The boolean is false if WithField has not been called for that key.
+//
+// This is for things that need to pass the fields along to somewhere other than the Logger (for
+// example, propagating a request ID to an outbound request); it is not aware of fields that were
+// added to a Logger before it was passed to WithLogger.
+func FieldValue(ctx context.Context, key string) (interface{}, bool) {
+	for field := getFieldList(ctx); field != nil; field = field.parent {
+		if field.key == key && field.active() {
+			return field.value, true
+		}
+	}
+	return nil, false
+}
+
+func init() {
+	// Have log fields follow dcontext.Detach.
+	dcontext.RegisterDetachable(loggerContextKey{})
 }
 
 // StdLogger returns a stdlib *log.Logger that uses the Logger
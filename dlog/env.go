@@ -0,0 +1,30 @@
+package dlog
+
+import (
+	"context"
+	"os"
+)
+
+// WithLoggerFromEnv returns a copy of ctx whose Logger discards log
+// entries that are more verbose than the level named by the
+// environment variable envVar (as parsed by ParseLogLevel).  This allows controlling verbosity with
+// something like LOG_LEVEL=debug without changing code.
+//
+// Note that this can only reduce verbosity; it cannot cause entries to
+// be logged that the underlying Logger would itself discard.
+//
+// If the variable is unset or empty, ctx is returned unchanged.  If
+// the variable is set to something that isn't a log level, a warning
+// is logged and ctx is returned unchanged.
+func WithLoggerFromEnv(ctx context.Context, envVar string) context.Context {
+	str := os.Getenv(envVar)
+	if str == "" {
+		return ctx
+	}
+	level, err := ParseLogLevel(str)
+	if err != nil {
+		Warnf(ctx, "ignoring $%s: %v", envVar, err)
+		return ctx
+	}
+	return withMaxLevel(ctx, level)
+}
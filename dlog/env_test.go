@@ -0,0 +1,52 @@
+package dlog_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+func logAllLevels(ctx context.Context) {
+	dlog.Error(ctx, "error")
+	dlog.Warnf(ctx, "%s", "warn")
+	dlog.Infoln(ctx, "info")
+	dlog.Debug(ctx, "debug")
+	dlog.Trace(ctx, "trace")
+}
+
+func TestWithLoggerFromEnv(t *testing.T) {
+	testcases := map[string]struct {
+		Value            string
+		ExpectedMessages []string
+		ExpectedMax      dlog.LogLevel
+	}{
+		"unset":   {Value: "", ExpectedMessages: []string{"error", "warn", "info", "debug", "trace"}, ExpectedMax: dlog.LogLevelTrace},
+		"error":   {Value: "error", ExpectedMessages: []string{"error"}, ExpectedMax: dlog.LogLevelError},
+		"warn":    {Value: "warn", ExpectedMessages: []string{"error", "warn"}, ExpectedMax: dlog.LogLevelWarn},
+		"info":    {Value: "INFO", ExpectedMessages: []string{"error", "warn", "info"}, ExpectedMax: dlog.LogLevelInfo},
+		"debug":   {Value: "Debug", ExpectedMessages: []string{"error", "warn", "info", "debug"}, ExpectedMax: dlog.LogLevelDebug},
+		"trace":   {Value: "trace", ExpectedMessages: []string{"error", "warn", "info", "debug", "trace"}, ExpectedMax: dlog.LogLevelTrace},
+		"invalid": {Value: "verbose", ExpectedMessages: []string{`ignoring $DLOG_TEST_LEVEL: invalid log level: "verbose"`, "error", "warn", "info", "debug", "trace"}, ExpectedMax: dlog.LogLevelTrace},
+	}
+	for tcName, tcData := range testcases {
+		tcData := tcData
+		t.Run(tcName, func(t *testing.T) {
+			t.Setenv("DLOG_TEST_LEVEL", tcData.Value)
+			var log testLog
+			ctx := dlog.WithLogger(context.Background(), testLogger{log: &log})
+			ctx = dlog.WithLoggerFromEnv(ctx, "DLOG_TEST_LEVEL")
+			ctx = dlog.WithField(ctx, "field", "value")
+			logAllLevels(ctx)
+
+			var actualMessages []string
+			for _, entry := range log.entries {
+				actualMessages = append(actualMessages, entry.message)
+			}
+			assert.Equal(t, tcData.ExpectedMessages, actualMessages)
+			assert.Equal(t, tcData.ExpectedMax, dlog.MaxLogLevel(ctx))
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package dlog_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestFieldValue(t *testing.T) {
+	ctx := context.Background()
+	_, ok := dlog.FieldValue(ctx, "a")
+	assert.False(t, ok)
+
+	ctx = dlog.WithField(ctx, "a", 1)
+	ctx = dlog.WithField(ctx, "b", nil)
+	ctx = dlog.WithField(ctx, "a", 2)
+
+	val, ok := dlog.FieldValue(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, val)
+
+	val, ok = dlog.FieldValue(ctx, "b")
+	assert.True(t, ok)
+	assert.Nil(t, val)
+
+	_, ok = dlog.FieldValue(ctx, "c")
+	assert.False(t, ok)
+}
+
+func TestWithFieldSingleLayer(t *testing.T) {
+	depth := func(ctx context.Context) int {
+		return strings.Count(dcontext.TraceContext(ctx), "\n") + 1
+	}
+	ctx := dlog.WithLogger(context.Background(), dlog.NopLogger())
+	before := depth(ctx)
+	ctx = dlog.WithField(ctx, "a", 1)
+	assert.Equal(t, before+1, depth(ctx))
+	ctx, end := dlog.WithScope(ctx, "b", 2)
+	defer end()
+	assert.Equal(t, before+2, depth(ctx))
+
+	// Replacing the Logger keeps the fields.
+	ctx = dlog.WithLogger(ctx, dlog.NopLogger())
+	val, ok := dlog.FieldValue(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, val)
+}
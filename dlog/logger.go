@@ -17,7 +17,11 @@
 package dlog
 
 import (
+	"fmt"
 	"log"
+	"strings"
+
+	"github.com/pkg/errors"
 )
 
 // Logger is a generic logging interface that most loggers implement,
@@ -129,3 +133,36 @@ const (
 	// informational events than the Debug.
 	LogLevelTrace
 )
+
+var logLevelNames = [...]string{
+	LogLevelError: "error",
+	LogLevelWarn:  "warn",
+	LogLevelInfo:  "info",
+	LogLevelDebug: "debug",
+	LogLevelTrace: "trace",
+}
+
+// String returns the lower-case name of the log level ("error",
+// "warn", "info", "debug", or "trace").
+func (level LogLevel) String() string {
+	if level > LogLevelTrace {
+		return fmt.Sprintf("LogLevel(%d)", uint32(level))
+	}
+	return logLevelNames[level]
+}
+
+// ParseLogLevel parses the name of a log level, as returned by
+// LogLevel.String.  It is case-insensitive, and also accepts "warning"
+// as a synonym for "warn".
+func ParseLogLevel(str string) (LogLevel, error) {
+	lower := strings.ToLower(str)
+	if lower == "warning" {
+		return LogLevelWarn, nil
+	}
+	for level, name := range logLevelNames {
+		if lower == name {
+			return LogLevel(level), nil
+		}
+	}
+	return 0, errors.Errorf("invalid log level: %q", str)
+}
@@ -0,0 +1,157 @@
+package dlog
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+)
+
+// BufferedLogger is a Logger that holds on to everything that is logged
+// to it until Flush is called, at which point it forwards the entries to
+// another Logger all at once.  This is useful for keeping a block of
+// related log entries (such as the trace of a single request) together,
+// rather than having them interleaved with unrelated entries.
+type BufferedLogger interface {
+	Logger
+
+	// Flush forwards all buffered entries, in the order that they
+	// were logged, to the Logger that was passed to
+	// NewBufferedLogger, then empties the buffer.  Calling Flush on
+	// an empty buffer is a no-op.
+	Flush()
+
+	// FlushCtx is like Flush, but forwards the entries to the
+	// Logger associated with ctx instead.
+	FlushCtx(ctx context.Context)
+
+	// Len returns the number of entries currently in the buffer.
+	Len() int
+
+	// Dropped returns the total number of entries that have been
+	// discarded because the buffer was full.
+	Dropped() int
+}
+
+type bufferedField struct {
+	key   string
+	value interface{}
+}
+
+type bufferedEntry struct {
+	fields []bufferedField
+	level  LogLevel
+	msg    string
+}
+
+// bufferedState is shared between a bufferedLogger and all of the
+// loggers derived from it with WithField.
+type bufferedState struct {
+	underlying Logger
+	maxEntries int
+
+	mu      sync.Mutex
+	entries []bufferedEntry
+	dropped int
+}
+
+type bufferedLogger struct {
+	state  *bufferedState
+	fields []bufferedField
+}
+
+var _ BufferedLogger = bufferedLogger{}
+
+// NewBufferedLogger returns a BufferedLogger that buffers entries until
+// they are flushed to underlying.  At most maxEntries are held; if
+// another entry is logged while the buffer is full, then the oldest
+// entry is dropped (and counted in Dropped).  A maxEntries of zero or
+// less means that the buffer is unbounded.
+//
+// Loggers derived from the BufferedLogger with WithField share its
+// buffer, so that flushing any of them flushes the entries logged to
+// all of them.
+func NewBufferedLogger(underlying Logger, maxEntries int) BufferedLogger {
+	return bufferedLogger{
+		state: &bufferedState{
+			underlying: underlying,
+			maxEntries: maxEntries,
+		},
+	}
+}
+
+func (l bufferedLogger) Helper() {}
+
+func (l bufferedLogger) WithField(key string, value interface{}) Logger {
+	fields := make([]bufferedField, len(l.fields), len(l.fields)+1)
+	copy(fields, l.fields)
+	return bufferedLogger{
+		state:  l.state,
+		fields: append(fields, bufferedField{key: key, value: value}),
+	}
+}
+
+type bufferedWriter struct {
+	l     bufferedLogger
+	level LogLevel
+}
+
+func (w bufferedWriter) Write(data []byte) (n int, err error) {
+	w.l.Log(w.level, strings.TrimSuffix(string(data), "\n"))
+	return len(data), nil
+}
+
+func (l bufferedLogger) StdLogger(level LogLevel) *log.Logger {
+	return log.New(bufferedWriter{l, level}, "", 0)
+}
+
+func (l bufferedLogger) Log(level LogLevel, msg string) {
+	s := l.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.maxEntries > 0 && len(s.entries) >= s.maxEntries {
+		s.entries = s.entries[1:]
+		s.dropped++
+	}
+	s.entries = append(s.entries, bufferedEntry{
+		fields: l.fields,
+		level:  level,
+		msg:    msg,
+	})
+}
+
+func (l bufferedLogger) Flush() {
+	l.flushTo(l.state.underlying)
+}
+
+func (l bufferedLogger) FlushCtx(ctx context.Context) {
+	l.flushTo(getLogger(ctx))
+}
+
+func (l bufferedLogger) flushTo(out Logger) {
+	s := l.state
+	s.mu.Lock()
+	entries := s.entries
+	s.entries = nil
+	s.mu.Unlock()
+
+	for _, entry := range entries {
+		logger := out
+		for _, field := range entry.fields {
+			logger = logger.WithField(field.key, field.value)
+		}
+		logger.Log(entry.level, entry.msg)
+	}
+}
+
+func (l bufferedLogger) Len() int {
+	l.state.mu.Lock()
+	defer l.state.mu.Unlock()
+	return len(l.state.entries)
+}
+
+func (l bufferedLogger) Dropped() int {
+	l.state.mu.Lock()
+	defer l.state.mu.Unlock()
+	return l.state.dropped
+}
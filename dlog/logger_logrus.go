@@ -1,6 +1,7 @@
 package dlog
 
 import (
+	"context"
 	"io"
 	"log"
 	"runtime"
@@ -12,6 +13,7 @@ import (
 
 type logrusLogger interface {
 	WithField(key string, value interface{}) *logrus.Entry
+	WithContext(ctx context.Context) *logrus.Entry
 	WriterLevel(level logrus.Level) *io.PipeWriter
 	Log(level logrus.Level, args ...interface{})
 	Logln(level logrus.Level, args ...interface{})
@@ -24,6 +26,10 @@ type logrusWrapper struct {
 
 var _ OptimizedLogger = logrusWrapper{}
 
+// logrusCallerDepthKey is the key in the logrus.Entry's Context that
+// holds the skip set by WithCallerDepth, for logrusFixCallerHook.
+type logrusCallerDepthKey struct{}
+
 // Helper does nothing--we use a Logrus Hook instead (see below).
 func (l logrusWrapper) Helper() {}
 
@@ -31,6 +37,14 @@ func (l logrusWrapper) WithField(key string, value interface{}) Logger {
 	return logrusWrapper{l.logrusLogger.WithField(key, value)}
 }
 
+func (l logrusWrapper) withCallerDepth(skip int) Logger {
+	ctx := context.Background()
+	if le, ok := l.logrusLogger.(*logrus.Entry); ok && le.Context != nil {
+		ctx = le.Context
+	}
+	return logrusWrapper{l.logrusLogger.WithContext(context.WithValue(ctx, logrusCallerDepthKey{}, skip))}
+}
+
 var dlogLevel2logrusLevel = [5]logrus.Level{
 	logrus.ErrorLevel,
 	logrus.WarnLevel,
@@ -106,7 +120,11 @@ func (logrusFixCallerHook) Levels() []logrus.Level {
 
 func (logrusFixCallerHook) Fire(entry *logrus.Entry) error {
 	if entry.Caller != nil && strings.HasPrefix(entry.Caller.Function, dlogPackage+".") {
-		entry.Caller = getCaller()
+		skip := 0
+		if entry.Context != nil {
+			skip, _ = entry.Context.Value(logrusCallerDepthKey{}).(int)
+		}
+		entry.Caller = getCaller(skip)
 	}
 	return nil
 }
@@ -122,7 +140,10 @@ const (
 // kind if skip/.Helper() functionality that testing.TB has.
 //
 // https://github.com/sirupsen/logrus/issues/972
-func getCaller() *runtime.Frame {
+//
+// skip is the number of additional frames to skip after the dlog and
+// logrus frames, as set by WithCallerDepth.
+func getCaller(skip int) *runtime.Frame {
 	// Restrict the lookback frames to avoid runaway lookups
 	pcs := make([]uintptr, maximumCallerDepth)
 	depth := runtime.Callers(minimumCallerDepth, pcs)
@@ -136,6 +157,10 @@ func getCaller() *runtime.Frame {
 		if strings.HasPrefix(f.Function, dlogPackage+".") {
 			continue
 		}
+		if skip > 0 {
+			skip--
+			continue
+		}
 		return &f //nolint:scopelint
 	}
 
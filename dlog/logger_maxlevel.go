@@ -0,0 +1,90 @@
+package dlog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+)
+
+// maxLevelLogger wraps a Logger, discarding entries that are more
+// verbose than max.
+type maxLevelLogger struct {
+	Logger
+	max LogLevel
+}
+
+var _ OptimizedLogger = maxLevelLogger{}
+var _ LoggerWithMaxLevel = maxLevelLogger{}
+
+// withMaxLevel returns a copy of ctx whose Logger discards entries
+// that are more verbose than max.
+func withMaxLevel(ctx context.Context, max LogLevel) context.Context {
+	return WithLogger(ctx, maxLevelLogger{Logger: getLogger(ctx), max: max})
+}
+
+func (l maxLevelLogger) WithField(key string, value interface{}) Logger {
+	return maxLevelLogger{Logger: l.Logger.WithField(key, value), max: l.max}
+}
+
+func (l maxLevelLogger) withCallerDepth(skip int) Logger {
+	return maxLevelLogger{Logger: loggerWithCallerDepth(l.Logger, skip), max: l.max}
+}
+
+func (l maxLevelLogger) StdLogger(level LogLevel) *log.Logger {
+	if level > l.max && level <= LogLevelTrace {
+		return log.New(io.Discard, "", 0)
+	}
+	return l.Logger.StdLogger(level)
+}
+
+func (l maxLevelLogger) Log(level LogLevel, msg string) {
+	if level > l.max && level <= LogLevelTrace {
+		return
+	}
+	l.Logger.Helper()
+	l.Logger.Log(level, msg)
+}
+
+func (l maxLevelLogger) MaxLevel() LogLevel {
+	if inner, ok := l.Logger.(LoggerWithMaxLevel); ok && inner.MaxLevel() < l.max {
+		return inner.MaxLevel()
+	}
+	return l.max
+}
+
+func (l maxLevelLogger) UnformattedLog(level LogLevel, args ...interface{}) {
+	if level > l.max && level <= LogLevelTrace {
+		return
+	}
+	l.Logger.Helper()
+	if opt, ok := l.Logger.(OptimizedLogger); ok {
+		opt.UnformattedLog(level, args...)
+	} else {
+		l.Logger.Log(level, fmt.Sprint(args...))
+	}
+}
+
+func (l maxLevelLogger) UnformattedLogln(level LogLevel, args ...interface{}) {
+	if level > l.max && level <= LogLevelTrace {
+		return
+	}
+	l.Logger.Helper()
+	if opt, ok := l.Logger.(OptimizedLogger); ok {
+		opt.UnformattedLogln(level, args...)
+	} else {
+		l.Logger.Log(level, sprintln(args...))
+	}
+}
+
+func (l maxLevelLogger) UnformattedLogf(level LogLevel, format string, args ...interface{}) {
+	if level > l.max && level <= LogLevelTrace {
+		return
+	}
+	l.Logger.Helper()
+	if opt, ok := l.Logger.(OptimizedLogger); ok {
+		opt.UnformattedLogf(level, format, args...)
+	} else {
+		l.Logger.Log(level, fmt.Sprintf(format, args...))
+	}
+}
@@ -0,0 +1,33 @@
+package dlog
+
+import (
+	"context"
+	"io"
+	"log"
+)
+
+// nopLogger is a Logger that discards everything.
+type nopLogger struct{}
+
+var _ OptimizedLogger = nopLogger{}
+
+// NopLogger returns a Logger that silently discards all log entries.
+func NopLogger() Logger {
+	return nopLogger{}
+}
+
+// NewNopContext returns a copy of ctx whose Logger silently discards
+// all log entries.  Unlike NewTestContext, it does not need a
+// testing.TB, so it may be used outside of tests; for example, to run
+// library code that logs more than a caller cares to see.
+func NewNopContext(ctx context.Context) context.Context {
+	return WithLogger(ctx, nopLogger{})
+}
+
+func (nopLogger) Helper()                                          {}
+func (l nopLogger) WithField(string, interface{}) Logger           { return l }
+func (nopLogger) StdLogger(LogLevel) *log.Logger                   { return log.New(io.Discard, "", 0) }
+func (nopLogger) Log(LogLevel, string)                             {}
+func (nopLogger) UnformattedLog(LogLevel, ...interface{})          {}
+func (nopLogger) UnformattedLogln(LogLevel, ...interface{})        {}
+func (nopLogger) UnformattedLogf(LogLevel, string, ...interface{}) {}
@@ -0,0 +1,69 @@
+package dlog
+
+import (
+	"context"
+	"log"
+	"strings"
+)
+
+// prefixLogger wraps a Logger, prepending prefix to every message.
+type prefixLogger struct {
+	Logger
+	prefix string
+}
+
+var _ LoggerWithMaxLevel = prefixLogger{}
+
+// WithPrefix returns a copy of ctx whose Logger prepends prefix to
+// every message that is logged, for example:
+//
+//	ctx = dlog.WithPrefix(ctx, "[dns-resolver] ")
+//
+// Calls to WithPrefix nest; the prefix from the earlier (inner) call
+// comes first, so that
+//
+//	dlog.Info(dlog.WithPrefix(dlog.WithPrefix(ctx, "a: "), "b: "), "msg")
+//
+// logs "a: b: msg".
+//
+// The prefix is applied by the Logger.Log method, before the message
+// is handed to the underlying Logger, so it works with any Logger
+// implementation.
+func WithPrefix(ctx context.Context, prefix string) context.Context {
+	return WithLogger(ctx, prefixLogger{Logger: getLogger(ctx), prefix: prefix})
+}
+
+func (l prefixLogger) WithField(key string, value interface{}) Logger {
+	return prefixLogger{Logger: l.Logger.WithField(key, value), prefix: l.prefix}
+}
+
+func (l prefixLogger) withCallerDepth(skip int) Logger {
+	return prefixLogger{Logger: loggerWithCallerDepth(l.Logger, skip), prefix: l.prefix}
+}
+
+type prefixWriter struct {
+	l     prefixLogger
+	level LogLevel
+}
+
+func (w prefixWriter) Write(data []byte) (n int, err error) {
+	w.l.Helper()
+	w.l.Log(w.level, strings.TrimSuffix(string(data), "\n"))
+	return len(data), nil
+}
+
+func (l prefixLogger) StdLogger(level LogLevel) *log.Logger {
+	return log.New(prefixWriter{l, level}, "", 0)
+}
+
+func (l prefixLogger) Log(level LogLevel, msg string) {
+	l.Logger.Helper()
+	l.Logger.Log(level, l.prefix+msg)
+}
+
+func (l prefixLogger) MaxLevel() LogLevel {
+	if inner, ok := l.Logger.(LoggerWithMaxLevel); ok {
+		return inner.MaxLevel()
+	}
+	return LogLevelTrace
+}
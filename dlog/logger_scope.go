@@ -0,0 +1,106 @@
+package dlog
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// scope is shared by everything derived from a single WithScope call.
+type scope struct {
+	ended atomic.Bool
+}
+
+// scopeLogger wraps two Loggers, one with the scoped field and one
+// without, and switches from the former to the latter once the scope
+// has ended.
+type scopeLogger struct {
+	inScope  Logger
+	outScope Logger
+	scope    *scope
+}
+
+var _ LoggerWithMaxLevel = scopeLogger{}
+
+// WithScope returns a copy of ctx with the logger field key=value
+// associated with it (just like WithField), along with a function
+// that ends the scope.  Once the function has been called, log
+// entries no longer carry the field, even if they are logged using
+// the returned Context (or a Context derived from it); FieldValue
+// stops reporting it too.  This mirrors how context.WithCancel works:
+//
+//	ctx, end := dlog.WithScope(ctx, "span", spanID)
+//	defer end()
+//
+// Calling the function more than once is harmless.  Fields added
+// with WithField on top of the scoped Context are unaffected by
+// ending the scope.
+func WithScope(ctx context.Context, key string, value interface{}) (context.Context, func()) {
+	s := new(scope)
+	ctx = withField(ctx, key, value, s, func(outer Logger) Logger {
+		return scopeLogger{
+			inScope:  outer.WithField(key, value),
+			outScope: outer,
+			scope:    s,
+		}
+	})
+	return ctx, func() { s.ended.Store(true) }
+}
+
+func (l scopeLogger) current() Logger {
+	if l.scope.ended.Load() {
+		return l.outScope
+	}
+	return l.inScope
+}
+
+func (l scopeLogger) Helper() {
+	l.current().Helper()
+}
+
+func (l scopeLogger) WithField(key string, value interface{}) Logger {
+	return scopeLogger{
+		inScope:  l.inScope.WithField(key, value),
+		outScope: l.outScope.WithField(key, value),
+		scope:    l.scope,
+	}
+}
+
+func (l scopeLogger) withCallerDepth(skip int) Logger {
+	return scopeLogger{
+		inScope:  loggerWithCallerDepth(l.inScope, skip),
+		outScope: loggerWithCallerDepth(l.outScope, skip),
+		scope:    l.scope,
+	}
+}
+
+type scopeWriter struct {
+	l     scopeLogger
+	level LogLevel
+}
+
+func (w scopeWriter) Write(data []byte) (n int, err error) {
+	w.l.Helper()
+	w.l.Log(w.level, strings.TrimSuffix(string(data), "\n"))
+	return len(data), nil
+}
+
+func (l scopeLogger) StdLogger(level LogLevel) *log.Logger {
+	// Don't just return l.current().StdLogger(level); the
+	// *log.Logger may outlive the scope.
+	return log.New(scopeWriter{l, level}, "", 0)
+}
+
+func (l scopeLogger) Log(level LogLevel, msg string) {
+	cur := l.current()
+	cur.Helper()
+	cur.Log(level, msg)
+}
+
+func (l scopeLogger) MaxLevel() LogLevel {
+	if inner, ok := l.current().(LoggerWithMaxLevel); ok {
+		return inner.MaxLevel()
+	}
+	return LogLevelTrace
+}
@@ -3,9 +3,11 @@ package dlog
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -14,15 +16,32 @@ import (
 
 type tbWrapper struct {
 	testing.TB
-	failOnError   bool
-	logTimestamps bool
-	fields        map[string]interface{}
+	failOnError       bool
+	logTimestamps     bool
+	timestampFormat   string
+	timestampLocation *time.Location
+	fields            map[string]interface{}
+	mirror            *mirrorWriter
+}
+
+// mirrorWriter serializes writes to a WithMirrorWriter writer, since
+// tests commonly log from multiple goroutines.
+type mirrorWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (m *mirrorWriter) writeLine(line string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, _ = io.WriteString(m.w, line+"\n")
 }
 
 func (w tbWrapper) WithField(key string, value interface{}) Logger {
 	ret := tbWrapper{
 		TB:     w.TB,
 		fields: make(map[string]interface{}, len(w.fields)+1),
+		mirror: w.mirror,
 	}
 	for k, v := range w.fields {
 		ret.fields[k] = v
@@ -51,7 +70,11 @@ func (w tbWrapper) Log(level LogLevel, msg string) {
 	}
 
 	if w.logTimestamps {
-		fields["timestamp"] = time.Now().Format("2006-01-02 15:04:05.0000")
+		now := time.Now()
+		if w.timestampLocation != nil {
+			now = now.In(w.timestampLocation)
+		}
+		fields["timestamp"] = now.Format(w.timestampFormat)
 	}
 
 	parts := make([]string, 0, len(fields))
@@ -64,6 +87,9 @@ func (w tbWrapper) Log(level LogLevel, msg string) {
 	}
 	str := strings.Join(parts, " ")
 
+	if w.mirror != nil {
+		w.mirror.writeLine(str)
+	}
 	switch level {
 	case LogLevelError:
 		if w.failOnError {
@@ -89,11 +115,19 @@ func WrapTB(in testing.TB, failOnError bool) Logger {
 	return wrapTB(in, WithFailOnError(failOnError))
 }
 
+// WrapTBWithFields is like WrapTB, but the returned Logger starts out with the given fields already
+// set, as if WithField had been called for each of them.  This is useful for carrying the fields
+// of an outer Context over to a sub-test; see also WithFieldsFrom.
+func WrapTBWithFields(in testing.TB, failOnError bool, fields map[string]interface{}) Logger {
+	return wrapTB(in, WithFailOnError(failOnError), WithFields(fields))
+}
+
 func wrapTB(in testing.TB, opts ...TestContextOption) Logger {
 	wrapper := tbWrapper{
-		TB:            in,
-		fields:        map[string]interface{}{},
-		logTimestamps: true,
+		TB:              in,
+		fields:          map[string]interface{}{},
+		logTimestamps:   true,
+		timestampFormat: "2006-01-02 15:04:05.0000",
 	}
 	for _, opt := range opts {
 		opt(&wrapper)
@@ -136,13 +170,72 @@ func WithTimestampLogging(logTimestamps bool) TestContextOption {
 	}
 }
 
+// WithTimestampFormat sets the layout (as for time.Time.Format) that a test context uses for the
+// timestamp field.  It has no effect if timestamp logging is disabled with WithTimestampLogging.
+// If not given, defaults to "2006-01-02 15:04:05.0000".
+func WithTimestampFormat(layout string) TestContextOption {
+	return func(w *tbWrapper) {
+		w.timestampFormat = layout
+	}
+}
+
+// WithTimestampLocation sets the time zone that a test context uses for the timestamp field; for
+// example, time.UTC.  It has no effect if timestamp logging is disabled with WithTimestampLogging.
+// If not given, defaults to time.Local.
+func WithTimestampLocation(loc *time.Location) TestContextOption {
+	return func(w *tbWrapper) {
+		w.timestampLocation = loc
+	}
+}
+
+// WithMirrorWriter sets a test context to also write each log line to w (followed by a newline), in
+// addition to passing it to the testing.TB's Log or Error method; for example, to save the logs to
+// a file for post-mortem analysis.  Writes to w are serialized, so w need not be safe for
+// concurrent use, but w must not be used for anything else until the test is over.  Errors writing
+// to w are ignored.
+func WithMirrorWriter(w io.Writer) TestContextOption {
+	return func(wrapper *tbWrapper) {
+		wrapper.mirror = &mirrorWriter{w: w}
+	}
+}
+
+// WithFields sets initial fields on a test context's logger, as if WithField had been called for
+// each of them.
+func WithFields(fields map[string]interface{}) TestContextOption {
+	return func(w *tbWrapper) {
+		for k, v := range fields {
+			w.fields[k] = v
+		}
+	}
+}
+
+// WithFieldsFrom sets initial fields on a test context's logger to the fields that were attached to
+// ctx with WithField (see FieldValue).  This allows a sub-test to get its own test context without
+// losing the fields of the parent test's context:
+//
+//	t.Run("sub", func(t *testing.T) {
+//		ctx := dlog.NewTestContextWithOpts(t, dlog.WithFieldsFrom(ctx))
+//		...
+//	})
+func WithFieldsFrom(ctx context.Context) TestContextOption {
+	fields := make(map[string]interface{})
+	for field := getFieldList(ctx); field != nil; field = field.parent {
+		if _, shadowed := fields[field.key]; !shadowed && field.active() {
+			fields[field.key] = field.value
+		}
+	}
+	return WithFields(fields)
+}
+
 // NewTestContext is like NewTestContextWithOpts but allows for the failOnError option to be set
-// as a boolean. It is kept for backward-compatibility, new code should prefer NewTestContextWithOpts
+// as a boolean. It is kept for backward-compatibility, new code should prefer NewTestContextWithOpts.
+// Like NewTestContextWithOpts, it accepts any testing.TB, so it may be used from benchmarks and
+// fuzz targets as well as from tests.
 func NewTestContext(t testing.TB, failOnError bool) context.Context {
 	return NewTestContextWithOpts(t, WithFailOnError(failOnError))
 }
 
-// NewTestContextWithOpts takes a testing.TB (that is: either a *testing.T or a *testing.B) and returns a
+// NewTestContextWithOpts takes a testing.TB (that is: a *testing.T, *testing.B, or *testing.F) and returns a
 // good default Context to use in unit test.  The Context will have dlog configured to log using the
 // Go test runner's built-in logging facilities.  The context will be canceled when the test
 // terminates.  The failOnError argument controls whether calling any of the dlog.Error{,f,ln}
@@ -0,0 +1,56 @@
+package dlog_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestLogLevelString(t *testing.T) {
+	assert.Equal(t, "error", dlog.LogLevelError.String())
+	assert.Equal(t, "warn", dlog.LogLevelWarn.String())
+	assert.Equal(t, "info", dlog.LogLevelInfo.String())
+	assert.Equal(t, "debug", dlog.LogLevelDebug.String())
+	assert.Equal(t, "trace", dlog.LogLevelTrace.String())
+	assert.Equal(t, "LogLevel(5)", dlog.LogLevel(5).String())
+}
+
+func TestParseLogLevel(t *testing.T) {
+	testcases := map[string]struct {
+		Input       string
+		Expected    dlog.LogLevel
+		ExpectedErr string
+	}{
+		"error":      {Input: "error", Expected: dlog.LogLevelError},
+		"warn":       {Input: "warn", Expected: dlog.LogLevelWarn},
+		"warning":    {Input: "Warning", Expected: dlog.LogLevelWarn},
+		"info":       {Input: "INFO", Expected: dlog.LogLevelInfo},
+		"debug":      {Input: "Debug", Expected: dlog.LogLevelDebug},
+		"trace":      {Input: "trace", Expected: dlog.LogLevelTrace},
+		"empty":      {Input: "", ExpectedErr: `invalid log level: ""`},
+		"unknown":    {Input: "fatal", ExpectedErr: `invalid log level: "fatal"`},
+		"number":     {Input: "2", ExpectedErr: `invalid log level: "2"`},
+		"whitespace": {Input: " info", ExpectedErr: `invalid log level: " info"`},
+	}
+	for tcName, tcData := range testcases {
+		tcData := tcData
+		t.Run(tcName, func(t *testing.T) {
+			actual, err := dlog.ParseLogLevel(tcData.Input)
+			if tcData.ExpectedErr != "" {
+				assert.EqualError(t, err, tcData.ExpectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tcData.Expected, actual)
+		})
+	}
+
+	// Round-trip
+	for level := dlog.LogLevelError; level <= dlog.LogLevelTrace; level++ {
+		parsed, err := dlog.ParseLogLevel(level.String())
+		assert.NoError(t, err)
+		assert.Equal(t, level, parsed)
+	}
+}
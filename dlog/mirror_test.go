@@ -0,0 +1,28 @@
+package dlog_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestWithMirrorWriter(t *testing.T) {
+	var lines []string
+	var mirror bytes.Buffer
+	ctx := dlog.NewTestContextWithOpts(capturingTB{TB: t, lines: &lines},
+		dlog.WithMirrorWriter(&mirror))
+
+	dlog.Info(ctx, "first")
+	dlog.Warnf(dlog.WithField(ctx, "key", "value"), "second %d", 2)
+	dlog.StdLogger(ctx, dlog.LogLevelDebug).Print("third")
+
+	if !assert.Len(t, lines, 3) {
+		return
+	}
+	assert.Equal(t, strings.Join(lines, "\n")+"\n", mirror.String())
+	assert.Contains(t, lines[1], `key="value"`)
+}
@@ -0,0 +1,39 @@
+package dlog_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestNewNopContext(t *testing.T) {
+	var out testLog
+	ctx := dlog.WithLogger(context.Background(), testLogger{log: &out})
+	ctx = dlog.WithField(ctx, "k", "v")
+
+	nopCtx := dlog.NewNopContext(ctx)
+	dlog.Error(nopCtx, "error")
+	dlog.Infof(nopCtx, "info %d", 1)
+	dlog.Debugln(dlog.WithField(nopCtx, "k2", "v2"), "debug")
+	dlog.StdLogger(nopCtx, dlog.LogLevelInfo).Print("std")
+	assert.Empty(t, out.entries)
+
+	// Fields set on the parent Context are still there.
+	val, ok := dlog.FieldValue(nopCtx, "k")
+	assert.True(t, ok)
+	assert.Equal(t, "v", val)
+
+	// The parent Context's logger is unaffected.
+	dlog.Info(ctx, "visible")
+	assert.Len(t, out.entries, 1)
+}
+
+func TestNopLogger(t *testing.T) {
+	logger := dlog.NopLogger()
+	logger.Log(dlog.LogLevelError, "msg")
+	logger.WithField("k", "v").Log(dlog.LogLevelInfo, "msg")
+	logger.StdLogger(dlog.LogLevelInfo).Print("msg")
+}
@@ -0,0 +1,50 @@
+package dlog_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestWithPrefix(t *testing.T) {
+	var log testLog
+	ctx := dlog.WithLogger(context.Background(), testLogger{log: &log})
+
+	dlog.Info(dlog.WithPrefix(ctx, "[a] "), "plain")
+	dlog.Infof(dlog.WithPrefix(ctx, "[a] "), "%s", "formatted")
+	dlog.Infoln(dlog.WithPrefix(ctx, "[a] "), "with", "newline")
+
+	nested := dlog.WithPrefix(dlog.WithPrefix(ctx, "[a] "), "[b] ")
+	dlog.Warn(nested, "nested")
+
+	withField := dlog.WithField(nested, "field", "value")
+	dlog.Error(withField, "field")
+
+	dlog.StdLogger(nested, dlog.LogLevelDebug).Print("std")
+
+	assert.Equal(t, []testLogEntry{
+		{level: dlog.LogLevelInfo, fields: map[string]interface{}{}, message: "[a] plain"},
+		{level: dlog.LogLevelInfo, fields: map[string]interface{}{}, message: "[a] formatted"},
+		{level: dlog.LogLevelInfo, fields: map[string]interface{}{}, message: "[a] with newline"},
+		{level: dlog.LogLevelWarn, fields: map[string]interface{}{}, message: "[a] [b] nested"},
+		{level: dlog.LogLevelError, fields: map[string]interface{}{"field": "value"}, message: "[a] [b] field"},
+		{level: dlog.LogLevelDebug, fields: map[string]interface{}{}, message: "[a] [b] std"},
+	}, log.entries)
+}
+
+func TestWithPrefixMaxLevel(t *testing.T) {
+	t.Setenv("DLOG_TEST_LEVEL", "warn")
+	var log testLog
+	ctx := dlog.WithLogger(context.Background(), testLogger{log: &log})
+	ctx = dlog.WithLoggerFromEnv(ctx, "DLOG_TEST_LEVEL")
+	ctx = dlog.WithPrefix(ctx, "[a] ")
+	assert.Equal(t, dlog.LogLevelWarn, dlog.MaxLogLevel(ctx))
+	logAllLevels(ctx)
+	assert.Equal(t, []testLogEntry{
+		{level: dlog.LogLevelError, fields: map[string]interface{}{}, message: "[a] error"},
+		{level: dlog.LogLevelWarn, fields: map[string]interface{}{}, message: "[a] warn"},
+	}, log.entries)
+}
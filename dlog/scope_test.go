@@ -0,0 +1,58 @@
+package dlog_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestWithScope(t *testing.T) {
+	var log testLog
+	ctx := dlog.WithLogger(context.Background(), testLogger{log: &log})
+	ctx = dlog.WithField(ctx, "span", "outer")
+
+	scoped, end := dlog.WithScope(ctx, "span", "inner")
+	derived := dlog.WithField(scoped, "other", "value")
+	std := dlog.StdLogger(scoped, dlog.LogLevelInfo)
+
+	dlog.Info(scoped, "in scope")
+	dlog.Info(derived, "derived in scope")
+	std.Print("std in scope")
+	val, ok := dlog.FieldValue(scoped, "span")
+	assert.True(t, ok)
+	assert.Equal(t, "inner", val)
+
+	end()
+	end() // calling it twice is harmless
+
+	dlog.Info(ctx, "parent")
+	dlog.Info(scoped, "after scope")
+	dlog.Info(derived, "derived after scope")
+	std.Print("std after scope")
+	val, ok = dlog.FieldValue(scoped, "span")
+	assert.True(t, ok)
+	assert.Equal(t, "outer", val)
+
+	assert.Equal(t, []testLogEntry{
+		{level: dlog.LogLevelInfo, fields: map[string]interface{}{"span": "inner"}, message: "in scope"},
+		{level: dlog.LogLevelInfo, fields: map[string]interface{}{"span": "inner", "other": "value"}, message: "derived in scope"},
+		{level: dlog.LogLevelInfo, fields: map[string]interface{}{"span": "inner"}, message: "std in scope"},
+		{level: dlog.LogLevelInfo, fields: map[string]interface{}{"span": "outer"}, message: "parent"},
+		{level: dlog.LogLevelInfo, fields: map[string]interface{}{"span": "outer"}, message: "after scope"},
+		{level: dlog.LogLevelInfo, fields: map[string]interface{}{"span": "outer", "other": "value"}, message: "derived after scope"},
+		{level: dlog.LogLevelInfo, fields: map[string]interface{}{"span": "outer"}, message: "std after scope"},
+	}, log.entries)
+}
+
+func TestWithScopeFieldValue(t *testing.T) {
+	ctx, end := dlog.WithScope(context.Background(), "span", "x")
+	val, ok := dlog.FieldValue(ctx, "span")
+	assert.True(t, ok)
+	assert.Equal(t, "x", val)
+	end()
+	_, ok = dlog.FieldValue(ctx, "span")
+	assert.False(t, ok)
+}
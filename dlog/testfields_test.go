@@ -0,0 +1,68 @@
+package dlog_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+// capturingTB is a testing.TB that records what is passed to Log instead of printing it.
+type capturingTB struct {
+	testing.TB
+	lines *[]string
+}
+
+func (tb capturingTB) Helper() {}
+
+func (tb capturingTB) Log(args ...interface{}) {
+	*tb.lines = append(*tb.lines, fmt.Sprint(args...))
+}
+
+func TestWrapTBWithFields(t *testing.T) {
+	var lines []string
+	logger := dlog.WrapTBWithFields(capturingTB{TB: t, lines: &lines}, false, map[string]interface{}{
+		"a": 1,
+	})
+	logger.Log(dlog.LogLevelInfo, "msg")
+	if !assert.Len(t, lines, 1) {
+		return
+	}
+	assert.Contains(t, lines[0], "msg")
+	assert.Contains(t, lines[0], "a=1")
+}
+
+func TestWithFieldsFrom(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	ctx = dlog.WithField(ctx, "outer", "x")
+	ctx = dlog.WithField(ctx, "shadowed", "old")
+	ctx = dlog.WithField(ctx, "shadowed", "new")
+
+	t.Run("sub", func(t *testing.T) {
+		var lines []string
+		subCtx := dlog.NewTestContextWithOpts(capturingTB{TB: t, lines: &lines},
+			dlog.WithFieldsFrom(ctx),
+			dlog.WithTimestampLogging(false))
+		dlog.Info(subCtx, "msg")
+		if !assert.Len(t, lines, 1) {
+			return
+		}
+		assert.Contains(t, lines[0], `outer="x"`)
+		assert.Contains(t, lines[0], `shadowed="new"`)
+		assert.NotContains(t, lines[0], `"old"`)
+	})
+}
+
+func TestWithFieldsFromEmpty(t *testing.T) {
+	var lines []string
+	ctx := dlog.NewTestContextWithOpts(capturingTB{TB: t, lines: &lines},
+		dlog.WithFieldsFrom(context.Background()))
+	dlog.Info(ctx, "msg")
+	if !assert.Len(t, lines, 1) {
+		return
+	}
+	assert.Contains(t, lines[0], "msg")
+}
@@ -0,0 +1,46 @@
+package dlog_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestWithTimestampFormat(t *testing.T) {
+	var lines []string
+	ctx := dlog.NewTestContextWithOpts(capturingTB{TB: t, lines: &lines},
+		dlog.WithTimestampFormat(time.RFC3339Nano),
+		dlog.WithTimestampLocation(time.UTC))
+	dlog.Info(ctx, "msg")
+	if !assert.Len(t, lines, 1) {
+		return
+	}
+	re := regexp.MustCompile(`timestamp="([^"]*)"`)
+	for _, line := range lines {
+		match := re.FindStringSubmatch(line)
+		if !assert.NotNil(t, match, line) {
+			continue
+		}
+		ts, err := time.Parse(time.RFC3339Nano, match[1])
+		assert.NoError(t, err)
+		assert.Less(t, time.Since(ts), time.Minute)
+		assert.Regexp(t, `Z$`, match[1])
+	}
+}
+
+func TestWithTimestampLocation(t *testing.T) {
+	loc := time.FixedZone("TEST", -7*60*60)
+	var lines []string
+	ctx := dlog.NewTestContextWithOpts(capturingTB{TB: t, lines: &lines},
+		dlog.WithTimestampFormat("MST -0700"),
+		dlog.WithTimestampLocation(loc))
+	dlog.Info(ctx, "msg")
+	if !assert.Len(t, lines, 1) {
+		return
+	}
+	assert.Contains(t, lines[0], `timestamp="TEST -0700"`)
+}
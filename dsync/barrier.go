@@ -0,0 +1,78 @@
+package dsync
+
+import (
+	"context"
+	"sync"
+)
+
+// A Barrier blocks a fixed number of goroutines ("parties") until all
+// of them have arrived, and then releases them all at once.  A Barrier
+// is reusable: once a wave of parties has been released (or broken,
+// see Wait), the next call to Wait starts a new wave.
+type Barrier struct {
+	parties int
+
+	mu   sync.Mutex
+	wave *barrierWave
+}
+
+type barrierWave struct {
+	arrived int
+	done    chan struct{}
+	err     error
+}
+
+// NewBarrier returns a Barrier that waits for the given number of
+// parties.  It panics if parties < 1.
+func NewBarrier(parties int) *Barrier {
+	if parties < 1 {
+		panic("dsync.NewBarrier: parties must be at least 1")
+	}
+	return &Barrier{parties: parties}
+}
+
+// Wait blocks until all parties have called Wait, and then returns
+// nil.
+//
+// If the Context of any of the waiting parties is done before all of
+// the parties have arrived, then the wave is broken: all of the
+// parties that are waiting return that Context's error, and the
+// Barrier is reset so that subsequent calls to Wait start a new wave.
+func (b *Barrier) Wait(ctx context.Context) error {
+	b.mu.Lock()
+	if err := ctx.Err(); err != nil {
+		b.mu.Unlock()
+		return err
+	}
+	wave := b.wave
+	if wave == nil {
+		wave = &barrierWave{
+			done: make(chan struct{}),
+		}
+		b.wave = wave
+	}
+	wave.arrived++
+	if wave.arrived == b.parties {
+		b.wave = nil
+		close(wave.done)
+		b.mu.Unlock()
+		return nil
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-wave.done:
+		return wave.err
+	case <-ctx.Done():
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if b.wave == wave {
+			wave.err = ctx.Err()
+			b.wave = nil
+			close(wave.done)
+		}
+		// If the wave was completed (or broken) while we were
+		// acquiring the lock, report that outcome instead.
+		return wave.err
+	}
+}
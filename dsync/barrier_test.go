@@ -0,0 +1,81 @@
+package dsync_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dgroup"
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/dlib/dsync"
+)
+
+func TestBarrier(t *testing.T) {
+	const parties = 10
+	barrier := dsync.NewBarrier(parties)
+
+	for wave := 0; wave < 2; wave++ {
+		var mu sync.Mutex
+		var released []time.Time
+
+		grp := dgroup.NewGroup(dlog.NewTestContext(t, true), dgroup.GroupConfig{})
+		for i := 0; i < parties; i++ {
+			i := i
+			grp.Go(fmt.Sprintf("party-%d", i), func(ctx context.Context) error {
+				// Stagger the arrivals.
+				time.Sleep(time.Duration(i) * 10 * time.Millisecond)
+				if err := barrier.Wait(ctx); err != nil {
+					return err
+				}
+				mu.Lock()
+				released = append(released, time.Now())
+				mu.Unlock()
+				return nil
+			})
+		}
+		if !assert.NoError(t, grp.Wait()) {
+			return
+		}
+
+		if assert.Len(t, released, parties) {
+			first, last := released[0], released[0]
+			for _, ts := range released {
+				if ts.Before(first) {
+					first = ts
+				}
+				if ts.After(last) {
+					last = ts
+				}
+			}
+			// The arrivals are spread over 90ms; the releases
+			// should be much closer together than that.
+			assert.Less(t, last.Sub(first), 50*time.Millisecond)
+		}
+	}
+}
+
+func TestBarrierCanceled(t *testing.T) {
+	barrier := dsync.NewBarrier(3)
+
+	errs := make(chan error, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { errs <- barrier.Wait(context.Background()) }()
+	go func() { errs <- barrier.Wait(ctx) }()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	assert.Equal(t, context.Canceled, <-errs)
+	assert.Equal(t, context.Canceled, <-errs)
+
+	// The barrier has been reset, and the next wave works.
+	done := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() { done <- barrier.Wait(context.Background()) }()
+	}
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, <-done)
+	}
+}
@@ -0,0 +1,117 @@
+package dsync
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Cond is like sync.Cond, but Wait takes a Context, so that waiting may
+// be abandoned.  Waiters are woken in FIFO order.
+//
+// A Cond must not be copied after first use.
+type Cond struct {
+	// L is held while observing or changing the condition.
+	L sync.Locker
+
+	mu      sync.Mutex
+	waiters []chan struct{}
+}
+
+// NewCond returns a new Cond with Locker l.
+func NewCond(l sync.Locker) *Cond {
+	return &Cond{L: l}
+}
+
+// Wait atomically unlocks c.L and suspends execution of the calling
+// goroutine, until either it is woken by Signal or Broadcast, or the
+// Context is done.  Before returning, Wait locks c.L, even if the
+// Context is done.  If Wait returns because the Context is done, it
+// returns the Context's error; otherwise it returns nil.
+//
+// As with sync.Cond.Wait, the caller should typically Wait in a loop
+// that checks the condition.
+func (c *Cond) Wait(ctx context.Context) error {
+	ch := make(chan struct{})
+	c.mu.Lock()
+	c.waiters = append(c.waiters, ch)
+	c.mu.Unlock()
+
+	c.L.Unlock()
+	defer c.L.Lock()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for i, waiter := range c.waiters {
+			if waiter == ch {
+				c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+				return ctx.Err()
+			}
+		}
+		// We were woken at the same time that the Context was
+		// done; report the wakeup so that it isn't lost.
+		return nil
+	}
+}
+
+// WaitWithTimeout is like Wait, but also gives up (returning
+// context.DeadlineExceeded) if it isn't woken within the given
+// timeout.
+func (c *Cond) WaitWithTimeout(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return c.Wait(ctx)
+}
+
+// Signal wakes the goroutine that has been waiting on c the longest,
+// if there is any.
+//
+// It is allowed but not required for the caller to hold c.L during the
+// call.
+func (c *Cond) Signal() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.waiters) > 0 {
+		close(c.waiters[0])
+		c.waiters = c.waiters[1:]
+	}
+}
+
+// BroadcastN wakes the n goroutines that have been waiting on c the
+// longest, or all of the goroutines waiting on c if there are fewer
+// than n.  BroadcastN(1) is the same as Signal, and BroadcastN with
+// n <= 0 does nothing.
+//
+// It is allowed but not required for the caller to hold c.L during the
+// call.
+func (c *Cond) BroadcastN(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n > len(c.waiters) {
+		n = len(c.waiters)
+	}
+	if n <= 0 {
+		return
+	}
+	for _, waiter := range c.waiters[:n] {
+		close(waiter)
+	}
+	c.waiters = c.waiters[n:]
+}
+
+// Broadcast wakes all goroutines waiting on c.
+//
+// It is allowed but not required for the caller to hold c.L during the
+// call.
+func (c *Cond) Broadcast() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, waiter := range c.waiters {
+		close(waiter)
+	}
+	c.waiters = nil
+}
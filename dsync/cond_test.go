@@ -0,0 +1,141 @@
+package dsync_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dsync"
+)
+
+// startWaiters starts n goroutines that each Wait on c, and returns a
+// channel that receives each goroutine's index (and closes once they
+// have all returned).  It returns once all n are waiting.
+func startWaiters(ctx context.Context, c *dsync.Cond, n int) <-chan int {
+	woken := make(chan int, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		c.L.Lock()
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer c.L.Unlock()
+			if c.Wait(ctx) == nil {
+				woken <- i
+			}
+		}(i)
+		// Wait for it to be waiting (Wait releases c.L).
+		c.L.Lock()
+		c.L.Unlock()
+	}
+	go func() {
+		wg.Wait()
+		close(woken)
+	}()
+	return woken
+}
+
+func TestCondSignal(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c := dsync.NewCond(new(sync.Mutex))
+
+	woken := startWaiters(ctx, c, 3)
+	for i := 0; i < 3; i++ {
+		c.Signal()
+		assert.Equal(t, i, <-woken)
+	}
+	_, open := <-woken
+	assert.False(t, open)
+}
+
+func TestCondBroadcast(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c := dsync.NewCond(new(sync.Mutex))
+
+	woken := startWaiters(ctx, c, 3)
+	c.Broadcast()
+	count := 0
+	for range woken {
+		count++
+	}
+	assert.Equal(t, 3, count)
+}
+
+func TestCondBroadcastN(t *testing.T) {
+	t.Run("fewer", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		c := dsync.NewCond(new(sync.Mutex))
+
+		woken := startWaiters(ctx, c, 5)
+		c.BroadcastN(2)
+		// The two are woken at once, so they may report in either order.
+		assert.ElementsMatch(t, []int{0, 1}, []int{<-woken, <-woken})
+		select {
+		case i := <-woken:
+			t.Errorf("waiter %d was woken", i)
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		// The rest are still waiting, in order.
+		c.BroadcastN(1)
+		assert.Equal(t, 2, <-woken)
+		cancel()
+		for i := range woken {
+			t.Errorf("waiter %d was woken", i)
+		}
+	})
+	t.Run("more", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		c := dsync.NewCond(new(sync.Mutex))
+
+		woken := startWaiters(ctx, c, 3)
+		c.BroadcastN(100)
+		count := 0
+		for range woken {
+			count++
+		}
+		assert.Equal(t, 3, count)
+	})
+	t.Run("none", func(t *testing.T) {
+		c := dsync.NewCond(new(sync.Mutex))
+		c.BroadcastN(0)
+		c.BroadcastN(-1)
+		c.BroadcastN(1)
+	})
+}
+
+func TestCondWaitWithTimeout(t *testing.T) {
+	c := dsync.NewCond(new(sync.Mutex))
+
+	t.Run("timeout", func(t *testing.T) {
+		c.L.Lock()
+		defer c.L.Unlock()
+		start := time.Now()
+		err := c.WaitWithTimeout(context.Background(), 100*time.Millisecond)
+		assert.Equal(t, context.DeadlineExceeded, err)
+		assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+		c.L.Lock()
+		defer c.L.Unlock()
+		err := c.WaitWithTimeout(ctx, time.Hour)
+		assert.Equal(t, context.Canceled, err)
+	})
+
+	t.Run("signal", func(t *testing.T) {
+		time.AfterFunc(100*time.Millisecond, c.Signal)
+		c.L.Lock()
+		defer c.L.Unlock()
+		assert.NoError(t, c.WaitWithTimeout(context.Background(), time.Hour))
+	})
+}
@@ -0,0 +1,175 @@
+// Package dsync provides Context-aware variants of the synchronization
+// primitives in the standard library "sync" package.
+package dsync
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// A Map is like a sync.Map, but a value may be computed lazily (and
+// cancellably) the first time a key is requested.  This avoids the
+// "thundering herd" problem where many goroutines that all miss the
+// same key each compute an expensive value, only for all but one of
+// them to be thrown away.
+//
+// The zero Map is empty and ready for use.  A Map must not be copied
+// after first use.
+type Map struct {
+	mu sync.Mutex
+	m  map[interface{}]*mapEntry
+}
+
+type mapEntry struct {
+	ready chan struct{} // closed once value and err are set
+	value interface{}
+	err   error
+}
+
+// Load returns the value stored in the map for a key, or nil if no
+// value is present.  The ok result indicates whether a value was found
+// in the map.  A value that is still being computed by LoadOrStore is
+// not considered present.
+func (m *Map) Load(key interface{}) (value interface{}, ok bool) {
+	m.mu.Lock()
+	entry, ok := m.m[key]
+	m.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	select {
+	case <-entry.ready:
+		return entry.value, entry.err == nil
+	default:
+		return nil, false
+	}
+}
+
+// Store sets the value for a key.  If a value for the key is being
+// computed by LoadOrStore, callers that are waiting on that
+// computation still receive the computed value, but it is not stored
+// in the map.
+func (m *Map) Store(key, value interface{}) {
+	entry := &mapEntry{
+		ready: make(chan struct{}),
+		value: value,
+	}
+	close(entry.ready)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.m == nil {
+		m.m = make(map[interface{}]*mapEntry)
+	}
+	m.m[key] = entry
+}
+
+// Delete deletes the value for a key.
+func (m *Map) Delete(key interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.m, key)
+}
+
+// Range calls f sequentially for each key and value present in the
+// map.  If f returns false, Range stops the iteration.  Values that
+// are still being computed are skipped.  As with sync.Map.Range, f may
+// call any method on the Map.
+func (m *Map) Range(f func(key, value interface{}) bool) {
+	m.mu.Lock()
+	entries := make(map[interface{}]*mapEntry, len(m.m))
+	for k, v := range m.m {
+		entries[k] = v
+	}
+	m.mu.Unlock()
+
+	for k, entry := range entries {
+		select {
+		case <-entry.ready:
+			if entry.err != nil {
+				continue
+			}
+		default:
+			continue
+		}
+		if !f(k, entry.value) {
+			break
+		}
+	}
+}
+
+// LoadOrStore returns the existing value for the key if present.
+// Otherwise, it stores and returns the given value.  The loaded result
+// is true if the value was loaded, false if stored.
+//
+// If value is a func(context.Context) (interface{}, error), then rather
+// than storing the function itself, it is called (with the given
+// Context) to compute the value to store; if it returns an error, then
+// nothing is stored and the error is returned.  Only one computation
+// for a key is in flight at a time; concurrent LoadOrStore calls for
+// the same key wait for it to complete rather than starting their
+// own.  If the in-flight computation fails, then one of the waiters
+// starts a new computation with its own value.
+//
+// If the Context is done while waiting on another caller's computation,
+// then LoadOrStore returns the Context's error.
+func (m *Map) LoadOrStore(ctx context.Context, key, value interface{}) (actual interface{}, loaded bool, err error) {
+	for {
+		m.mu.Lock()
+		if m.m == nil {
+			m.m = make(map[interface{}]*mapEntry)
+		}
+		entry, ok := m.m[key]
+		if !ok {
+			entry = &mapEntry{
+				ready: make(chan struct{}),
+			}
+			m.m[key] = entry
+			m.mu.Unlock()
+			return m.compute(ctx, key, entry, value)
+		}
+		m.mu.Unlock()
+
+		select {
+		case <-entry.ready:
+			if entry.err != nil {
+				// The computation failed; try again.
+				continue
+			}
+			return entry.value, true, nil
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+	}
+}
+
+// errComputePanicked is the error recorded for a computation that panicked rather than returning;
+// it is only seen by waiters, which retry.
+var errComputePanicked = errors.New("dsync.Map.LoadOrStore: value function panicked")
+
+func (m *Map) compute(ctx context.Context, key interface{}, entry *mapEntry, value interface{}) (interface{}, bool, error) {
+	fn, isFn := value.(func(context.Context) (interface{}, error))
+	if !isFn {
+		entry.value = value
+		close(entry.ready)
+		return value, false, nil
+	}
+
+	entry.err = errComputePanicked
+	defer func() {
+		if entry.err != nil {
+			m.mu.Lock()
+			if m.m[key] == entry {
+				delete(m.m, key)
+			}
+			m.mu.Unlock()
+		}
+		close(entry.ready)
+	}()
+	entry.value, entry.err = fn(ctx)
+	if entry.err != nil {
+		return nil, false, entry.err
+	}
+	return entry.value, false, nil
+}
@@ -0,0 +1,125 @@
+package dsync_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dsync"
+)
+
+func TestMapBasic(t *testing.T) {
+	var m dsync.Map
+
+	_, ok := m.Load("a")
+	assert.False(t, ok)
+
+	m.Store("a", 1)
+	v, ok := m.Load("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	actual, loaded, err := m.LoadOrStore(context.Background(), "a", 2)
+	assert.NoError(t, err)
+	assert.True(t, loaded)
+	assert.Equal(t, 1, actual)
+
+	actual, loaded, err = m.LoadOrStore(context.Background(), "b", 2)
+	assert.NoError(t, err)
+	assert.False(t, loaded)
+	assert.Equal(t, 2, actual)
+
+	seen := map[interface{}]interface{}{}
+	m.Range(func(k, v interface{}) bool {
+		seen[k] = v
+		return true
+	})
+	assert.Equal(t, map[interface{}]interface{}{"a": 1, "b": 2}, seen)
+
+	m.Delete("a")
+	_, ok = m.Load("a")
+	assert.False(t, ok)
+}
+
+func TestMapLoadOrStoreFunc(t *testing.T) {
+	var m dsync.Map
+	var calls int32
+	release := make(chan struct{})
+	compute := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "value", nil
+	}
+
+	const n = 50
+	var wg sync.WaitGroup
+	results := make([]interface{}, n)
+	loaded := make([]bool, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var err error
+			results[i], loaded[i], err = m.LoadOrStore(context.Background(), "key", compute)
+			assert.NoError(t, err)
+		}(i)
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	numStored := 0
+	for i := 0; i < n; i++ {
+		assert.Equal(t, "value", results[i])
+		if !loaded[i] {
+			numStored++
+		}
+	}
+	assert.Equal(t, 1, numStored)
+}
+
+func TestMapLoadOrStoreError(t *testing.T) {
+	var m dsync.Map
+	errFailed := errors.New("failed")
+
+	_, _, err := m.LoadOrStore(context.Background(), "key", func(context.Context) (interface{}, error) {
+		return nil, errFailed
+	})
+	assert.Equal(t, errFailed, err)
+	_, ok := m.Load("key")
+	assert.False(t, ok)
+
+	// A failed computation is not cached.
+	actual, loaded, err := m.LoadOrStore(context.Background(), "key", func(context.Context) (interface{}, error) {
+		return "value", nil
+	})
+	assert.NoError(t, err)
+	assert.False(t, loaded)
+	assert.Equal(t, "value", actual)
+}
+
+func TestMapLoadOrStoreCancel(t *testing.T) {
+	var m dsync.Map
+	computeCtx, computeCancel := context.WithCancel(context.Background())
+	defer computeCancel()
+	computing := make(chan struct{})
+	go func() {
+		_, _, _ = m.LoadOrStore(computeCtx, "key", func(ctx context.Context) (interface{}, error) {
+			close(computing)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+	}()
+	<-computing
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, _, err := m.LoadOrStore(ctx, "key", "other")
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
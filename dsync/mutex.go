@@ -0,0 +1,87 @@
+package dsync
+
+import (
+	"context"
+	"sync"
+)
+
+// A Mutex is a mutual exclusion lock, like sync.Mutex, but Lock takes
+// a Context, so that waiting for the lock may be abandoned.
+//
+// Unlike sync.Mutex, a Mutex is strictly fair: goroutines blocked in
+// Lock acquire the lock in the order that they started waiting.
+//
+// The zero Mutex is unlocked and ready for use.  A Mutex must not be
+// copied after first use.
+type Mutex struct {
+	once sync.Once
+	// ch holds a token while the Mutex is locked.  Goroutines
+	// blocked sending on a channel are queued in FIFO order, and
+	// when Unlock receives the token, the token of the longest
+	// waiter is moved in to the buffer atomically; so waiters are
+	// served in order, and the channel is never observed to be
+	// empty while there are waiters.
+	ch chan struct{}
+}
+
+func (m *Mutex) init() {
+	m.once.Do(func() {
+		m.ch = make(chan struct{}, 1)
+	})
+}
+
+// Lock locks m.  If the lock is already in use, the calling goroutine
+// blocks until the mutex is available or the Context is done.  It
+// returns nil if the lock was acquired, or the Context's error if not.
+func (m *Mutex) Lock(ctx context.Context) error {
+	m.init()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case m.ch <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TryLock tries to lock m and reports whether it succeeded.  It never
+// blocks, and it never jumps ahead of goroutines that are already
+// waiting in Lock.
+func (m *Mutex) TryLock() bool {
+	m.init()
+	select {
+	case m.ch <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Unlock unlocks m.  It is a run-time error if m is not locked on
+// entry to Unlock.
+//
+// As with sync.Mutex, a locked Mutex is not associated with a
+// particular goroutine; it is allowed for one goroutine to lock a
+// Mutex and then arrange for another goroutine to unlock it.
+func (m *Mutex) Unlock() {
+	m.init()
+	select {
+	case <-m.ch:
+	default:
+		panic("dsync: unlock of unlocked mutex")
+	}
+}
+
+// IsLocked reports whether m is currently locked.
+//
+// IsLocked is a diagnostic tool, intended for things like asserting in
+// tests that a critical section did not leak the lock; it is not a
+// synchronization primitive.  Unless the caller is holding the lock
+// itself, the result may be out of date by the time that the caller
+// acts on it.
+func (m *Mutex) IsLocked() bool {
+	m.init()
+	return len(m.ch) == 1
+}
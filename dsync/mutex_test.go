@@ -0,0 +1,116 @@
+package dsync_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dsync"
+)
+
+func HammerMutex(m *dsync.Mutex, loops int, cdone chan bool) {
+	ctx := context.Background()
+	for i := 0; i < loops; i++ {
+		if i%3 == 0 {
+			if m.TryLock() {
+				if !m.IsLocked() {
+					panic("IsLocked returned false after TryLock")
+				}
+				m.Unlock()
+			}
+			continue
+		}
+		if err := m.Lock(ctx); err != nil {
+			panic(err)
+		}
+		if !m.IsLocked() {
+			panic("IsLocked returned false after Lock")
+		}
+		m.Unlock()
+	}
+	cdone <- true
+}
+
+func TestMutex(t *testing.T) {
+	m := new(dsync.Mutex)
+
+	if m.IsLocked() {
+		t.Fatalf("IsLocked returned true for new mutex")
+	}
+	if !m.TryLock() {
+		t.Fatalf("TryLock failed with mutex unlocked")
+	}
+	if !m.IsLocked() {
+		t.Fatalf("IsLocked returned false with mutex locked")
+	}
+	if m.TryLock() {
+		t.Fatalf("TryLock succeeded with mutex locked")
+	}
+	m.Unlock()
+	if m.IsLocked() {
+		t.Fatalf("IsLocked returned true after Unlock")
+	}
+
+	c := make(chan bool)
+	for i := 0; i < 10; i++ {
+		go HammerMutex(m, 1000, c)
+	}
+	for i := 0; i < 10; i++ {
+		<-c
+	}
+	if m.IsLocked() {
+		t.Fatalf("IsLocked returned true after HammerMutex")
+	}
+}
+
+func TestMutexLockCanceled(t *testing.T) {
+	var m dsync.Mutex
+	assert.NoError(t, m.Lock(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, m.Lock(ctx))
+
+	m.Unlock()
+	assert.NoError(t, m.Lock(context.Background()))
+	m.Unlock()
+}
+
+func TestMutexFIFO(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var m dsync.Mutex
+	assert.NoError(t, m.Lock(ctx))
+
+	order := make(chan int, 3)
+	for i := 0; i < 3; i++ {
+		i := i
+		go func() {
+			if err := m.Lock(ctx); err != nil {
+				return
+			}
+			order <- i
+			m.Unlock()
+		}()
+		time.Sleep(50 * time.Millisecond) // let it start waiting
+	}
+
+	// TryLock must not jump the queue.
+	assert.False(t, m.TryLock())
+	m.Unlock()
+	assert.False(t, m.TryLock())
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, i, <-order)
+	}
+	assert.True(t, m.TryLock())
+	m.Unlock()
+}
+
+func TestMutexUnlockUnlocked(t *testing.T) {
+	var m dsync.Mutex
+	assert.PanicsWithValue(t, "dsync: unlock of unlocked mutex", m.Unlock)
+}
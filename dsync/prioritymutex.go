@@ -0,0 +1,118 @@
+package dsync
+
+import (
+	"context"
+	"sync"
+)
+
+// A PriorityMutex is like Mutex, but has two lock modes: LockNormal,
+// which waits in FIFO order just like Mutex.Lock, and LockHigh, which
+// jumps ahead of any goroutines waiting in LockNormal.  High-priority
+// waiters are served in FIFO order among themselves.
+//
+// When nobody is using LockHigh, a PriorityMutex behaves identically
+// to a Mutex.  Note that a steady stream of LockHigh callers can starve
+// LockNormal callers.
+//
+// The zero PriorityMutex is unlocked and ready for use.  A
+// PriorityMutex must not be copied after first use.
+type PriorityMutex struct {
+	mu     sync.Mutex
+	locked bool
+	// Unlock hands the lock directly to the next waiter (by closing
+	// its channel) rather than marking the mutex as unlocked, so
+	// that nobody can barge in ahead of the queue.
+	high   []chan struct{}
+	normal []chan struct{}
+}
+
+// LockHigh locks m, jumping ahead of any goroutines waiting in
+// LockNormal.  If the lock is already in use, the calling goroutine
+// blocks until the mutex is available or the Context is done.  It
+// returns nil if the lock was acquired, or the Context's error if not.
+func (m *PriorityMutex) LockHigh(ctx context.Context) error {
+	return m.lock(ctx, &m.high)
+}
+
+// LockNormal locks m.  If the lock is already in use, the calling
+// goroutine blocks until the mutex is available or the Context is
+// done.  It returns nil if the lock was acquired, or the Context's
+// error if not.
+func (m *PriorityMutex) LockNormal(ctx context.Context) error {
+	return m.lock(ctx, &m.normal)
+}
+
+func (m *PriorityMutex) lock(ctx context.Context, queue *[]chan struct{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	if !m.locked {
+		m.locked = true
+		m.mu.Unlock()
+		return nil
+	}
+	ch := make(chan struct{})
+	*queue = append(*queue, ch)
+	m.mu.Unlock()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		for i, waiter := range *queue {
+			if waiter == ch {
+				*queue = append((*queue)[:i], (*queue)[i+1:]...)
+				return ctx.Err()
+			}
+		}
+		// We were handed the lock at the same time that the
+		// Context was done; report the acquisition so that the
+		// lock isn't leaked.
+		return nil
+	}
+}
+
+// TryLock tries to lock m and reports whether it succeeded.  It never
+// blocks, and it never jumps ahead of goroutines that are already
+// waiting.
+func (m *PriorityMutex) TryLock() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.locked {
+		return false
+	}
+	m.locked = true
+	return true
+}
+
+// Unlock unlocks m, handing the lock to the longest-waiting
+// high-priority waiter if there is one, or else to the
+// longest-waiting normal waiter.  It is a run-time error if m is not
+// locked on entry to Unlock.
+func (m *PriorityMutex) Unlock() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.locked {
+		panic("dsync: unlock of unlocked mutex")
+	}
+	for _, queue := range []*[]chan struct{}{&m.high, &m.normal} {
+		if len(*queue) > 0 {
+			close((*queue)[0])
+			*queue = (*queue)[1:]
+			return
+		}
+	}
+	m.locked = false
+}
+
+// IsLocked reports whether m is currently locked.  Like
+// Mutex.IsLocked, it is a diagnostic tool, not a synchronization
+// primitive.
+func (m *PriorityMutex) IsLocked() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.locked
+}
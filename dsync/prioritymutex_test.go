@@ -0,0 +1,99 @@
+package dsync_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dsync"
+)
+
+func TestPriorityMutex(t *testing.T) {
+	var m dsync.PriorityMutex
+
+	assert.False(t, m.IsLocked())
+	assert.True(t, m.TryLock())
+	assert.True(t, m.IsLocked())
+	assert.False(t, m.TryLock())
+	m.Unlock()
+	assert.False(t, m.IsLocked())
+
+	c := make(chan bool)
+	for i := 0; i < 10; i++ {
+		go func(i int) {
+			ctx := context.Background()
+			for j := 0; j < 1000; j++ {
+				lock := m.LockNormal
+				if (i+j)%2 == 0 {
+					lock = m.LockHigh
+				}
+				if err := lock(ctx); err != nil {
+					panic(err)
+				}
+				m.Unlock()
+			}
+			c <- true
+		}(i)
+	}
+	for i := 0; i < 10; i++ {
+		<-c
+	}
+	assert.False(t, m.IsLocked())
+}
+
+func TestPriorityMutexHighJumpsQueue(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var m dsync.PriorityMutex
+	assert.NoError(t, m.LockNormal(ctx))
+
+	order := make(chan string, 4)
+	start := func(name string, lock func(context.Context) error) {
+		go func() {
+			if err := lock(ctx); err != nil {
+				return
+			}
+			order <- name
+			m.Unlock()
+		}()
+		time.Sleep(50 * time.Millisecond) // let it start waiting
+	}
+	start("normal1", m.LockNormal)
+	start("normal2", m.LockNormal)
+	start("high1", m.LockHigh)
+	start("high2", m.LockHigh)
+
+	// TryLock must not jump the queue.
+	m.Unlock()
+	assert.False(t, m.TryLock())
+
+	assert.Equal(t, "high1", <-order)
+	assert.Equal(t, "high2", <-order)
+	assert.Equal(t, "normal1", <-order)
+	assert.Equal(t, "normal2", <-order)
+}
+
+func TestPriorityMutexLockCanceled(t *testing.T) {
+	var m dsync.PriorityMutex
+	assert.NoError(t, m.LockNormal(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, m.LockHigh(ctx))
+	assert.Equal(t, context.DeadlineExceeded, m.LockNormal(ctx))
+
+	// The canceled waiters must have been removed from the queue,
+	// so Unlock actually unlocks.
+	m.Unlock()
+	assert.False(t, m.IsLocked())
+	assert.NoError(t, m.LockHigh(context.Background()))
+	m.Unlock()
+}
+
+func TestPriorityMutexUnlockUnlocked(t *testing.T) {
+	var m dsync.PriorityMutex
+	assert.PanicsWithValue(t, "dsync: unlock of unlocked mutex", m.Unlock)
+}
@@ -0,0 +1,110 @@
+package dtime_test
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	dtime "github.com/datawire/dlib/dtime/v2"
+)
+
+func TestAfter(t *testing.T) {
+	fc := dtime.NewFakeClock()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ctx = dtime.WithClock(ctx, fc)
+
+	ch := dtime.After(ctx, 5*time.Second)
+	assert.Equal(t, 1, fc.PendingTimers())
+	fc.StepSec(4)
+	select {
+	case <-ch:
+		t.Fatal("After fired early")
+	default:
+	}
+	fc.StepSec(1)
+	select {
+	case ts := <-ch:
+		assert.Equal(t, fc.BootTime().Add(5*time.Second), ts)
+	case <-ctx.Done():
+		t.Fatal("After did not fire")
+	}
+}
+
+func TestAfterCanceled(t *testing.T) {
+	fc := dtime.NewFakeClock()
+	ctx, cancel := context.WithCancel(dtime.WithClock(context.Background(), fc))
+
+	ch := dtime.After(ctx, 5*time.Second)
+	assert.Equal(t, 1, fc.PendingTimers())
+	cancel()
+	// The timer is stopped asynchronously.
+	for fc.PendingTimers() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	fc.StepSec(10)
+	select {
+	case <-ch:
+		t.Fatal("After fired after the Context was canceled")
+	default:
+	}
+}
+
+// BenchmarkLeakComparison compares time.After and dtime.After when
+// selecting against a Context that is canceled first.  Run it with
+//
+//	go test -run=NONE -bench=LeakComparison -benchmem ./dtime/v2
+//
+// and compare the "live-B/op" metric, which is the heap memory that
+// is still in use (after a GC) per iteration once the loop is done.
+//
+// When built with a Go older than 1.23 (or with
+// GODEBUG=asynctimerchan=1, on Go 1.23 through 1.26), the timer of
+// each time.After iteration is kept alive until it fires (an hour
+// later), and so time.After shows a few hundred live bytes per
+// iteration; with dtime.After, the timer is released when the Context
+// is canceled, regardless of Go version.  Newer versions of Go can
+// garbage-collect unreferenced timers, and so there both are near
+// zero.
+func BenchmarkLeakComparison(b *testing.B) {
+	afters := map[string]func(ctx context.Context, d time.Duration) <-chan time.Time{
+		"time.After": func(_ context.Context, d time.Duration) <-chan time.Time {
+			return time.After(d)
+		},
+		"dtime.After": dtime.After,
+	}
+	for name, after := range afters {
+		after := after
+		b.Run(name, func(b *testing.B) {
+			var before, after_ runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&before)
+			goroutines := runtime.NumGoroutine()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				ctx, cancel := context.WithCancel(context.Background())
+				ch := after(ctx, time.Hour)
+				cancel()
+				select {
+				case <-ctx.Done():
+				case <-ch:
+				}
+			}
+			b.StopTimer()
+			// Give dtime.After's goroutines a chance to notice the cancellation.
+			for deadline := time.Now().Add(10 * time.Second); runtime.NumGoroutine() > goroutines && time.Now().Before(deadline); {
+				time.Sleep(time.Millisecond)
+			}
+			runtime.GC()
+			runtime.ReadMemStats(&after_)
+			live := int64(after_.HeapInuse) - int64(before.HeapInuse)
+			if live < 0 {
+				live = 0
+			}
+			b.ReportMetric(float64(live)/float64(b.N), "live-B/op")
+		})
+	}
+}
@@ -0,0 +1,322 @@
+package dtime
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A CronSchedule describes when a Cron should tick.
+type CronSchedule interface {
+	// Next returns the first time strictly after t that the
+	// schedule is due, or the zero time.Time if the schedule will
+	// never be due again.
+	Next(t time.Time) time.Time
+}
+
+// A Cron delivers ticks of a clock according to a CronSchedule, using
+// a Context's Clock.  It is like a Ticker, but for calendar-based
+// schedules ("every day at 03:00") rather than fixed intervals.
+type Cron struct {
+	C <-chan time.Time
+
+	c        chan time.Time
+	clock    Clock
+	schedule CronSchedule
+
+	mu      sync.Mutex
+	cancel  func() bool
+	stopped bool
+	done    chan struct{}
+}
+
+// NewCron returns a new Cron that sends the current time (as reported
+// by the Clock associated with the Context) on its channel each time
+// the schedule is due.  As with a Ticker, ticks are dropped to make up
+// for slow receivers.  The Cron is stopped when the Context is
+// canceled.
+func NewCron(ctx context.Context, schedule CronSchedule) *Cron {
+	c := make(chan time.Time, 1)
+	cron := &Cron{
+		C:        c,
+		c:        c,
+		clock:    getClock(ctx),
+		schedule: schedule,
+		done:     make(chan struct{}),
+	}
+	cron.mu.Lock()
+	cron.scheduleLocked(cron.clock.Now())
+	cron.mu.Unlock()
+	if ctxDone := ctx.Done(); ctxDone != nil {
+		go func() {
+			select {
+			case <-ctxDone:
+				cron.Stop()
+			case <-cron.done:
+			}
+		}()
+	}
+	return cron
+}
+
+// scheduleLocked arranges for the next tick after now; it must be
+// called with mu held.
+func (cron *Cron) scheduleLocked(now time.Time) {
+	next := cron.schedule.Next(now)
+	if next.IsZero() {
+		cron.cancel = func() bool { return false }
+		return
+	}
+	cron.cancel = cron.clock.At(next, cron.tick)
+}
+
+func (cron *Cron) tick() {
+	cron.mu.Lock()
+	defer cron.mu.Unlock()
+	if cron.stopped {
+		return
+	}
+	now := cron.clock.Now()
+	select {
+	case cron.c <- now:
+	default:
+	}
+	cron.scheduleLocked(now)
+}
+
+// Stop turns off the Cron, and releases its resources.  After Stop, no
+// more ticks will be sent, and any tick that has been sent but not yet
+// received is discarded.  Stop does not close the channel.  Stopping
+// an already-stopped Cron has no effect.
+func (cron *Cron) Stop() {
+	cron.mu.Lock()
+	defer cron.mu.Unlock()
+	if cron.stopped {
+		return
+	}
+	cron.stopped = true
+	cron.cancel()
+	close(cron.done)
+	select {
+	case <-cron.c:
+	default:
+	}
+}
+
+// SimpleCronSchedule is a CronSchedule that is parsed from a standard
+// 5-field cron expression, or from an "@every <duration>" shorthand.
+type SimpleCronSchedule struct {
+	every time.Duration
+
+	// bitsets of the permitted values of each field
+	minute, hour, dom, month, dow uint64
+	// whether the day-of-month and day-of-week fields were "*"
+	domStar, dowStar bool
+}
+
+var _ CronSchedule = (*SimpleCronSchedule)(nil)
+
+var cronShorthands = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // names for the values starting at min
+}
+
+var (
+	cronMinute = cronField{name: "minute", min: 0, max: 59}
+	cronHour   = cronField{name: "hour", min: 0, max: 23}
+	cronDOM    = cronField{name: "day-of-month", min: 1, max: 31}
+	cronMonth  = cronField{name: "month", min: 1, max: 12, names: []string{
+		"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	// 7 is also accepted as Sunday.
+	cronDOW = cronField{name: "day-of-week", min: 0, max: 7, names: []string{
+		"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+// ParseCronSchedule parses a cron expression in to a
+// SimpleCronSchedule.  The expression is either:
+//
+//   - the standard 5 space-separated fields "minute hour day-of-month
+//     month day-of-week", each of which may be "*", a number, a range
+//     "a-b", a step "*/n" or "a-b/n", or a comma-separated list of
+//     those; months and days of the week may also be given as
+//     3-letter names ("jan", "mon"), and 0 or 7 is Sunday.  As with
+//     the traditional cron, if both the day-of-month and day-of-week
+//     are restricted, then a day matching either one is due;
+//   - one of the shorthands "@yearly" (or "@annually"), "@monthly",
+//     "@weekly", "@daily" (or "@midnight"), or "@hourly"; or
+//   - "@every <duration>", where the duration is parsed by
+//     time.ParseDuration, and must be positive.
+//
+// Times are evaluated in the time.Location of the time passed to Next.
+func ParseCronSchedule(spec string) (*SimpleCronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron schedule %q: %w", spec, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("invalid cron schedule %q: non-positive interval", spec)
+		}
+		return &SimpleCronSchedule{every: d}, nil
+	}
+	if expanded, ok := cronShorthands[strings.ToLower(spec)]; ok {
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron schedule %q: expected 5 fields, got %d", spec, len(fields))
+	}
+	var sched SimpleCronSchedule
+	var err error
+	for i, dst := range []struct {
+		field cronField
+		bits  *uint64
+	}{
+		{cronMinute, &sched.minute},
+		{cronHour, &sched.hour},
+		{cronDOM, &sched.dom},
+		{cronMonth, &sched.month},
+		{cronDOW, &sched.dow},
+	} {
+		if *dst.bits, err = dst.field.parse(fields[i]); err != nil {
+			return nil, fmt.Errorf("invalid cron schedule %q: %w", spec, err)
+		}
+	}
+	if sched.dow&(1<<7) != 0 {
+		sched.dow |= 1 << 0
+	}
+	sched.domStar = fields[2] == "*"
+	sched.dowStar = fields[4] == "*"
+	return &sched, nil
+}
+
+func (f cronField) parseValue(str string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(str, name) {
+			return f.min + i, nil
+		}
+	}
+	val, err := strconv.Atoi(str)
+	if err != nil || val < f.min || val > f.max {
+		return 0, fmt.Errorf("%s: invalid value %q (must be %d-%d)", f.name, str, f.min, f.max)
+	}
+	return val, nil
+}
+
+func (f cronField) parse(str string) (uint64, error) {
+	var ret uint64
+	for _, part := range strings.Split(str, ",") {
+		rng, stepStr := part, ""
+		if slash := strings.IndexByte(part, '/'); slash >= 0 {
+			rng, stepStr = part[:slash], part[slash+1:]
+		}
+		step := 1
+		if stepStr != "" {
+			var err error
+			step, err = strconv.Atoi(stepStr)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("%s: invalid step %q", f.name, stepStr)
+			}
+		}
+		var lo, hi int
+		switch {
+		case rng == "*":
+			lo, hi = f.min, f.max
+		case strings.Contains(rng, "-"):
+			dash := strings.IndexByte(rng, '-')
+			var err error
+			if lo, err = f.parseValue(rng[:dash]); err != nil {
+				return 0, err
+			}
+			if hi, err = f.parseValue(rng[dash+1:]); err != nil {
+				return 0, err
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("%s: invalid range %q", f.name, rng)
+			}
+		default:
+			var err error
+			if lo, err = f.parseValue(rng); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if stepStr != "" {
+				// "a/n" means "a-max/n"
+				hi = f.max
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			ret |= 1 << uint(v)
+		}
+	}
+	return ret, nil
+}
+
+func (s *SimpleCronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.domStar && s.dowStar:
+		return true
+	case s.domStar:
+		return dowMatch
+	case s.dowStar:
+		return domMatch
+	default:
+		return domMatch || dowMatch
+	}
+}
+
+// Next implements CronSchedule.
+func (s *SimpleCronSchedule) Next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Add(s.every)
+	}
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// Give up if nothing matches within 5 years (for example,
+	// "0 0 31 2 *").
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			// Skip ahead to the next permitted minute in this hour, if any.
+			if later := s.minute >> uint(t.Minute()); later != 0 {
+				t = t.Add(time.Duration(bits.TrailingZeros64(later)) * time.Minute)
+			} else {
+				t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			}
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
@@ -0,0 +1,147 @@
+package dtime_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	dtime "github.com/datawire/dlib/dtime/v2"
+)
+
+func mustParseTime(t *testing.T, str string) time.Time {
+	t.Helper()
+	ts, err := time.Parse(time.RFC3339, str)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ts
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	testcases := []struct {
+		Spec     string
+		From     string
+		Expected []string
+	}{
+		{"0 3 * * *", "2021-03-14T12:00:00Z", []string{"2021-03-15T03:00:00Z", "2021-03-16T03:00:00Z"}},
+		{"@daily", "2021-03-14T00:00:00Z", []string{"2021-03-15T00:00:00Z"}},
+		{"*/15 * * * *", "2021-03-14T12:07:30Z", []string{"2021-03-14T12:15:00Z", "2021-03-14T12:30:00Z", "2021-03-14T12:45:00Z", "2021-03-14T13:00:00Z"}},
+		{"30 9-17/4 * * mon-fri", "2021-03-12T16:00:00Z", []string{"2021-03-12T17:30:00Z", "2021-03-15T09:30:00Z", "2021-03-15T13:30:00Z"}},
+		{"0 0 * * 7", "2021-03-14T00:00:00Z", []string{"2021-03-21T00:00:00Z"}}, // 7 is Sunday
+		{"0 0 1,15 * *", "2021-03-10T00:00:00Z", []string{"2021-03-15T00:00:00Z", "2021-04-01T00:00:00Z"}},
+		{"0 0 13 * fri", "2021-03-10T00:00:00Z", []string{"2021-03-12T00:00:00Z", "2021-03-13T00:00:00Z", "2021-03-19T00:00:00Z"}}, // dom OR dow
+		{"0 12 29 feb *", "2021-01-01T00:00:00Z", []string{"2024-02-29T12:00:00Z"}},
+		{"0 0 31 2 *", "2021-01-01T00:00:00Z", []string{"0001-01-01T00:00:00Z"}}, // never
+		{"@every 90s", "2021-03-14T12:00:00Z", []string{"2021-03-14T12:01:30Z", "2021-03-14T12:03:00Z"}},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Spec, func(t *testing.T) {
+			sched, err := dtime.ParseCronSchedule(tc.Spec)
+			if !assert.NoError(t, err) {
+				return
+			}
+			ts := mustParseTime(t, tc.From)
+			for _, expected := range tc.Expected {
+				ts = sched.Next(ts)
+				assert.Equal(t, mustParseTime(t, expected), ts.UTC())
+			}
+		})
+	}
+}
+
+func TestParseCronScheduleErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"foo * * * *",
+		"@every",
+		"@every -1s",
+		"@every 1x",
+	} {
+		_, err := dtime.ParseCronSchedule(spec)
+		assert.Errorf(t, err, "spec=%q", spec)
+	}
+}
+
+func TestCron(t *testing.T) {
+	fc := dtime.NewFakeClock()
+	fc.SetTime(mustParseTime(t, "2021-03-14T12:00:00Z"))
+	ctx := dtime.WithClock(context.Background(), fc)
+
+	sched, err := dtime.ParseCronSchedule("0 3 * * *")
+	if !assert.NoError(t, err) {
+		return
+	}
+	cron := dtime.NewCron(ctx, sched)
+	defer cron.Stop()
+
+	var ticks []time.Time
+	for hour := 0; hour < 72; hour++ {
+		fc.Step(time.Hour)
+		select {
+		case ts := <-cron.C:
+			ticks = append(ticks, ts.UTC())
+		default:
+		}
+	}
+	assert.Equal(t, []time.Time{
+		mustParseTime(t, "2021-03-15T03:00:00Z"),
+		mustParseTime(t, "2021-03-16T03:00:00Z"),
+		mustParseTime(t, "2021-03-17T03:00:00Z"),
+	}, ticks)
+}
+
+func TestCronStop(t *testing.T) {
+	fc := dtime.NewFakeClock()
+	ctx := dtime.WithClock(context.Background(), fc)
+
+	sched, err := dtime.ParseCronSchedule("@every 1s")
+	if !assert.NoError(t, err) {
+		return
+	}
+	cron := dtime.NewCron(ctx, sched)
+	fc.StepSec(1)
+	assert.Equal(t, 1, fc.PendingTimers())
+
+	// A tick that hasn't been received is drained.
+	cron.Stop()
+	assert.Equal(t, 0, fc.PendingTimers())
+	fc.StepSec(1)
+	select {
+	case <-cron.C:
+		t.Error("got a tick after Stop")
+	default:
+	}
+	cron.Stop() // no-op
+}
+
+func TestCronContextCancel(t *testing.T) {
+	fc := dtime.NewFakeClock()
+	ctx, cancel := context.WithCancel(dtime.WithClock(context.Background(), fc))
+	defer cancel()
+
+	sched, err := dtime.ParseCronSchedule("@every 1s")
+	if !assert.NoError(t, err) {
+		return
+	}
+	cron := dtime.NewCron(ctx, sched)
+	defer cron.Stop()
+	assert.Equal(t, 1, fc.PendingTimers())
+
+	cancel()
+	for start := time.Now(); fc.PendingTimers() != 0 && time.Since(start) < 10*time.Second; {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, 0, fc.PendingTimers())
+}
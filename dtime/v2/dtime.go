@@ -0,0 +1,69 @@
+// Package dtime (github.com/datawire/dlib/dtime/v2) provides
+// Context-aware tools for working with time.
+//
+// Unlike version 1 of the package (github.com/datawire/dlib/dtime),
+// which has a single process-wide clock that is overridden with
+// dtime.SetNow, version 2 attaches a Clock to a context.Context with
+// WithClock.  Everything in this package that needs to know the time
+// (Now, NewTimer, AfterFunc, SleepWithContext, and so on) takes a
+// Context and uses the Clock attached to it, or the real system clock
+// (StdClock) if there isn't one.  This allows tests to substitute a
+// FakeClock, and explicitly control the passage of time, without
+// affecting any other part of the process.
+//
+// The functions that wait (SleepWithContext, SleepUntil, and After)
+// also stop their timers as soon as the Context is done, rather than
+// leaving them to fire later; so unlike a select on time.After, they
+// don't hold on to resources after the caller has stopped waiting.
+package dtime
+
+import (
+	"context"
+	"time"
+
+	"github.com/datawire/dlib/dcontext"
+)
+
+// A Clock is a source of time.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// At arranges for fn to be called once the clock reaches t.
+	// It returns a function that cancels the call; that function
+	// returns true if it prevented fn from being called, and
+	// false if fn has already been called (or the call has
+	// already been canceled).
+	At(t time.Time, fn func()) (cancel func() bool)
+}
+
+type clockContextKey struct{}
+
+func init() {
+	// Have the Clock follow dcontext.Detach.
+	dcontext.RegisterDetachable(clockContextKey{})
+}
+
+// WithClock returns a copy of ctx that uses clock as its source of
+// time.
+func WithClock(ctx context.Context, clock Clock) context.Context {
+	return context.WithValue(ctx, clockContextKey{}, clock)
+}
+
+func getClock(ctx context.Context) Clock {
+	if clock, ok := ctx.Value(clockContextKey{}).(Clock); ok {
+		return clock
+	}
+	return StdClock{}
+}
+
+// Now returns the current time, according to the Clock associated
+// with the Context.
+func Now(ctx context.Context) time.Time {
+	return getClock(ctx).Now()
+}
+
+// Since returns the time elapsed since t, according to the Clock
+// associated with the Context.
+func Since(ctx context.Context, t time.Time) time.Duration {
+	return Now(ctx).Sub(t)
+}
@@ -0,0 +1,279 @@
+package dtime
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FakeClock is a Clock that keeps track of fake time, so that we don't
+// have to rely on the real system clock.  This can make life during
+// testing much, much easier -- rather than needing to wait forever,
+// you can control the passage of time however you like.
+//
+// To use FakeClock, use NewFakeClock to instantiate it, attach it to a
+// Context with WithClock, then Step (or StepSec) to change its current
+// time.  Callbacks registered with At (as by NewTimer, AfterFunc, or
+// SleepWithContext) are called synchronously by Step, in the order
+// that they are due; so once Step returns, everything that was due has
+// happened.
+//
+// A FakeClock is safe for concurrent use.  Callbacks are called without
+// any of the FakeClock's internal locks held, so a callback may call
+// At, Now, or any other non-stepping method; but a callback must not
+// call Step, StepSec, or StepUntilBlocked.
+type FakeClock struct {
+	bootTime time.Time
+
+	// stepMu serializes Step and StepUntilBlocked, so that
+	// concurrent steps don't call their callbacks out of order.  It
+	// is held while callbacks are called; mu is not.
+	stepMu sync.Mutex
+
+	mu      sync.Mutex
+	now     time.Time
+	jobs    []*fakeJob    // sorted by when, then by registration order
+	changed chan struct{} // closed and replaced whenever jobs changes
+}
+
+type fakeJob struct {
+	when time.Time
+	fn   func()
+}
+
+var _ Clock = (*FakeClock)(nil)
+
+// NewFakeClock creates a new FakeClock, booted at the current
+// (real) time.
+func NewFakeClock() *FakeClock {
+	now := time.Now()
+	return &FakeClock{
+		bootTime: now,
+		now:      now,
+		changed:  make(chan struct{}),
+	}
+}
+
+// BootTime returns the real system time at which the FakeClock was
+// instantiated.
+func (f *FakeClock) BootTime() time.Time {
+	return f.bootTime
+}
+
+// Now implements Clock by returning the current fake time.
+func (f *FakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// TimeSinceBoot returns the amount of fake time that has passed since
+// the FakeClock was instantiated.
+func (f *FakeClock) TimeSinceBoot() time.Duration {
+	return f.Now().Sub(f.bootTime)
+}
+
+// At implements Clock.  If t is not after the current fake time, then
+// fn is called immediately, in a new goroutine; otherwise it is called
+// by the Step call that advances the clock to (or past) t.
+func (f *FakeClock) At(t time.Time, fn func()) func() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !t.After(f.now) {
+		go fn()
+		return func() bool { return false }
+	}
+
+	return f.addLocked(t, fn)
+}
+
+// Schedule registers fn to be called once by the Step (or SetTime, or
+// StepUntilBlocked) call that advances the clock to or past t.  It is
+// intended for test code that needs a side effect to happen "when fake
+// time reaches t".
+//
+// Unlike At, if t is not after the current fake time then fn is not
+// called immediately; it is called by the next Step.  Callbacks that
+// are due at the same time are called in the order that they were
+// registered.  The returned function cancels the callback if it has
+// not yet been called.
+func (f *FakeClock) Schedule(t time.Time, fn func()) context.CancelFunc {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	cancel := f.addLocked(t, fn)
+	return func() { cancel() }
+}
+
+// addLocked inserts a job into f.jobs, after any other jobs with the
+// same time, and returns a function that removes it; it must be called
+// with mu held.
+func (f *FakeClock) addLocked(t time.Time, fn func()) func() bool {
+	job := &fakeJob{
+		when: t,
+		fn:   fn,
+	}
+	idx := sort.Search(len(f.jobs), func(i int) bool {
+		return f.jobs[i].when.After(t)
+	})
+	f.jobs = append(f.jobs, nil)
+	copy(f.jobs[idx+1:], f.jobs[idx:])
+	f.jobs[idx] = job
+	f.notifyLocked()
+
+	return func() bool {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for i, j := range f.jobs {
+			if j == job {
+				f.jobs = append(f.jobs[:i], f.jobs[i+1:]...)
+				f.notifyLocked()
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// notifyLocked wakes up any WaitFor calls; it must be called with mu
+// held.
+func (f *FakeClock) notifyLocked() {
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// Step steps a FakeClock by the given duration, calling any callbacks
+// that become due.  Any duration may be used, with all the obvious
+// concerns about stepping the fake clock into the past.
+func (f *FakeClock) Step(d time.Duration) {
+	f.setTime(func(now time.Time) time.Time { return now.Add(d) })
+}
+
+// SetTime sets the FakeClock's current time to t, calling any
+// callbacks that become due.  This is useful for simulating specific
+// real-world timestamps, without having to compute the delta from the
+// current fake time.
+//
+// If t is before the current fake time, then no callbacks are called;
+// pending callbacks remain scheduled for the absolute times that they
+// were registered for, which is consistent with how a real clock
+// behaves when it is stepped backward.
+func (f *FakeClock) SetTime(t time.Time) {
+	f.setTime(func(time.Time) time.Time { return t })
+}
+
+func (f *FakeClock) setTime(fn func(now time.Time) time.Time) {
+	f.stepMu.Lock()
+	defer f.stepMu.Unlock()
+
+	f.mu.Lock()
+	f.now = fn(f.now)
+	due := f.popDueLocked()
+	f.mu.Unlock()
+
+	for _, job := range due {
+		job.fn()
+	}
+}
+
+// StepSec steps a FakeClock by a given number of seconds.
+//
+// This is a convenience to allow writing unit tests that don't have to
+// have "* time.Second" scattered over and over and over again through
+// everything.
+func (f *FakeClock) StepSec(s int) {
+	f.Step(time.Duration(s) * time.Second)
+}
+
+// StepUntilBlocked advances the clock one callback at a time: it steps
+// the clock to the time of the next pending callback, calls it, and
+// repeats, until there are no more pending callbacks.  It returns the
+// number of callbacks that were called.  This allows a test to observe
+// intermediate states between callbacks, which a single large Step
+// would hide.
+//
+// So that a callback that registers another callback (such as a
+// ticker) does not cause StepUntilBlocked to run forever, the clock is
+// not advanced past the latest time that was pending when
+// StepUntilBlocked was called; callbacks registered for after that are
+// left pending.
+func (f *FakeClock) StepUntilBlocked() int {
+	f.stepMu.Lock()
+	defer f.stepMu.Unlock()
+
+	f.mu.Lock()
+	if len(f.jobs) == 0 {
+		f.mu.Unlock()
+		return 0
+	}
+	horizon := f.jobs[len(f.jobs)-1].when
+	f.mu.Unlock()
+
+	fired := 0
+	for {
+		f.mu.Lock()
+		if len(f.jobs) == 0 || f.jobs[0].when.After(horizon) {
+			f.mu.Unlock()
+			return fired
+		}
+		job := f.jobs[0]
+		f.jobs = append(f.jobs[:0], f.jobs[1:]...)
+		if job.when.After(f.now) {
+			f.now = job.when
+		}
+		f.notifyLocked()
+		f.mu.Unlock()
+
+		job.fn()
+		fired++
+	}
+}
+
+// popDueLocked removes and returns the jobs that are due at the
+// current fake time; it must be called with mu held.
+func (f *FakeClock) popDueLocked() []*fakeJob {
+	n := sort.Search(len(f.jobs), func(i int) bool {
+		return f.jobs[i].when.After(f.now)
+	})
+	if n == 0 {
+		return nil
+	}
+	due := make([]*fakeJob, n)
+	copy(due, f.jobs[:n])
+	f.jobs = append(f.jobs[:0], f.jobs[n:]...)
+	f.notifyLocked()
+	return due
+}
+
+// PendingTimers returns the number of callbacks registered with At
+// that have neither been called nor canceled.
+func (f *FakeClock) PendingTimers() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.jobs)
+}
+
+// WaitFor blocks until at least n callbacks registered with At are
+// pending (see PendingTimers), or until the Context is done, in which
+// case it returns the Context's error.
+//
+// This allows a test to wait for the code under test to set a timer
+// before it Steps the clock, rather than having to sleep and hope.
+func (f *FakeClock) WaitFor(ctx context.Context, n int) error {
+	for {
+		f.mu.Lock()
+		pending := len(f.jobs)
+		changed := f.changed
+		f.mu.Unlock()
+		if pending >= n {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package dtime_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	dtime "github.com/datawire/dlib/dtime/v2"
+)
+
+func TestFakeClockStep(t *testing.T) {
+	fc := dtime.NewFakeClock()
+	assert.Equal(t, fc.BootTime(), fc.Now())
+
+	var fired []int
+	fc.At(fc.Now().Add(2*time.Second), func() { fired = append(fired, 2) })
+	fc.At(fc.Now().Add(1*time.Second), func() { fired = append(fired, 1) })
+	cancel := fc.At(fc.Now().Add(1*time.Second), func() { fired = append(fired, -1) })
+	fc.At(fc.Now().Add(3*time.Second), func() { fired = append(fired, 3) })
+	assert.Equal(t, 4, fc.PendingTimers())
+
+	assert.True(t, cancel())
+	assert.False(t, cancel())
+	assert.Equal(t, 3, fc.PendingTimers())
+
+	fc.StepSec(2)
+	assert.Equal(t, []int{1, 2}, fired)
+	assert.Equal(t, 1, fc.PendingTimers())
+	assert.Equal(t, 2*time.Second, fc.TimeSinceBoot())
+
+	fc.StepSec(5)
+	assert.Equal(t, []int{1, 2, 3}, fired)
+	assert.Equal(t, 0, fc.PendingTimers())
+}
+
+func TestFakeClockWaitFor(t *testing.T) {
+	fc := dtime.NewFakeClock()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	go func() {
+		fc.At(fc.Now().Add(time.Second), func() {})
+		fc.At(fc.Now().Add(time.Second), func() {})
+	}()
+	assert.NoError(t, fc.WaitFor(ctx, 2))
+	assert.Equal(t, 2, fc.PendingTimers())
+
+	shortCtx, shortCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer shortCancel()
+	assert.Equal(t, context.DeadlineExceeded, fc.WaitFor(shortCtx, 3))
+}
+
+func TestFakeClockStepUntilBlocked(t *testing.T) {
+	fc := dtime.NewFakeClock()
+	start := fc.Now()
+
+	var fired []time.Duration
+	record := func() { fired = append(fired, fc.Now().Sub(start)) }
+	fc.At(start.Add(3*time.Second), record)
+	fc.At(start.Add(1*time.Second), record)
+	fc.At(start.Add(1*time.Second), record)
+
+	// A callback that re-registers itself, like a ticker.
+	var tick func()
+	tick = func() {
+		record()
+		fc.At(fc.Now().Add(time.Second), tick)
+	}
+	fc.At(start.Add(2*time.Second), tick)
+
+	assert.Equal(t, 5, fc.StepUntilBlocked())
+	assert.Equal(t, []time.Duration{
+		1 * time.Second,
+		1 * time.Second,
+		2 * time.Second,
+		3 * time.Second, // the re-registered tick is after the 3s timer
+		3 * time.Second,
+	}, fired)
+	assert.Equal(t, 3*time.Second, fc.TimeSinceBoot())
+	assert.Equal(t, 1, fc.PendingTimers())
+
+	assert.Equal(t, 0, dtime.NewFakeClock().StepUntilBlocked())
+}
+
+func TestFakeClockConcurrency(t *testing.T) {
+	const sleepers = 100
+
+	fc := dtime.NewFakeClock()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	ctx = dtime.WithClock(ctx, fc)
+
+	done := make(chan struct{}, sleepers)
+	for i := 0; i < sleepers; i++ {
+		i := i
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for j := 0; j < 3; j++ {
+				if i%2 == 0 {
+					dtime.SleepWithContext(ctx, time.Duration(i%7+1)*time.Second)
+				} else {
+					timer := dtime.NewTimer(ctx, time.Duration(i%5+1)*time.Second)
+					select {
+					case <-timer.C:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	stepperDone := make(chan struct{})
+	go func() {
+		defer close(stepperDone)
+		for ctx.Err() == nil {
+			fc.Step(1 * time.Second)
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	for i := 0; i < sleepers; i++ {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			t.Fatalf("deadlock: only %d/%d goroutines finished", i, sleepers)
+		}
+	}
+	cancel()
+	<-stepperDone
+}
+
+func TestFakeClockSetTime(t *testing.T) {
+	fc := dtime.NewFakeClock()
+	newYear := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	fc.SetTime(newYear)
+	assert.Equal(t, newYear, fc.Now())
+
+	var fired []string
+	fc.At(newYear.Add(1*time.Hour), func() { fired = append(fired, "1h") })
+	fc.At(newYear.Add(2*time.Hour), func() { fired = append(fired, "2h") })
+	fc.At(newYear.Add(3*time.Hour), func() { fired = append(fired, "3h") })
+
+	// Going forward fires the timers that become due.
+	fc.SetTime(newYear.Add(2 * time.Hour))
+	assert.Equal(t, []string{"1h", "2h"}, fired)
+	assert.Equal(t, 1, fc.PendingTimers())
+
+	// Going backward fires nothing.
+	fc.SetTime(newYear.Add(-24 * time.Hour))
+	assert.Equal(t, newYear.Add(-24*time.Hour), fc.Now())
+	assert.Equal(t, []string{"1h", "2h"}, fired)
+	assert.Equal(t, 1, fc.PendingTimers())
+
+	// The remaining timer still fires at its absolute time.
+	fc.SetTime(newYear.Add(3 * time.Hour))
+	assert.Equal(t, []string{"1h", "2h", "3h"}, fired)
+}
+
+func TestFakeClockSchedule(t *testing.T) {
+	fc := dtime.NewFakeClock()
+	start := fc.Now()
+
+	var fired []string
+	fc.Schedule(start.Add(3*time.Second), func() { fired = append(fired, "c") })
+	fc.Schedule(start.Add(1*time.Second), func() { fired = append(fired, "a") })
+	fc.Schedule(start.Add(2*time.Second), func() { fired = append(fired, "b") })
+	fc.Schedule(start.Add(1*time.Second), func() { fired = append(fired, "a2") })
+	cancel := fc.Schedule(start.Add(2*time.Second), func() { fired = append(fired, "canceled") })
+	assert.Equal(t, 5, fc.PendingTimers())
+
+	cancel()
+	cancel()
+	assert.Equal(t, 4, fc.PendingTimers())
+
+	fc.StepSec(5)
+	assert.Equal(t, []string{"a", "a2", "b", "c"}, fired)
+	assert.Equal(t, 0, fc.PendingTimers())
+
+	// A callback for a time that has already passed is not called
+	// until the next Step.
+	fired = nil
+	fc.Schedule(start, func() { fired = append(fired, "past") })
+	assert.Nil(t, fired)
+	fc.Step(0)
+	assert.Equal(t, []string{"past"}, fired)
+}
@@ -0,0 +1,41 @@
+package dtime
+
+import (
+	"context"
+	"time"
+)
+
+// SleepWithContext pauses the current goroutine for at least the
+// duration d (according to the Clock associated with the Context), or
+// until the Context is done, whichever happens first.
+//
+// Unlike a select on time.After, the timer is stopped if the Context
+// is done first, so that it can be garbage collected right away.
+func SleepWithContext(ctx context.Context, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	timer := NewTimer(ctx, d)
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+	case <-timer.C:
+	}
+}
+
+// SleepUntil pauses the current goroutine until the Clock associated
+// with the Context reaches t, or until the Context is done, whichever
+// happens first.  If t is not in the future, it returns immediately.
+func SleepUntil(ctx context.Context, t time.Time) {
+	clock := getClock(ctx)
+	if !t.After(clock.Now()) {
+		return
+	}
+	done := make(chan struct{})
+	cancel := clock.At(t, func() { close(done) })
+	select {
+	case <-ctx.Done():
+		cancel()
+	case <-done:
+	}
+}
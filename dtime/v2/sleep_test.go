@@ -0,0 +1,63 @@
+package dtime_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	dtime "github.com/datawire/dlib/dtime/v2"
+)
+
+func TestSleepUntilStdClock(t *testing.T) {
+	ctx := context.Background()
+
+	start := time.Now()
+	dtime.SleepUntil(ctx, start.Add(-time.Hour))
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	start = time.Now()
+	dtime.SleepUntil(ctx, start.Add(200*time.Millisecond))
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	dtime.SleepUntil(ctx, start.Add(time.Hour))
+	assert.Less(t, time.Since(start), time.Minute)
+}
+
+func TestSleepUntilFakeClock(t *testing.T) {
+	fc := dtime.NewFakeClock()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ctx = dtime.WithClock(ctx, fc)
+
+	// in the past
+	dtime.SleepUntil(ctx, fc.Now().Add(-time.Hour))
+	assert.Equal(t, 0, fc.PendingTimers())
+
+	// in the future
+	target := fc.Now().Add(time.Hour)
+	done := make(chan struct{})
+	go func() {
+		dtime.SleepUntil(ctx, target)
+		close(done)
+	}()
+	if !assert.NoError(t, fc.WaitFor(ctx, 1)) {
+		return
+	}
+	fc.Step(59 * time.Minute)
+	select {
+	case <-done:
+		t.Fatal("SleepUntil returned early")
+	default:
+	}
+	fc.SetTime(target)
+	select {
+	case <-done:
+	case <-ctx.Done():
+		t.Fatal("SleepUntil did not return")
+	}
+}
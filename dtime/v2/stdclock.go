@@ -0,0 +1,49 @@
+package dtime
+
+import (
+	"time"
+)
+
+// StdClock is a Clock that uses the real system clock.
+//
+// The zero StdClock{} reports the system time as-is; the fields allow
+// adjusting it, which can be useful in tests that don't want to go as
+// far as using a FakeClock.
+type StdClock struct {
+	// MinResolution, if positive, causes Now to round the time down
+	// to a multiple of MinResolution (as with time.Time.Truncate).
+	// At takes it in to account, so that fn is not called until the
+	// rounded-down Now has reached t.
+	MinResolution time.Duration
+
+	// Skew is added to every time reported by Now, simulating a
+	// system clock that is ahead (positive) or behind (negative).
+	// At takes it in to account, so that fn is still called when
+	// Now reaches t.
+	Skew time.Duration
+}
+
+var _ Clock = StdClock{}
+
+// Now implements Clock by calling time.Now.
+func (c StdClock) Now() time.Time {
+	now := time.Now()
+	if c.Skew != 0 {
+		now = now.Add(c.Skew)
+	}
+	if c.MinResolution > 0 {
+		now = now.Truncate(c.MinResolution)
+	}
+	return now
+}
+
+// At implements Clock by calling time.AfterFunc.
+func (c StdClock) At(t time.Time, fn func()) func() bool {
+	if c.MinResolution > 0 {
+		// Round up, as Now rounds down.
+		if rounded := t.Truncate(c.MinResolution); rounded.Before(t) {
+			t = rounded.Add(c.MinResolution)
+		}
+	}
+	return time.AfterFunc(time.Until(t.Add(-c.Skew)), fn).Stop
+}
@@ -0,0 +1,67 @@
+package dtime_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	dtime "github.com/datawire/dlib/dtime/v2"
+)
+
+func TestStdClockZero(t *testing.T) {
+	before := time.Now()
+	now := dtime.StdClock{}.Now()
+	after := time.Now()
+	assert.False(t, now.Before(before))
+	assert.False(t, now.After(after))
+}
+
+func TestStdClockSkew(t *testing.T) {
+	clock := dtime.StdClock{Skew: 5 * time.Minute}
+	ctx := dtime.WithClock(context.Background(), clock)
+
+	before := time.Now()
+	now := dtime.Now(ctx)
+	after := time.Now()
+	assert.False(t, now.Before(before.Add(5*time.Minute)))
+	assert.False(t, now.After(after.Add(5*time.Minute)))
+
+	// At is relative to the skewed clock.
+	fired := make(chan time.Time, 1)
+	target := clock.Now().Add(100 * time.Millisecond)
+	clock.At(target, func() { fired <- clock.Now() })
+	select {
+	case firedAt := <-fired:
+		assert.False(t, firedAt.Before(target))
+		assert.Less(t, firedAt.Sub(target), 5*time.Second)
+	case <-time.After(10 * time.Second):
+		t.Fatal("At did not fire")
+	}
+}
+
+func TestStdClockMinResolution(t *testing.T) {
+	clock := dtime.StdClock{MinResolution: time.Hour}
+	now := clock.Now()
+	assert.Equal(t, now.Truncate(time.Hour), now)
+	assert.False(t, now.After(time.Now()))
+	assert.Less(t, time.Since(now), time.Hour+time.Second)
+}
+
+func TestStdClockMinResolutionAt(t *testing.T) {
+	clock := dtime.StdClock{MinResolution: 200 * time.Millisecond, Skew: 30 * time.Millisecond}
+
+	// At is relative to the rounded-down clock, so fn isn't called
+	// until Now has reached target.
+	fired := make(chan time.Time, 1)
+	target := clock.Now().Add(50 * time.Millisecond)
+	clock.At(target, func() { fired <- clock.Now() })
+	select {
+	case firedAt := <-fired:
+		assert.False(t, firedAt.Before(target))
+		assert.Less(t, firedAt.Sub(target), 5*time.Second)
+	case <-time.After(10 * time.Second):
+		t.Fatal("At did not fire")
+	}
+}
@@ -0,0 +1,122 @@
+package dtime
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// A Ticker delivers ticks of a clock at intervals, according to a
+// Context's Clock.  It is like a *time.Ticker, but it may also be
+// paused and resumed.
+type Ticker struct {
+	C <-chan time.Time
+
+	c     chan time.Time
+	clock Clock
+	timer *FuncTimer
+
+	mu      sync.Mutex
+	period  time.Duration
+	epoch   time.Time // ticks are due at epoch+n*period
+	paused  bool
+	stopped bool
+}
+
+// NewTicker returns a new Ticker that sends the current time (as
+// reported by the Clock associated with the Context) on its channel
+// every period d.  As with time.NewTicker, ticks are dropped to make up
+// for slow receivers, and d must be greater than zero.
+func NewTicker(ctx context.Context, d time.Duration) *Ticker {
+	if d <= 0 {
+		panic("non-positive interval for dtime.NewTicker")
+	}
+	c := make(chan time.Time, 1)
+	t := &Ticker{
+		C:      c,
+		c:      c,
+		clock:  getClock(ctx),
+		period: d,
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.epoch = t.clock.Now()
+	t.timer = AfterFunc(ctx, d, t.tick)
+	return t
+}
+
+// untilNextLocked returns how long from now until the next tick is
+// due; it must be called with mu held.
+func (t *Ticker) untilNextLocked(now time.Time) time.Duration {
+	n := now.Sub(t.epoch)/t.period + 1
+	return t.epoch.Add(n * t.period).Sub(now)
+}
+
+func (t *Ticker) tick() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.paused || t.stopped {
+		return
+	}
+	now := t.clock.Now()
+	select {
+	case t.c <- now:
+	default:
+	}
+	t.timer.Reset(t.untilNextLocked(now))
+}
+
+// Stop turns off a ticker.  After Stop, no more ticks will be sent.
+// As with time.Ticker.Stop, Stop does not close the channel.
+func (t *Ticker) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopped = true
+	t.timer.Stop()
+}
+
+// Reset stops a ticker and resets its period to the specified
+// duration.  The next tick will arrive after the new period elapses.
+// If the ticker is paused, it remains paused.
+func (t *Ticker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("non-positive interval for dtime.Ticker.Reset")
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopped = false
+	t.period = d
+	t.epoch = t.clock.Now()
+	if t.paused {
+		t.timer.Stop()
+	} else {
+		t.timer.Reset(d)
+	}
+}
+
+// Pause stops ticks from being sent, without forgetting the ticker's
+// phase; see Resume.  Pausing an already-paused ticker has no effect.
+func (t *Ticker) Pause() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.paused = true
+	t.timer.Stop()
+}
+
+// Resume resumes a paused ticker.  Ticks resume at the same phase as
+// if the ticker had never been paused: the next tick is sent at the
+// next multiple of the period since the ticker was started (or last
+// Reset), rather than one full period after Resume.  Resuming a ticker
+// that isn't paused, or that has been stopped, has no effect.
+func (t *Ticker) Resume() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.paused {
+		return
+	}
+	t.paused = false
+	if t.stopped {
+		return
+	}
+	t.timer.Reset(t.untilNextLocked(t.clock.Now()))
+}
@@ -0,0 +1,76 @@
+package dtime_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	dtime "github.com/datawire/dlib/dtime/v2"
+)
+
+func TestTickerPauseResume(t *testing.T) {
+	fc := dtime.NewFakeClock()
+	ctx := dtime.WithClock(context.Background(), fc)
+
+	steady := dtime.NewTicker(ctx, 10*time.Second)
+	defer steady.Stop()
+	paused := dtime.NewTicker(ctx, 10*time.Second)
+	defer paused.Stop()
+
+	drain := func(ticker *dtime.Ticker, ticks *[]time.Duration) {
+		select {
+		case ts := <-ticker.C:
+			*ticks = append(*ticks, ts.Sub(fc.BootTime()))
+		default:
+		}
+	}
+
+	var steadyTicks, pausedTicks []time.Duration
+	for sec := 1; sec <= 60; sec++ {
+		switch sec {
+		case 15:
+			paused.Pause()
+		case 37:
+			paused.Resume()
+		}
+		fc.StepSec(1)
+		drain(steady, &steadyTicks)
+		drain(paused, &pausedTicks)
+	}
+
+	assert.Equal(t, []time.Duration{
+		10 * time.Second, 20 * time.Second, 30 * time.Second,
+		40 * time.Second, 50 * time.Second, 60 * time.Second,
+	}, steadyTicks)
+	assert.Equal(t, []time.Duration{
+		10 * time.Second,
+		40 * time.Second, 50 * time.Second, 60 * time.Second,
+	}, pausedTicks)
+}
+
+func TestTickerStopReset(t *testing.T) {
+	fc := dtime.NewFakeClock()
+	ctx := dtime.WithClock(context.Background(), fc)
+
+	ticker := dtime.NewTicker(ctx, 10*time.Second)
+	ticker.Stop()
+	fc.StepSec(20)
+	select {
+	case <-ticker.C:
+		t.Fatal("stopped ticker ticked")
+	default:
+	}
+	assert.Equal(t, 0, fc.PendingTimers())
+
+	ticker.Reset(5 * time.Second)
+	fc.StepSec(5)
+	select {
+	case ts := <-ticker.C:
+		assert.Equal(t, 25*time.Second, ts.Sub(fc.BootTime()))
+	default:
+		t.Fatal("reset ticker did not tick")
+	}
+	ticker.Stop()
+}
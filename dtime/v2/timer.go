@@ -0,0 +1,128 @@
+package dtime
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// A FuncTimer calls a function once, after a delay, according to a
+// Context's Clock.  It is like the *time.Timer returned by
+// time.AfterFunc.
+type FuncTimer struct {
+	clock Clock
+	fn    func()
+
+	mu     sync.Mutex
+	cancel func() bool
+}
+
+// AfterFunc waits for the duration to elapse (according to the Clock
+// associated with the Context) and then calls fn.  It returns a
+// FuncTimer that can be used to cancel the call using its Stop method.
+func AfterFunc(ctx context.Context, d time.Duration, fn func()) *FuncTimer {
+	t := &FuncTimer{
+		clock: getClock(ctx),
+		fn:    fn,
+	}
+	t.cancel = t.clock.At(t.clock.Now().Add(d), t.fn)
+	return t
+}
+
+// Stop prevents the FuncTimer from firing.  It returns true if the
+// call stops the timer, false if the timer has already fired or been
+// stopped.
+func (t *FuncTimer) Stop() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cancel()
+}
+
+// Reset changes the timer to fire after duration d (from now).  It
+// returns true if the timer had been active, false if the timer had
+// fired or been stopped.
+func (t *FuncTimer) Reset(d time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	active := t.cancel()
+	t.cancel = t.clock.At(t.clock.Now().Add(d), t.fn)
+	return active
+}
+
+// A Timer sends the current time on its channel after a delay,
+// according to a Context's Clock.  It is like a *time.Timer.
+type Timer struct {
+	C <-chan time.Time
+	t *FuncTimer
+}
+
+// NewTimer creates a new Timer that will send the current time (as
+// reported by the Clock associated with the Context) on its channel
+// after at least duration d.
+func NewTimer(ctx context.Context, d time.Duration) *Timer {
+	clock := getClock(ctx)
+	c := make(chan time.Time, 1)
+	return &Timer{
+		C: c,
+		t: AfterFunc(ctx, d, func() {
+			select {
+			case c <- clock.Now():
+			default:
+			}
+		}),
+	}
+}
+
+// Stop prevents the Timer from firing.  It returns true if the call
+// stops the timer, false if the timer has already expired or been
+// stopped.  As with time.Timer.Stop, Stop does not drain the channel.
+func (t *Timer) Stop() bool {
+	return t.t.Stop()
+}
+
+// Reset changes the timer to expire after duration d.  It returns true
+// if the timer had been active, false if the timer had expired or been
+// stopped.  As with time.Timer.Reset, it should only be called on a
+// stopped or expired timer with a drained channel.
+func (t *Timer) Reset(d time.Duration) bool {
+	return t.t.Reset(d)
+}
+
+// After waits for the duration to elapse (according to the Clock
+// associated with the Context) and then sends the current time on the
+// returned channel.  It is like time.After, except that if the Context
+// is done first, then the underlying timer is stopped (and nothing is
+// ever sent on the channel).
+//
+// This matters when After is used in a select with the Context:
+//
+//	select {
+//	case <-ctx.Done():
+//		return ctx.Err()
+//	case <-dtime.After(ctx, time.Hour):
+//	}
+//
+// With time.After on Go versions before 1.23, each time that the
+// Context wins the select, the timer (and everything that it
+// references) is kept alive until the full duration has elapsed; in a
+// loop, that adds up.  With dtime.After, it is released as soon as the
+// Context is done.  See BenchmarkLeakComparison for a demonstration.
+func After(ctx context.Context, d time.Duration) <-chan time.Time {
+	clock := getClock(ctx)
+	c := make(chan time.Time, 1)
+	fired := make(chan struct{})
+	timer := AfterFunc(ctx, d, func() {
+		c <- clock.Now()
+		close(fired)
+	})
+	if done := ctx.Done(); done != nil {
+		go func() {
+			select {
+			case <-done:
+				timer.Stop()
+			case <-fired:
+			}
+		}()
+	}
+	return c
+}
@@ -0,0 +1,84 @@
+package dtime_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	dtime "github.com/datawire/dlib/dtime/v2"
+)
+
+func TestNewTimer(t *testing.T) {
+	fc := dtime.NewFakeClock()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ctx = dtime.WithClock(ctx, fc)
+
+	fired := make(chan time.Time)
+	go func() {
+		timer := dtime.NewTimer(ctx, 5*time.Second)
+		fired <- <-timer.C
+	}()
+
+	if !assert.NoError(t, fc.WaitFor(ctx, 1)) {
+		return
+	}
+	fc.StepSec(4)
+	select {
+	case <-fired:
+		t.Fatal("timer fired early")
+	default:
+	}
+	fc.StepSec(1)
+	select {
+	case ts := <-fired:
+		assert.Equal(t, fc.BootTime().Add(5*time.Second), ts)
+	case <-ctx.Done():
+		t.Fatal("timer did not fire")
+	}
+}
+
+func TestTimerStopReset(t *testing.T) {
+	fc := dtime.NewFakeClock()
+	ctx := dtime.WithClock(context.Background(), fc)
+
+	timer := dtime.NewTimer(ctx, 5*time.Second)
+	assert.True(t, timer.Stop())
+	assert.False(t, timer.Stop())
+	assert.Equal(t, 0, fc.PendingTimers())
+
+	assert.False(t, timer.Reset(2*time.Second))
+	assert.Equal(t, 1, fc.PendingTimers())
+	fc.StepSec(2)
+	select {
+	case ts := <-timer.C:
+		assert.Equal(t, fc.BootTime().Add(2*time.Second), ts)
+	default:
+		t.Fatal("timer did not fire")
+	}
+	assert.False(t, timer.Stop())
+}
+
+func TestSleepWithContext(t *testing.T) {
+	fc := dtime.NewFakeClock()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ctx = dtime.WithClock(ctx, fc)
+
+	done := make(chan struct{})
+	go func() {
+		dtime.SleepWithContext(ctx, time.Minute)
+		close(done)
+	}()
+	if !assert.NoError(t, fc.WaitFor(ctx, 1)) {
+		return
+	}
+	fc.Step(time.Minute)
+	select {
+	case <-done:
+	case <-ctx.Done():
+		t.Fatal("sleep did not return")
+	}
+}